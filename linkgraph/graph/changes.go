@@ -0,0 +1,77 @@
+package graph
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChangeType identifies the kind of mutation described by a ChangeEvent.
+type ChangeType uint8
+
+const (
+	// ChangeLinkUpserted is emitted when a link is created or updated.
+	ChangeLinkUpserted ChangeType = iota + 1
+
+	// ChangeEdgeUpserted is emitted when an edge is created or updated.
+	ChangeEdgeUpserted
+
+	// ChangeStaleEdgesRemoved is emitted when RemoveStaleEdges removes at
+	// least one edge.
+	ChangeStaleEdgesRemoved
+)
+
+// String implements fmt.Stringer.
+func (t ChangeType) String() string {
+	switch t {
+	case ChangeLinkUpserted:
+		return "link_upserted"
+	case ChangeEdgeUpserted:
+		return "edge_upserted"
+	case ChangeStaleEdgesRemoved:
+		return "stale_edges_removed"
+	default:
+		return "unknown"
+	}
+}
+
+// ChangeEvent describes a mutation applied to a graph.
+type ChangeEvent struct {
+	Type ChangeType
+
+	// Link is set for ChangeLinkUpserted events and Edge is set for
+	// ChangeEdgeUpserted events. Both hold the stored state of the item.
+	Link *Link
+	Edge *Edge
+
+	// Src and UpdatedBefore hold the arguments passed to RemoveStaleEdges
+	// for ChangeStaleEdgesRemoved events.
+	Src           uuid.UUID
+	UpdatedBefore time.Time
+}
+
+// ChangeIterator is implemented by objects that stream change events.
+type ChangeIterator interface {
+	// Next blocks until the next event is available. It returns false
+	// if the subscription is closed, its context is cancelled or an error
+	// occurs.
+	Next() bool
+
+	// Event returns the event obtained by the last call to Next.
+	Event() *ChangeEvent
+
+	// Error returns the last error encountered by the iterator.
+	Error() error
+
+	// Close terminates the subscription.
+	Close() error
+}
+
+// ChangeSubscriber is implemented by graphs that can notify subscribers
+// about link and edge mutations.
+type ChangeSubscriber interface {
+	// Subscribe returns an iterator that yields the changes applied to the
+	// graph after the call, in the order in which they were applied.
+	Subscribe(ctx context.Context) (ChangeIterator, error)
+}
@@ -1,6 +1,10 @@
 package graph
 
-import "golang.org/x/xerrors"
+import (
+	"fmt"
+
+	"golang.org/x/xerrors"
+)
 
 var (
 	// ErrNotFound is returned when a link or edge lookup fails.
@@ -9,4 +13,52 @@ var (
 	// ErrUnknownEdgeLinks is returned when attempting to create an edge
 	// with an invalid source and/or destination ID
 	ErrUnknownEdgeLinks = xerrors.New("unknown source and/or destination for edge")
+
+	// ErrInvalidCursor is returned when attempting to resume an iterator
+	// with a malformed cursor token.
+	ErrInvalidCursor = xerrors.New("invalid iterator cursor")
+
+	// ErrConflict is returned when restoring a link or edge that clashes
+	// with an existing one with a different ID.
+	ErrConflict = xerrors.New("conflicts with an existing item")
+
+	// ErrSubscriberLagged is returned by change iterators whose consumer
+	// could not keep up with the rate of changes.
+	ErrSubscriberLagged = xerrors.New("change subscriber lagged behind")
+
+	// ErrInvalidLimit is returned when a negative result limit is
+	// requested.
+	ErrInvalidLimit = xerrors.New("invalid limit")
+
+	// ErrNotSupported is returned by graph decorators when the wrapped
+	// graph does not implement the requested optional interface.
+	ErrNotSupported = xerrors.New("operation not supported")
 )
+
+// BatchError is returned by batch operations when some of the items in the
+// batch could not be processed. Errors contains one entry per batch item;
+// entries for items that were processed successfully are nil.
+type BatchError struct {
+	Errors []error
+}
+
+// Error implements the error interface.
+func (e *BatchError) Error() string {
+	var failed int
+	for _, err := range e.Errors {
+		if err != nil {
+			failed++
+		}
+	}
+	return fmt.Sprintf("%d of %d batch items failed", failed, len(e.Errors))
+}
+
+// Is returns true if any of the per-item errors matches target.
+func (e *BatchError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if err != nil && xerrors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,17 +1,30 @@
 package graph
 
 import (
+	"context"
+	"hash/fnv"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"golang.org/x/xerrors"
 )
 
 type Iterator interface {
 	// If next item doesn't exist or an error occours, Next() returns false.
 	Next() bool
 
+	// Error returns the last error encountered by the iterator. If the
+	// context the iterator was created with gets cancelled, Error returns
+	// the context's error.
 	Error() error
 
+	// Cursor returns an opaque token that can be passed to WithCursor to
+	// resume the scan right after the last item returned by the iterator.
+	// Items are always returned in ascending ID order.
+	Cursor() string
+
 	Close() error
 }
 
@@ -20,6 +33,75 @@ type Link struct {
 	ID          uuid.UUID
 	URL         string
 	RetrievedAt time.Time
+
+	Metadata LinkMetadata
+}
+
+// LinkMetadata holds the information collected about a link while crawling
+// it. Zero-valued fields are treated as unset and never overwrite existing
+// values when a link is upserted.
+type LinkMetadata struct {
+	// The HTTP status code and content type returned by the last fetch.
+	StatusCode  int
+	ContentType string
+
+	// A hash of the content returned by the last fetch.
+	ContentHash string
+
+	// The ID of the link that this link redirects to.
+	RedirectTo uuid.UUID
+
+	// The error encountered by the last fetch attempt, if any.
+	FetchError string
+
+	// The number of hops from a seed link to this link. As zero denotes an
+	// unknown depth, seed links should be assigned a depth of 1.
+	CrawlDepth int
+
+	// The time when the link was first discovered.
+	DiscoveredAt time.Time
+
+	// The crawl schedule of the link, as maintained by ScheduleNextFetch.
+	// Higher priority links are fetched first.
+	NextFetchAt   time.Time
+	FetchInterval time.Duration
+	Priority      int
+}
+
+// Merge applies the set fields of other to m. CrawlDepth and DiscoveredAt
+// keep the smallest set value; all other set fields in other replace the
+// values in m.
+func (m *LinkMetadata) Merge(other LinkMetadata) {
+	if other.StatusCode != 0 {
+		m.StatusCode = other.StatusCode
+	}
+	if other.ContentType != "" {
+		m.ContentType = other.ContentType
+	}
+	if other.ContentHash != "" {
+		m.ContentHash = other.ContentHash
+	}
+	if other.RedirectTo != uuid.Nil {
+		m.RedirectTo = other.RedirectTo
+	}
+	if other.FetchError != "" {
+		m.FetchError = other.FetchError
+	}
+	if other.CrawlDepth != 0 && (m.CrawlDepth == 0 || other.CrawlDepth < m.CrawlDepth) {
+		m.CrawlDepth = other.CrawlDepth
+	}
+	if !other.DiscoveredAt.IsZero() && (m.DiscoveredAt.IsZero() || other.DiscoveredAt.Before(m.DiscoveredAt)) {
+		m.DiscoveredAt = other.DiscoveredAt
+	}
+	if !other.NextFetchAt.IsZero() {
+		m.NextFetchAt = other.NextFetchAt
+	}
+	if other.FetchInterval != 0 {
+		m.FetchInterval = other.FetchInterval
+	}
+	if other.Priority != 0 {
+		m.Priority = other.Priority
+	}
 }
 
 // Edge describes a graph edge that originates from src and terminates at Dst
@@ -28,6 +110,148 @@ type Edge struct {
 	Src       uuid.UUID
 	Dst       uuid.UUID
 	UpdatedAt time.Time
+
+	// The text of the anchor element that links Src to Dst.
+	AnchorText string
+
+	// The rel attribute flags of the anchor element.
+	NoFollow  bool
+	Sponsored bool
+	UGC       bool
+
+	// The position of the anchor element among the links of the Src page.
+	Position int
+}
+
+// Stats summarizes the contents of a graph.
+type Stats struct {
+	Links int
+	Edges int
+
+	// UncrawledLinks is the number of links with a zero RetrievedAt.
+	UncrawledLinks int
+
+	// OutDegreeHistogram maps out-degrees to the number of links with
+	// that many outgoing edges.
+	OutDegreeHistogram map[int]int
+
+	// HostLinks maps host names, as returned by LinkHost, to the number
+	// of links pointing to each host.
+	HostLinks map[string]int
+}
+
+// LinkHost returns the lower-cased host name of rawURL without any port
+// number. It returns an empty string if rawURL cannot be parsed.
+func LinkHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// HostHash returns a stable 32-bit FNV-1a hash of the host name of rawURL,
+// as returned by LinkHost. Links that share a host always have the same hash
+// and can therefore be assigned to the same partition.
+func HostHash(rawURL string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(LinkHost(rawURL)))
+	return h.Sum32()
+}
+
+// HostHashRange is a [From, To) range of host hashes.
+type HostHashRange struct {
+	From uint64
+	To   uint64
+}
+
+// Contains returns true if hash belongs to the range.
+func (r HostHashRange) Contains(hash uint32) bool {
+	return uint64(hash) >= r.From && uint64(hash) < r.To
+}
+
+// HostPartition splits the host hash space into numPartitions ranges of
+// equal size and returns the range for the specified partition.
+func HostPartition(partition, numPartitions int) (HostHashRange, error) {
+	if numPartitions <= 0 || partition < 0 || partition >= numPartitions {
+		return HostHashRange{}, xerrors.Errorf("host partition: invalid partition %d of %d", partition, numPartitions)
+	}
+
+	const hashSpace = uint64(1) << 32
+	size := hashSpace / uint64(numPartitions)
+	r := HostHashRange{From: uint64(partition) * size, To: uint64(partition+1) * size}
+	if partition == numPartitions-1 {
+		r.To = hashSpace
+	}
+	return r, nil
+}
+
+// IteratorOptions configures the items returned by graph iterators.
+type IteratorOptions struct {
+	// ExcludeNoFollow skips edges whose NoFollow flag is set.
+	ExcludeNoFollow bool
+
+	// Cursor resumes a scan after the item that the cursor points to.
+	Cursor string
+
+	// PageSize limits the number of items that stores fetch in one go. If
+	// zero, a store-specific default is used.
+	PageSize int
+
+	// HostHashes, if set, restricts link iterators to links whose
+	// HostHash belongs to the range.
+	HostHashes *HostHashRange
+}
+
+// IteratorOption is a function that configures IteratorOptions.
+type IteratorOption func(*IteratorOptions)
+
+// ExcludeNoFollow configures edge iterators to skip nofollow edges.
+func ExcludeNoFollow() IteratorOption {
+	return func(o *IteratorOptions) { o.ExcludeNoFollow = true }
+}
+
+// WithCursor configures iterators to resume a scan using a token obtained
+// by calling Cursor on an iterator created with the same arguments.
+func WithCursor(cursor string) IteratorOption {
+	return func(o *IteratorOptions) { o.Cursor = cursor }
+}
+
+// WithPageSize configures the number of items that iterators fetch from
+// the store in one go.
+func WithPageSize(pageSize int) IteratorOption {
+	return func(o *IteratorOptions) { o.PageSize = pageSize }
+}
+
+// WithHostHashRange configures link iterators to only return links whose
+// host hash belongs to r. Combined with HostPartition, it allows each worker
+// to own all links of a host.
+func WithHostHashRange(r HostHashRange) IteratorOption {
+	return func(o *IteratorOptions) { o.HostHashes = &r }
+}
+
+// ParseCursor decodes a cursor token into the ID of the last item returned
+// by the iterator that generated it. An empty token decodes to uuid.Nil.
+func ParseCursor(cursor string) (uuid.UUID, error) {
+	if cursor == "" {
+		return uuid.Nil, nil
+	}
+
+	id, err := uuid.Parse(cursor)
+	if err != nil {
+		return uuid.Nil, ErrInvalidCursor
+	}
+	return id, nil
+}
+
+// ApplyIteratorOptions returns the IteratorOptions obtained by applying opts
+// in order.
+func ApplyIteratorOptions(opts ...IteratorOption) IteratorOptions {
+	var o IteratorOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
 }
 
 type LinkIterator interface {
@@ -42,13 +266,50 @@ type EdgeIterator interface {
 	Edge() *Edge
 }
 
+// Graph is implemented by objects that can mutate or query a link graph.
+// All methods accept a context that can be used to cancel long-running
+// operations or to enforce deadlines.
 type Graph interface {
-	UpsertLink(link *Link) error
-	FindLink(id uuid.UUID) (*Link, error)
+	UpsertLink(ctx context.Context, link *Link) error
+	UpsertLinks(ctx context.Context, links []*Link) error
+	FindLink(ctx context.Context, id uuid.UUID) (*Link, error)
+	FindLinkByURL(ctx context.Context, url string) (*Link, error)
+	FindLinksByURL(ctx context.Context, urls []string) ([]*Link, error)
+	RemoveLink(ctx context.Context, id uuid.UUID) error
+
+	UpsertEdge(ctx context.Context, edge *Edge) error
+	UpsertEdges(ctx context.Context, edges []*Edge) error
+	RemoveEdge(ctx context.Context, id uuid.UUID) error
+	RemoveStaleEdges(ctx context.Context, fromID uuid.UUID, updatedBefore time.Time) error
+
+	Links(ctx context.Context, fromID, toID uuid.UUID, retrievedBefore time.Time, opts ...IteratorOption) (LinkIterator, error)
+	Edges(ctx context.Context, fromID, toID uuid.UUID, updatedBefore time.Time, opts ...IteratorOption) (EdgeIterator, error)
+	InboundEdges(ctx context.Context, dstID uuid.UUID, updatedBefore time.Time, opts ...IteratorOption) (EdgeIterator, error)
+
+	InDegree(ctx context.Context, id uuid.UUID) (int, error)
+	OutDegree(ctx context.Context, id uuid.UUID) (int, error)
+
+	Stats(ctx context.Context) (*Stats, error)
+
+	// MostDueLinks returns up to limit links in the [fromID, toID) range
+	// that are due at dueAt, ordered as by SortByDue. Of the iterator
+	// options, only WithHostHashRange is taken into account.
+	MostDueLinks(ctx context.Context, fromID, toID uuid.UUID, dueAt time.Time, limit int, opts ...IteratorOption) ([]*Link, error)
+}
 
-	UpsertEdge(edge *Edge) error
-	RemoveStaleEdges(fromID uuid.UUID, updatedBefore time.Time) error
+// Restorer is implemented by graphs that can store links and edges
+// verbatim, preserving their IDs and timestamps. It allows data to be moved
+// between graph instances without reassigning IDs.
+type Restorer interface {
+	// RestoreLinks inserts links or replaces the links with the same ID.
+	// A zero DiscoveredAt is set to the current time. It fails with
+	// ErrConflict if a link URL is already in use by a link with a
+	// different ID.
+	RestoreLinks(ctx context.Context, links []*Link) error
 
-	Links(fromID, toID uuid.UUID, retrievedBefore time.Time) (LinkIterator, error)
-	Edges(fromID, toID uuid.UUID, updatedBefore time.Time) (EdgeIterator, error)
+	// RestoreEdges inserts edges or replaces the edges with the same ID.
+	// It fails with ErrUnknownEdgeLinks if an edge endpoint does not exist
+	// and with ErrConflict if another edge already connects the same pair
+	// of links.
+	RestoreEdges(ctx context.Context, edges []*Edge) error
 }
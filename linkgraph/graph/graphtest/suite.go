@@ -1,6 +1,7 @@
 package graphtest
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 	"sort"
@@ -32,7 +33,7 @@ func (s *SuiteBase) TestUpsertLink(c *gc.C) {
 		RetrievedAt: time.Now().Add(-10 * time.Hour),
 	}
 
-	err := s.g.UpsertLink(original)
+	err := s.g.UpsertLink(context.TODO(), original)
 	c.Assert(err, gc.IsNil)
 	c.Assert(original.ID, gc.Not(gc.Equals), uuid.Nil, gc.Commentf("expected a linkID to be assigned to the new link"))
 
@@ -43,11 +44,11 @@ func (s *SuiteBase) TestUpsertLink(c *gc.C) {
 		URL:         "https://example.com",
 		RetrievedAt: accessedAt,
 	}
-	err = s.g.UpsertLink(existing)
+	err = s.g.UpsertLink(context.TODO(), existing)
 	c.Assert(err, gc.IsNil)
 	c.Assert(existing.ID, gc.Equals, original.ID, gc.Commentf("link ID changed while upserting"))
 
-	stored, err := s.g.FindLink(existing.ID)
+	stored, err := s.g.FindLink(context.TODO(), existing.ID)
 	c.Assert(err, gc.IsNil)
 	c.Assert(stored.RetrievedAt, gc.Equals, accessedAt, gc.Commentf("last accessed timestamp was not updated"))
 
@@ -57,11 +58,11 @@ func (s *SuiteBase) TestUpsertLink(c *gc.C) {
 		URL:         existing.URL,
 		RetrievedAt: time.Now().Add(-10 * time.Hour).UTC(),
 	}
-	err = s.g.UpsertLink(sameURL)
+	err = s.g.UpsertLink(context.TODO(), sameURL)
 	c.Assert(err, gc.IsNil)
 	c.Assert(sameURL.ID, gc.Equals, existing.ID)
 
-	stored, err = s.g.FindLink(existing.ID)
+	stored, err = s.g.FindLink(context.TODO(), existing.ID)
 	c.Assert(err, gc.IsNil)
 	c.Assert(stored.RetrievedAt, gc.Equals, accessedAt, gc.Commentf("last accessed timestamp was overwritten with an older value"))
 
@@ -70,11 +71,76 @@ func (s *SuiteBase) TestUpsertLink(c *gc.C) {
 	dup := &graph.Link{
 		URL: "foo",
 	}
-	err = s.g.UpsertLink(dup)
+	err = s.g.UpsertLink(context.TODO(), dup)
 	c.Assert(err, gc.IsNil)
 	c.Assert(dup.ID, gc.Not(gc.Equals), uuid.Nil, gc.Commentf("expected a linkID to be assigned to the new link"))
 }
 
+// TestUpsertLinkMetadata verifies that link metadata is persisted and that
+// upserts merge new metadata with the existing values.
+func (s *SuiteBase) TestUpsertLinkMetadata(c *gc.C) {
+	target := &graph.Link{URL: "https://example.com/target"}
+	c.Assert(s.g.UpsertLink(context.TODO(), target), gc.IsNil)
+	c.Assert(target.Metadata.DiscoveredAt.IsZero(), gc.Equals, false, gc.Commentf("expected DiscoveredAt to be populated"))
+
+	discoveredAt := time.Now().Add(-time.Hour).Truncate(time.Second).UTC()
+	original := &graph.Link{
+		URL: "https://example.com",
+		Metadata: graph.LinkMetadata{
+			StatusCode:   200,
+			ContentType:  "text/html",
+			ContentHash:  "cafebabe",
+			CrawlDepth:   3,
+			DiscoveredAt: discoveredAt,
+		},
+	}
+	c.Assert(s.g.UpsertLink(context.TODO(), original), gc.IsNil)
+
+	stored, err := s.g.FindLink(context.TODO(), original.ID)
+	c.Assert(err, gc.IsNil)
+	c.Assert(stored, gc.DeepEquals, original)
+
+	// Upsert a partial update; unset fields should not clobber the
+	// existing values.
+	update := &graph.Link{
+		URL: "https://example.com",
+		Metadata: graph.LinkMetadata{
+			StatusCode:   301,
+			RedirectTo:   target.ID,
+			FetchError:   "too many redirects",
+			CrawlDepth:   5,
+			DiscoveredAt: discoveredAt.Add(time.Minute),
+		},
+	}
+	c.Assert(s.g.UpsertLink(context.TODO(), update), gc.IsNil)
+	c.Assert(update.ID, gc.Equals, original.ID)
+
+	stored, err = s.g.FindLink(context.TODO(), original.ID)
+	c.Assert(err, gc.IsNil)
+	c.Assert(stored.Metadata, gc.DeepEquals, graph.LinkMetadata{
+		StatusCode:   301,
+		ContentType:  "text/html",
+		ContentHash:  "cafebabe",
+		RedirectTo:   target.ID,
+		FetchError:   "too many redirects",
+		CrawlDepth:   3,
+		DiscoveredAt: discoveredAt,
+	})
+	c.Assert(update, gc.DeepEquals, stored, gc.Commentf("expected the upserted link to reflect the merged metadata"))
+
+	// A shallower crawl depth should replace the existing one
+	c.Assert(s.g.UpsertLinks(context.TODO(), []*graph.Link{
+		{URL: "https://example.com", Metadata: graph.LinkMetadata{CrawlDepth: 2}},
+		{URL: "https://example.com", Metadata: graph.LinkMetadata{ContentHash: "deadbeef"}},
+	}), gc.IsNil)
+
+	stored, err = s.g.FindLinkByURL(context.TODO(), "https://example.com")
+	c.Assert(err, gc.IsNil)
+	c.Assert(stored.Metadata.CrawlDepth, gc.Equals, 2)
+	c.Assert(stored.Metadata.ContentHash, gc.Equals, "deadbeef")
+	c.Assert(stored.Metadata.StatusCode, gc.Equals, 301)
+}
+
 // TestFindLink verifies the link lookup logic.
 func (s *SuiteBase) TestFindLink(c *gc.C) {
 	// Create a new link
@@ -83,20 +149,62 @@ func (s *SuiteBase) TestFindLink(c *gc.C) {
 		RetrievedAt: time.Now().Truncate(time.Second).UTC(),
 	}
 
-	err := s.g.UpsertLink(link)
+	err := s.g.UpsertLink(context.TODO(), link)
 	c.Assert(err, gc.IsNil)
 	c.Assert(link.ID, gc.Not(gc.Equals), uuid.Nil, gc.Commentf("expected a linkID to be assigned to the new link"))
 
 	// Lookup link by ID
-	other, err := s.g.FindLink(link.ID)
+	other, err := s.g.FindLink(context.TODO(), link.ID)
 	c.Assert(err, gc.IsNil)
 	c.Assert(other, gc.DeepEquals, link, gc.Commentf("lookup by ID returned the wrong link"))
 
 	// Lookup link by unknown ID
-	_, err = s.g.FindLink(uuid.Nil)
+	_, err = s.g.FindLink(context.TODO(), uuid.Nil)
 	c.Assert(xerrors.Is(err, graph.ErrNotFound), gc.Equals, true)
 }
 
+// TestFindLinkByURL verifies the link lookup by URL logic.
+func (s *SuiteBase) TestFindLinkByURL(c *gc.C) {
+	link := &graph.Link{
+		URL:         "https://example.com",
+		RetrievedAt: time.Now().Truncate(time.Second).UTC(),
+	}
+	c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
+
+	other, err := s.g.FindLinkByURL(context.TODO(), link.URL)
+	c.Assert(err, gc.IsNil)
+	c.Assert(other, gc.DeepEquals, link, gc.Commentf("lookup by URL returned the wrong link"))
+
+	_, err = s.g.FindLinkByURL(context.TODO(), "https://example.com/unknown")
+	c.Assert(xerrors.Is(err, graph.ErrNotFound), gc.Equals, true)
+}
+
+// TestFindLinksByURL verifies the batched link lookup by URL logic.
+func (s *SuiteBase) TestFindLinksByURL(c *gc.C) {
+	var (
+		urls     []string
+		expLinks []*graph.Link
+	)
+	for i := 0; i < 5; i++ {
+		link := &graph.Link{
+			URL:         fmt.Sprintf("https://example.com/%d", i),
+			RetrievedAt: time.Now().Truncate(time.Second).UTC(),
+		}
+		c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
+		urls = append(urls, link.URL, "https://example.com/unknown")
+		expLinks = append(expLinks, link)
+	}
+	urls = append(urls, urls[0])
+
+	got, err := s.g.FindLinksByURL(context.TODO(), urls)
+	c.Assert(err, gc.IsNil)
+	c.Assert(got, gc.DeepEquals, expLinks)
+
+	got, err = s.g.FindLinksByURL(context.TODO(), []string{"https://example.com/unknown"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(got, gc.HasLen, 0)
+}
+
 // TestConcurrentLinkIterators verifies that multiple clients can concurrently
 // access the store.
 func (s *SuiteBase) TestConcurrentLinkIterators(c *gc.C) {
@@ -108,7 +216,7 @@ func (s *SuiteBase) TestConcurrentLinkIterators(c *gc.C) {
 
 	for i := 0; i < numLinks; i++ {
 		link := &graph.Link{URL: fmt.Sprint(i)}
-		c.Assert(s.g.UpsertLink(link), gc.IsNil)
+		c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
 	}
 
 	wg.Add(numIterators)
@@ -158,7 +266,7 @@ func (s *SuiteBase) TestLinkIteratorTimeFilter(c *gc.C) {
 	linkInsertTimes := make([]time.Time, len(linkUUIDs))
 	for i := 0; i < len(linkUUIDs); i++ {
 		link := &graph.Link{URL: fmt.Sprint(i), RetrievedAt: time.Now()}
-		c.Assert(s.g.UpsertLink(link), gc.IsNil)
+		c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
 		linkUUIDs[i] = link.ID
 		linkInsertTimes[i] = time.Now()
 	}
@@ -191,7 +299,7 @@ func (s *SuiteBase) TestPartitionedLinkIterators(c *gc.C) {
 	numLinks := 100
 	numPartitions := 10
 	for i := 0; i < numLinks; i++ {
-		c.Assert(s.g.UpsertLink(&graph.Link{URL: fmt.Sprint(i)}), gc.IsNil)
+		c.Assert(s.g.UpsertLink(context.TODO(), &graph.Link{URL: fmt.Sprint(i)}), gc.IsNil)
 	}
 
 	// Check with both odd and even partition counts to check for rounding-related bugs.
@@ -222,13 +330,153 @@ func (s *SuiteBase) iteratePartitionedLinks(c *gc.C, numPartitions int) int {
 	return len(seen)
 }
 
+// TestHostPartitionedLinkIterators verifies that host hash partitions cover
+// all links and that all links of a host belong to the same partition.
+func (s *SuiteBase) TestHostPartitionedLinkIterators(c *gc.C) {
+	numHosts, linksPerHost := 20, 5
+	for host := 0; host < numHosts; host++ {
+		for i := 0; i < linksPerHost; i++ {
+			link := &graph.Link{URL: fmt.Sprintf("https://host-%d.example.com/%d", host, i)}
+			c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
+		}
+	}
+
+	for _, numPartitions := range []int{1, 4, 7} {
+		var (
+			numLinks         int
+			hostPartitions   = make(map[string]int)
+			minUUID, maxUUID = s.partitionRange(c, 0, 1)
+		)
+		for partition := 0; partition < numPartitions; partition++ {
+			r, err := graph.HostPartition(partition, numPartitions)
+			c.Assert(err, gc.IsNil)
+
+			it, err := s.g.Links(context.TODO(), minUUID, maxUUID, time.Now(), graph.WithHostHashRange(r))
+			c.Assert(err, gc.IsNil)
+			for it.Next() {
+				host := graph.LinkHost(it.Link().URL)
+				if p, seen := hostPartitions[host]; seen {
+					c.Assert(p, gc.Equals, partition, gc.Commentf("links of host %q returned by different partitions", host))
+				}
+				hostPartitions[host] = partition
+				numLinks++
+			}
+			c.Assert(it.Error(), gc.IsNil)
+			c.Assert(it.Close(), gc.IsNil)
+		}
+
+		c.Assert(numLinks, gc.Equals, numHosts*linksPerHost)
+		c.Assert(hostPartitions, gc.HasLen, numHosts)
+	}
+
+	_, err := graph.HostPartition(4, 4)
+	c.Assert(err, gc.NotNil)
+}
+
+// TestHostHashOfUnusualURLs verifies that host hash ranges select links
+// using the host returned by graph.LinkHost, even for URLs with IPv6 hosts,
+// userinfo, no scheme or an unparsable host.
+func (s *SuiteBase) TestHostHashOfUnusualURLs(c *gc.C) {
+	specs := []struct {
+		url  string
+		host string
+	}{
+		{url: "https://[::1]:8080/", host: "::1"},
+		{url: "http://u:p@ss@host/", host: "host"},
+		{url: "//example.com/a", host: "example.com"},
+		{url: "http://a b.com/", host: ""},
+	}
+
+	minUUID, maxUUID := s.partitionRange(c, 0, 1)
+	for _, spec := range specs {
+		c.Assert(graph.LinkHost(spec.url), gc.Equals, spec.host, gc.Commentf("url %q", spec.url))
+
+		link := &graph.Link{URL: spec.url}
+		c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
+
+		hash := uint64(graph.HostHash(spec.url))
+		it, err := s.g.Links(context.TODO(), minUUID, maxUUID, time.Now(), graph.WithHostHashRange(graph.HostHashRange{From: hash, To: hash + 1}))
+		c.Assert(err, gc.IsNil)
+
+		var found bool
+		for it.Next() {
+			found = found || it.Link().ID == link.ID
+		}
+		c.Assert(it.Error(), gc.IsNil)
+		c.Assert(it.Close(), gc.IsNil)
+		c.Assert(found, gc.Equals, true, gc.Commentf("url %q not matched by its host hash", spec.url))
+	}
+}
+
+// TestMostDueLinks verifies that due links are returned by descending
+// priority and ascending next fetch time.
+func (s *SuiteBase) TestMostDueLinks(c *gc.C) {
+	now := time.Now().Truncate(time.Second).UTC()
+	links := map[string]*graph.Link{
+		"unscheduled": {URL: "https://example.com/unscheduled"},
+		"low":         {URL: "https://example.com/low", Metadata: graph.LinkMetadata{NextFetchAt: now.Add(-time.Hour), Priority: 2}},
+		"high-later":  {URL: "https://example.com/high-later", Metadata: graph.LinkMetadata{NextFetchAt: now.Add(-time.Minute), Priority: 9}},
+		"high":        {URL: "https://example.com/high", Metadata: graph.LinkMetadata{NextFetchAt: now.Add(-time.Hour), Priority: 9}},
+		"not-due":     {URL: "https://example.com/not-due", Metadata: graph.LinkMetadata{NextFetchAt: now.Add(time.Hour), Priority: 10}},
+	}
+	for _, link := range links {
+		c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
+	}
+
+	// Upserting a link without schedule information keeps its schedule.
+	c.Assert(s.g.UpsertLink(context.TODO(), &graph.Link{URL: links["high"].URL}), gc.IsNil)
+
+	minUUID, maxUUID := s.partitionRange(c, 0, 1)
+	got, err := s.g.MostDueLinks(context.TODO(), minUUID, maxUUID, now, 10)
+	c.Assert(err, gc.IsNil)
+
+	var gotIDs []uuid.UUID
+	for _, link := range got {
+		gotIDs = append(gotIDs, link.ID)
+	}
+	c.Assert(gotIDs, gc.DeepEquals, []uuid.UUID{
+		links["high"].ID,
+		links["high-later"].ID,
+		links["unscheduled"].ID,
+		links["low"].ID,
+	})
+	c.Assert(got[0].Metadata.Priority, gc.Equals, 9)
+	c.Assert(got[0].Metadata.NextFetchAt.Equal(links["high"].Metadata.NextFetchAt), gc.Equals, true)
+
+	got, err = s.g.MostDueLinks(context.TODO(), minUUID, maxUUID, now, 1)
+	c.Assert(err, gc.IsNil)
+	c.Assert(got, gc.HasLen, 1)
+	c.Assert(got[0].ID, gc.Equals, links["high"].ID)
+
+	// Rescheduling a link whose content did not change lowers its priority.
+	link := got[0]
+	link.Metadata.ScheduleNextFetch(now, false)
+	link.Metadata.ScheduleNextFetch(now, false)
+	c.Assert(link.Metadata.Priority, gc.Equals, 8)
+	c.Assert(link.Metadata.FetchInterval, gc.Equals, 2*graph.DefaultFetchInterval)
+	c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
+
+	got, err = s.g.MostDueLinks(context.TODO(), minUUID, maxUUID, now.Add(24*time.Hour), 10)
+	c.Assert(err, gc.IsNil)
+	c.Assert(got, gc.HasLen, 4)
+	c.Assert(got[0].ID, gc.Equals, links["not-due"].ID)
+	c.Assert(got[1].ID, gc.Equals, links["high-later"].ID)
+
+	// A zero limit yields no links while a negative one is rejected.
+	got, err = s.g.MostDueLinks(context.TODO(), minUUID, maxUUID, now.Add(24*time.Hour), 0)
+	c.Assert(err, gc.IsNil)
+	c.Assert(got, gc.HasLen, 0)
+	_, err = s.g.MostDueLinks(context.TODO(), minUUID, maxUUID, now.Add(24*time.Hour), -1)
+	c.Assert(xerrors.Is(err, graph.ErrInvalidLimit), gc.Equals, true)
+}
+
 // TestUpsertEdge verifies the edge upsert logic.
 func (s *SuiteBase) TestUpsertEdge(c *gc.C) {
 	// Create links
 	linkUUIDs := make([]uuid.UUID, 3)
 	for i := 0; i < 3; i++ {
 		link := &graph.Link{URL: fmt.Sprint(i)}
-		c.Assert(s.g.UpsertLink(link), gc.IsNil)
+		c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
 		linkUUIDs[i] = link.ID
 	}
 
@@ -238,7 +486,7 @@ func (s *SuiteBase) TestUpsertEdge(c *gc.C) {
 		Dst: linkUUIDs[1],
 	}
 
-	err := s.g.UpsertEdge(edge)
+	err := s.g.UpsertEdge(context.TODO(), edge)
 	c.Assert(err, gc.IsNil)
 	c.Assert(edge.ID, gc.Not(gc.Equals), uuid.Nil, gc.Commentf("expected an edgeID to be assigned to the new edge"))
 	c.Assert(edge.UpdatedAt.IsZero(), gc.Equals, false, gc.Commentf("UpdatedAt field not set"))
@@ -249,7 +497,7 @@ func (s *SuiteBase) TestUpsertEdge(c *gc.C) {
 		Src: linkUUIDs[0],
 		Dst: linkUUIDs[1],
 	}
-	err = s.g.UpsertEdge(other)
+	err = s.g.UpsertEdge(context.TODO(), other)
 	c.Assert(err, gc.IsNil)
 	c.Assert(other.ID, gc.Equals, edge.ID, gc.Commentf("edge ID changed while upserting"))
 	c.Assert(other.UpdatedAt, gc.Not(gc.Equals), edge.UpdatedAt, gc.Commentf("UpdatedAt field not modified"))
@@ -259,8 +507,175 @@ func (s *SuiteBase) TestUpsertEdge(c *gc.C) {
 		Src: linkUUIDs[0],
 		Dst: uuid.New(),
 	}
-	err = s.g.UpsertEdge(bogus)
+	err = s.g.UpsertEdge(context.TODO(), bogus)
+	c.Assert(xerrors.Is(err, graph.ErrUnknownEdgeLinks), gc.Equals, true)
+}
+
+// TestUpsertLinks verifies the batch link upsert logic.
+func (s *SuiteBase) TestUpsertLinks(c *gc.C) {
+	accessedAt := time.Now().Truncate(time.Second).UTC()
+	existing := &graph.Link{
+		URL:         "https://example.com",
+		RetrievedAt: accessedAt,
+	}
+	c.Assert(s.g.UpsertLink(context.TODO(), existing), gc.IsNil)
+
+	batch := []*graph.Link{
+		{URL: "https://example.com", RetrievedAt: accessedAt.Add(-10 * time.Hour)},
+		{URL: "https://example.com/foo"},
+		{URL: "https://example.com/bar"},
+		{URL: "https://example.com/foo"},
+	}
+	c.Assert(s.g.UpsertLinks(context.TODO(), batch), gc.IsNil)
+
+	c.Assert(batch[0].ID, gc.Equals, existing.ID, gc.Commentf("expected batch upsert to reuse the ID of the existing link"))
+	for i, link := range batch {
+		c.Assert(link.ID, gc.Not(gc.Equals), uuid.Nil, gc.Commentf("expected a linkID to be assigned to link %d", i))
+	}
+	c.Assert(batch[1].ID, gc.Not(gc.Equals), batch[2].ID)
+	c.Assert(batch[3].ID, gc.Equals, batch[1].ID, gc.Commentf("expected links with the same URL to be assigned the same ID"))
+
+	stored, err := s.g.FindLink(context.TODO(), existing.ID)
+	c.Assert(err, gc.IsNil)
+	c.Assert(stored.RetrievedAt, gc.Equals, accessedAt, gc.Commentf("last accessed timestamp was overwritten with an older value"))
+
+	for _, link := range batch[1:] {
+		_, err = s.g.FindLink(context.TODO(), link.ID)
+		c.Assert(err, gc.IsNil)
+	}
+
+	// Upserting an empty batch is a no-op.
+	c.Assert(s.g.UpsertLinks(context.TODO(), nil), gc.IsNil)
+}
+
+// TestEdgeAttributes verifies that edge attributes are persisted, replaced
+// by upserts and can be used to filter out nofollow edges.
+func (s *SuiteBase) TestEdgeAttributes(c *gc.C) {
+	linkUUIDs := make([]uuid.UUID, 3)
+	for i := 0; i < len(linkUUIDs); i++ {
+		link := &graph.Link{URL: fmt.Sprint(i)}
+		c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
+		linkUUIDs[i] = link.ID
+	}
+
+	followed := &graph.Edge{
+		Src:        linkUUIDs[0],
+		Dst:        linkUUIDs[1],
+		AnchorText: "about us",
+		Position:   1,
+	}
+	noFollow := &graph.Edge{
+		Src:        linkUUIDs[0],
+		Dst:        linkUUIDs[2],
+		AnchorText: "our sponsor",
+		NoFollow:   true,
+		Sponsored:  true,
+		Position:   2,
+	}
+	c.Assert(s.g.UpsertEdge(context.TODO(), followed), gc.IsNil)
+	c.Assert(s.g.UpsertEdges(context.TODO(), []*graph.Edge{noFollow}), gc.IsNil)
+	c.Assert(noFollow.AnchorText, gc.Equals, "our sponsor")
+
+	it, err := s.partitionedEdgeIterator(c, 0, 1, time.Now())
+	c.Assert(err, gc.IsNil)
+	got := make(map[uuid.UUID]*graph.Edge)
+	for it.Next() {
+		edge := it.Edge()
+		got[edge.ID] = edge
+	}
+	c.Assert(it.Error(), gc.IsNil)
+	c.Assert(it.Close(), gc.IsNil)
+	c.Assert(got, gc.DeepEquals, map[uuid.UUID]*graph.Edge{
+		followed.ID: followed,
+		noFollow.ID: noFollow,
+	})
+
+	// Nofollow edges should be skipped when requested
+	from, to := s.partitionRange(c, 0, 1)
+	it, err = s.g.Edges(context.TODO(), from, to, time.Now(), graph.ExcludeNoFollow())
+	c.Assert(err, gc.IsNil)
+	c.Assert(it.Next(), gc.Equals, true)
+	c.Assert(it.Edge().ID, gc.Equals, followed.ID)
+	c.Assert(it.Next(), gc.Equals, false)
+	c.Assert(it.Error(), gc.IsNil)
+	c.Assert(it.Close(), gc.IsNil)
+
+	it, err = s.g.InboundEdges(context.TODO(), linkUUIDs[2], time.Now(), graph.ExcludeNoFollow())
+	c.Assert(err, gc.IsNil)
+	c.Assert(it.Next(), gc.Equals, false)
+	c.Assert(it.Error(), gc.IsNil)
+	c.Assert(it.Close(), gc.IsNil)
+
+	// Upserting an existing edge should replace its attributes
+	update := &graph.Edge{
+		Src:        linkUUIDs[0],
+		Dst:        linkUUIDs[2],
+		AnchorText: "comments",
+		UGC:        true,
+		Position:   5,
+	}
+	c.Assert(s.g.UpsertEdge(context.TODO(), update), gc.IsNil)
+	c.Assert(update.ID, gc.Equals, noFollow.ID)
+	c.Assert(update.NoFollow, gc.Equals, false)
+	c.Assert(update.Sponsored, gc.Equals, false)
+
+	it, err = s.g.InboundEdges(context.TODO(), linkUUIDs[2], time.Now(), graph.ExcludeNoFollow())
+	c.Assert(err, gc.IsNil)
+	c.Assert(it.Next(), gc.Equals, true)
+	c.Assert(it.Edge(), gc.DeepEquals, update)
+	c.Assert(it.Next(), gc.Equals, false)
+	c.Assert(it.Error(), gc.IsNil)
+	c.Assert(it.Close(), gc.IsNil)
+}
+
+// TestUpsertEdges verifies the batch edge upsert logic.
+func (s *SuiteBase) TestUpsertEdges(c *gc.C) {
+	linkUUIDs := make([]uuid.UUID, 3)
+	for i := 0; i < 3; i++ {
+		link := &graph.Link{URL: fmt.Sprint(i)}
+		c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
+		linkUUIDs[i] = link.ID
+	}
+
+	existing := &graph.Edge{Src: linkUUIDs[0], Dst: linkUUIDs[1]}
+	c.Assert(s.g.UpsertEdge(context.TODO(), existing), gc.IsNil)
+
+	batch := []*graph.Edge{
+		{Src: linkUUIDs[0], Dst: linkUUIDs[1]},
+		{Src: linkUUIDs[0], Dst: linkUUIDs[2]},
+		{Src: linkUUIDs[0], Dst: uuid.New()},
+		{Src: linkUUIDs[1], Dst: linkUUIDs[2]},
+	}
+	err := s.g.UpsertEdges(context.TODO(), batch)
 	c.Assert(xerrors.Is(err, graph.ErrUnknownEdgeLinks), gc.Equals, true)
+
+	var batchErr *graph.BatchError
+	c.Assert(xerrors.As(err, &batchErr), gc.Equals, true)
+	c.Assert(batchErr.Errors, gc.HasLen, len(batch))
+	for i, itemErr := range batchErr.Errors {
+		if i == 2 {
+			c.Assert(xerrors.Is(itemErr, graph.ErrUnknownEdgeLinks), gc.Equals, true)
+			continue
+		}
+		c.Assert(itemErr, gc.IsNil, gc.Commentf("unexpected error for edge %d", i))
+	}
+
+	c.Assert(batch[0].ID, gc.Equals, existing.ID, gc.Commentf("expected batch upsert to reuse the ID of the existing edge"))
+	c.Assert(batch[0].UpdatedAt.Before(existing.UpdatedAt), gc.Equals, false, gc.Commentf("UpdatedAt field not modified"))
+	for _, i := range []int{1, 3} {
+		c.Assert(batch[i].ID, gc.Not(gc.Equals), uuid.Nil, gc.Commentf("expected an edgeID to be assigned to edge %d", i))
+		c.Assert(batch[i].UpdatedAt.IsZero(), gc.Equals, false, gc.Commentf("UpdatedAt field not set for edge %d", i))
+	}
+
+	it, err := s.partitionedEdgeIterator(c, 0, 1, time.Now())
+	c.Assert(err, gc.IsNil)
+	var seen int
+	for it.Next() {
+		seen++
+	}
+	c.Assert(it.Error(), gc.IsNil)
+	c.Assert(it.Close(), gc.IsNil)
+	c.Assert(seen, gc.Equals, 3)
 }
 
 // TestConcurrentEdgeIterators verifies that multiple clients can concurrently
@@ -275,11 +690,11 @@ func (s *SuiteBase) TestConcurrentEdgeIterators(c *gc.C) {
 
 	for i := 0; i < numEdges*2; i++ {
 		link := &graph.Link{URL: fmt.Sprint(i)}
-		c.Assert(s.g.UpsertLink(link), gc.IsNil)
+		c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
 		linkUUIDs[i] = link.ID
 	}
 	for i := 0; i < numEdges; i++ {
-		c.Assert(s.g.UpsertEdge(&graph.Edge{
+		c.Assert(s.g.UpsertEdge(context.TODO(), &graph.Edge{
 			Src: linkUUIDs[0],
 			Dst: linkUUIDs[i],
 		}), gc.IsNil)
@@ -332,7 +747,7 @@ func (s *SuiteBase) TestEdgeIteratorTimeFilter(c *gc.C) {
 	linkInsertTimes := make([]time.Time, len(linkUUIDs))
 	for i := 0; i < len(linkUUIDs); i++ {
 		link := &graph.Link{URL: fmt.Sprint(i)}
-		c.Assert(s.g.UpsertLink(link), gc.IsNil)
+		c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
 		linkUUIDs[i] = link.ID
 		linkInsertTimes[i] = time.Now()
 	}
@@ -341,7 +756,7 @@ func (s *SuiteBase) TestEdgeIteratorTimeFilter(c *gc.C) {
 	edgeInsertTimes := make([]time.Time, len(linkUUIDs))
 	for i := 0; i < len(linkUUIDs); i++ {
 		edge := &graph.Edge{Src: linkUUIDs[0], Dst: linkUUIDs[i]}
-		c.Assert(s.g.UpsertEdge(edge), gc.IsNil)
+		c.Assert(s.g.UpsertEdge(context.TODO(), edge), gc.IsNil)
 		edgeUUIDs[i] = edge.ID
 		edgeInsertTimes[i] = time.Now()
 	}
@@ -376,11 +791,11 @@ func (s *SuiteBase) TestPartitionedEdgeIterators(c *gc.C) {
 	linkUUIDs := make([]uuid.UUID, numEdges*2)
 	for i := 0; i < numEdges*2; i++ {
 		link := &graph.Link{URL: fmt.Sprint(i)}
-		c.Assert(s.g.UpsertLink(link), gc.IsNil)
+		c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
 		linkUUIDs[i] = link.ID
 	}
 	for i := 0; i < numEdges; i++ {
-		c.Assert(s.g.UpsertEdge(&graph.Edge{
+		c.Assert(s.g.UpsertEdge(context.TODO(), &graph.Edge{
 			Src: linkUUIDs[0],
 			Dst: linkUUIDs[i],
 		}), gc.IsNil)
@@ -427,6 +842,101 @@ func (s *SuiteBase) iteratePartitionedEdges(c *gc.C, numPartitions int) int {
 	return len(seen)
 }
 
+// TestInboundEdges verifies that the inbound edge iterator returns the edges
+// pointing to a particular link.
+func (s *SuiteBase) TestInboundEdges(c *gc.C) {
+	linkUUIDs := make([]uuid.UUID, 4)
+	for i := 0; i < len(linkUUIDs); i++ {
+		link := &graph.Link{URL: fmt.Sprint(i)}
+		c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
+		linkUUIDs[i] = link.ID
+	}
+
+	// 1 -> 0, 2 -> 0, 3 -> 1
+	var expSrcIDs []uuid.UUID
+	for i := 1; i < len(linkUUIDs); i++ {
+		dst := linkUUIDs[0]
+		if i == 3 {
+			dst = linkUUIDs[1]
+		} else {
+			expSrcIDs = append(expSrcIDs, linkUUIDs[i])
+		}
+		c.Assert(s.g.UpsertEdge(context.TODO(), &graph.Edge{Src: linkUUIDs[i], Dst: dst}), gc.IsNil)
+	}
+
+	it, err := s.g.InboundEdges(context.TODO(), linkUUIDs[0], time.Now())
+	c.Assert(err, gc.IsNil)
+
+	var gotSrcIDs []uuid.UUID
+	for it.Next() {
+		edge := it.Edge()
+		c.Assert(edge.Dst, gc.Equals, linkUUIDs[0])
+		gotSrcIDs = append(gotSrcIDs, edge.Src)
+	}
+	c.Assert(it.Error(), gc.IsNil)
+	c.Assert(it.Close(), gc.IsNil)
+
+	sort.Slice(gotSrcIDs, func(l, r int) bool { return gotSrcIDs[l].String() < gotSrcIDs[r].String() })
+	sort.Slice(expSrcIDs, func(l, r int) bool { return expSrcIDs[l].String() < expSrcIDs[r].String() })
+	c.Assert(gotSrcIDs, gc.DeepEquals, expSrcIDs)
+
+	// No edges point to link 3
+	it, err = s.g.InboundEdges(context.TODO(), linkUUIDs[3], time.Now())
+	c.Assert(err, gc.IsNil)
+	c.Assert(it.Next(), gc.Equals, false)
+	c.Assert(it.Error(), gc.IsNil)
+	c.Assert(it.Close(), gc.IsNil)
+}
+
+// TestLinkDegrees verifies the in- and out-degree lookups.
+func (s *SuiteBase) TestLinkDegrees(c *gc.C) {
+	linkUUIDs := make([]uuid.UUID, 4)
+	for i := 0; i < len(linkUUIDs); i++ {
+		link := &graph.Link{URL: fmt.Sprint(i)}
+		c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
+		linkUUIDs[i] = link.ID
+	}
+
+	// 0 -> 1, 0 -> 2, 0 -> 3, 1 -> 2, 3 -> 2
+	staleEdge := &graph.Edge{Src: linkUUIDs[3], Dst: linkUUIDs[2]}
+	c.Assert(s.g.UpsertEdges(context.TODO(), []*graph.Edge{
+		{Src: linkUUIDs[0], Dst: linkUUIDs[1]},
+		{Src: linkUUIDs[0], Dst: linkUUIDs[2]},
+		{Src: linkUUIDs[0], Dst: linkUUIDs[3]},
+		{Src: linkUUIDs[1], Dst: linkUUIDs[2]},
+		staleEdge,
+	}), gc.IsNil)
+
+	s.assertDegrees(c, linkUUIDs[0], 0, 3)
+	s.assertDegrees(c, linkUUIDs[1], 1, 1)
+	s.assertDegrees(c, linkUUIDs[2], 3, 0)
+	s.assertDegrees(c, linkUUIDs[3], 1, 1)
+
+	// Degrees must be updated when edges or links are removed
+	c.Assert(s.g.RemoveStaleEdges(context.TODO(), linkUUIDs[3], staleEdge.UpdatedAt.Add(time.Millisecond)), gc.IsNil)
+	s.assertDegrees(c, linkUUIDs[2], 2, 0)
+	s.assertDegrees(c, linkUUIDs[3], 1, 0)
+
+	c.Assert(s.g.RemoveLink(context.TODO(), linkUUIDs[1]), gc.IsNil)
+	s.assertDegrees(c, linkUUIDs[0], 0, 2)
+	s.assertDegrees(c, linkUUIDs[2], 1, 0)
+
+	_, err := s.g.InDegree(context.TODO(), linkUUIDs[1])
+	c.Assert(xerrors.Is(err, graph.ErrNotFound), gc.Equals, true)
+	_, err = s.g.OutDegree(context.TODO(), linkUUIDs[1])
+	c.Assert(xerrors.Is(err, graph.ErrNotFound), gc.Equals, true)
+}
+
+func (s *SuiteBase) assertDegrees(c *gc.C, id uuid.UUID, expIn, expOut int) {
+	in, err := s.g.InDegree(context.TODO(), id)
+	c.Assert(err, gc.IsNil)
+	c.Assert(in, gc.Equals, expIn, gc.Commentf("in degree for link %s", id))
+
+	out, err := s.g.OutDegree(context.TODO(), id)
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.Equals, expOut, gc.Commentf("out degree for link %s", id))
+}
+
 // TestRemoveStaleEdges verifies that the edge deletion logic works as expected.
 func (s *SuiteBase) TestRemoveStaleEdges(c *gc.C) {
 	numEdges := 100
@@ -434,7 +944,7 @@ func (s *SuiteBase) TestRemoveStaleEdges(c *gc.C) {
 	goneUUIDs := make(map[uuid.UUID]struct{})
 	for i := 0; i < numEdges*4; i++ {
 		link := &graph.Link{URL: fmt.Sprint(i)}
-		c.Assert(s.g.UpsertLink(link), gc.IsNil)
+		c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
 		linkUUIDs[i] = link.ID
 	}
 
@@ -444,7 +954,7 @@ func (s *SuiteBase) TestRemoveStaleEdges(c *gc.C) {
 			Src: linkUUIDs[0],
 			Dst: linkUUIDs[i],
 		}
-		c.Assert(s.g.UpsertEdge(e1), gc.IsNil)
+		c.Assert(s.g.UpsertEdge(context.TODO(), e1), gc.IsNil)
 		goneUUIDs[e1.ID] = struct{}{}
 		lastTs = e1.UpdatedAt
 	}
@@ -458,9 +968,9 @@ func (s *SuiteBase) TestRemoveStaleEdges(c *gc.C) {
 			Src: linkUUIDs[0],
 			Dst: linkUUIDs[numEdges+i+1],
 		}
-		c.Assert(s.g.UpsertEdge(e2), gc.IsNil)
+		c.Assert(s.g.UpsertEdge(context.TODO(), e2), gc.IsNil)
 	}
-	c.Assert(s.g.RemoveStaleEdges(linkUUIDs[0], deleteBefore), gc.IsNil)
+	c.Assert(s.g.RemoveStaleEdges(context.TODO(), linkUUIDs[0], deleteBefore), gc.IsNil)
 
 	it, err := s.partitionedEdgeIterator(c, 0, 1, time.Now())
 	c.Assert(err, gc.IsNil)
@@ -477,14 +987,461 @@ func (s *SuiteBase) TestRemoveStaleEdges(c *gc.C) {
 	c.Assert(seen, gc.Equals, numEdges)
 }
 
+// TestRemoveLink verifies that removing a link also removes its inbound and
+// outbound edges.
+func (s *SuiteBase) TestRemoveLink(c *gc.C) {
+	linkUUIDs := make([]uuid.UUID, 3)
+	for i := 0; i < len(linkUUIDs); i++ {
+		link := &graph.Link{URL: fmt.Sprint(i)}
+		c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
+		linkUUIDs[i] = link.ID
+	}
+
+	// 0 -> 1, 1 -> 2, 2 -> 0 and 0 -> 2
+	keptEdge := &graph.Edge{Src: linkUUIDs[0], Dst: linkUUIDs[2]}
+	c.Assert(s.g.UpsertEdges(context.TODO(), []*graph.Edge{
+		{Src: linkUUIDs[0], Dst: linkUUIDs[1]},
+		{Src: linkUUIDs[1], Dst: linkUUIDs[2]},
+		{Src: linkUUIDs[2], Dst: linkUUIDs[0]},
+		keptEdge,
+	}), gc.IsNil)
+
+	c.Assert(s.g.RemoveLink(context.TODO(), linkUUIDs[1]), gc.IsNil)
+
+	_, err := s.g.FindLink(context.TODO(), linkUUIDs[1])
+	c.Assert(xerrors.Is(err, graph.ErrNotFound), gc.Equals, true)
+	_, err = s.g.FindLinkByURL(context.TODO(), "1")
+	c.Assert(xerrors.Is(err, graph.ErrNotFound), gc.Equals, true)
+
+	it, err := s.partitionedEdgeIterator(c, 0, 1, time.Now())
+	c.Assert(err, gc.IsNil)
+	var seen int
+	for it.Next() {
+		edge := it.Edge()
+		c.Assert(edge.Src, gc.Not(gc.Equals), linkUUIDs[1], gc.Commentf("outbound edge of removed link still present"))
+		c.Assert(edge.Dst, gc.Not(gc.Equals), linkUUIDs[1], gc.Commentf("inbound edge of removed link still present"))
+		seen++
+	}
+	c.Assert(it.Error(), gc.IsNil)
+	c.Assert(it.Close(), gc.IsNil)
+	c.Assert(seen, gc.Equals, 2)
+
+	// Re-inserting a link with the same URL should work and yield a new ID
+	link := &graph.Link{URL: "1"}
+	c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
+	c.Assert(link.ID, gc.Not(gc.Equals), linkUUIDs[1])
+
+	// Removing an unknown link should fail
+	err = s.g.RemoveLink(context.TODO(), linkUUIDs[1])
+	c.Assert(xerrors.Is(err, graph.ErrNotFound), gc.Equals, true)
+
+	// Upserting an edge pointing to the removed link should fail
+	err = s.g.UpsertEdge(context.TODO(), &graph.Edge{Src: linkUUIDs[0], Dst: linkUUIDs[1]})
+	c.Assert(xerrors.Is(err, graph.ErrUnknownEdgeLinks), gc.Equals, true)
+}
+
+// TestRemoveEdge verifies the edge removal logic.
+func (s *SuiteBase) TestRemoveEdge(c *gc.C) {
+	linkUUIDs := make([]uuid.UUID, 3)
+	for i := 0; i < len(linkUUIDs); i++ {
+		link := &graph.Link{URL: fmt.Sprint(i)}
+		c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
+		linkUUIDs[i] = link.ID
+	}
+
+	removed := &graph.Edge{Src: linkUUIDs[0], Dst: linkUUIDs[1]}
+	kept := &graph.Edge{Src: linkUUIDs[0], Dst: linkUUIDs[2]}
+	c.Assert(s.g.UpsertEdges(context.TODO(), []*graph.Edge{removed, kept}), gc.IsNil)
+
+	c.Assert(s.g.RemoveEdge(context.TODO(), removed.ID), gc.IsNil)
+	s.assertIteratedEdgeIDsMatch(c, time.Now(), []uuid.UUID{kept.ID})
+
+	// Both endpoints must still exist
+	for _, id := range linkUUIDs[:2] {
+		_, err := s.g.FindLink(context.TODO(), id)
+		c.Assert(err, gc.IsNil)
+	}
+
+	err := s.g.RemoveEdge(context.TODO(), removed.ID)
+	c.Assert(xerrors.Is(err, graph.ErrNotFound), gc.Equals, true)
+}
+
+// TestLinkIteratorCursor verifies that link scans are returned in ID order
+// and can be resumed using the iterator cursor.
+func (s *SuiteBase) TestLinkIteratorCursor(c *gc.C) {
+	numLinks := 25
+	var expIDs []uuid.UUID
+	for i := 0; i < numLinks; i++ {
+		link := &graph.Link{URL: fmt.Sprint(i)}
+		c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
+		expIDs = append(expIDs, link.ID)
+	}
+	sort.Slice(expIDs, func(l, r int) bool { return expIDs[l].String() < expIDs[r].String() })
+
+	from, to := s.partitionRange(c, 0, 1)
+	it, err := s.g.Links(context.TODO(), from, to, time.Now(), graph.WithPageSize(10))
+	c.Assert(err, gc.IsNil)
+	c.Assert(it.Cursor(), gc.Equals, "")
+
+	var got []uuid.UUID
+	for len(got) < 7 && it.Next() {
+		got = append(got, it.Link().ID)
+	}
+	cursor := it.Cursor()
+	c.Assert(it.Close(), gc.IsNil)
+	c.Assert(cursor, gc.Equals, got[len(got)-1].String())
+
+	// Resume the scan from the checkpoint
+	it, err = s.g.Links(context.TODO(), from, to, time.Now(), graph.WithPageSize(10), graph.WithCursor(cursor))
+	c.Assert(err, gc.IsNil)
+	c.Assert(it.Cursor(), gc.Equals, cursor)
+	for it.Next() {
+		got = append(got, it.Link().ID)
+	}
+	c.Assert(it.Error(), gc.IsNil)
+	c.Assert(it.Close(), gc.IsNil)
+	c.Assert(got, gc.DeepEquals, expIDs)
+
+	_, err = s.g.Links(context.TODO(), from, to, time.Now(), graph.WithCursor("bogus"))
+	c.Assert(xerrors.Is(err, graph.ErrInvalidCursor), gc.Equals, true)
+}
+
+// TestEdgeIteratorCursor verifies that edge scans are returned in ID order
+// and can be resumed using the iterator cursor.
+func (s *SuiteBase) TestEdgeIteratorCursor(c *gc.C) {
+	numEdges := 25
+	linkUUIDs := make([]uuid.UUID, numEdges)
+	for i := 0; i < numEdges; i++ {
+		link := &graph.Link{URL: fmt.Sprint(i)}
+		c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
+		linkUUIDs[i] = link.ID
+	}
+
+	var expIDs []uuid.UUID
+	for i := 0; i < numEdges; i++ {
+		edge := &graph.Edge{Src: linkUUIDs[i%3], Dst: linkUUIDs[i]}
+		c.Assert(s.g.UpsertEdge(context.TODO(), edge), gc.IsNil)
+		expIDs = append(expIDs, edge.ID)
+	}
+	sort.Slice(expIDs, func(l, r int) bool { return expIDs[l].String() < expIDs[r].String() })
+
+	from, to := s.partitionRange(c, 0, 1)
+	it, err := s.g.Edges(context.TODO(), from, to, time.Now(), graph.WithPageSize(10))
+	c.Assert(err, gc.IsNil)
+
+	var got []uuid.UUID
+	for len(got) < 12 && it.Next() {
+		got = append(got, it.Edge().ID)
+	}
+	cursor := it.Cursor()
+	c.Assert(it.Close(), gc.IsNil)
+
+	it, err = s.g.Edges(context.TODO(), from, to, time.Now(), graph.WithPageSize(10), graph.WithCursor(cursor))
+	c.Assert(err, gc.IsNil)
+	for it.Next() {
+		got = append(got, it.Edge().ID)
+	}
+	c.Assert(it.Error(), gc.IsNil)
+	c.Assert(it.Close(), gc.IsNil)
+	c.Assert(got, gc.DeepEquals, expIDs)
+
+	_, err = s.g.Edges(context.TODO(), from, to, time.Now(), graph.WithCursor("bogus"))
+	c.Assert(xerrors.Is(err, graph.ErrInvalidCursor), gc.Equals, true)
+}
+
+// TestInboundEdgeIteratorCursor verifies that inbound edge scans are
+// returned in ID order and can be resumed using the iterator cursor.
+func (s *SuiteBase) TestInboundEdgeIteratorCursor(c *gc.C) {
+	dst := &graph.Link{URL: "dst"}
+	c.Assert(s.g.UpsertLink(context.TODO(), dst), gc.IsNil)
+
+	var expIDs []uuid.UUID
+	for i := 0; i < 25; i++ {
+		src := &graph.Link{URL: fmt.Sprint(i)}
+		c.Assert(s.g.UpsertLink(context.TODO(), src), gc.IsNil)
+		edge := &graph.Edge{Src: src.ID, Dst: dst.ID}
+		c.Assert(s.g.UpsertEdge(context.TODO(), edge), gc.IsNil)
+		expIDs = append(expIDs, edge.ID)
+	}
+	sort.Slice(expIDs, func(l, r int) bool { return expIDs[l].String() < expIDs[r].String() })
+
+	it, err := s.g.InboundEdges(context.TODO(), dst.ID, time.Now(), graph.WithPageSize(10))
+	c.Assert(err, gc.IsNil)
+
+	var got []uuid.UUID
+	for len(got) < 12 && it.Next() {
+		got = append(got, it.Edge().ID)
+	}
+	cursor := it.Cursor()
+	c.Assert(it.Close(), gc.IsNil)
+
+	it, err = s.g.InboundEdges(context.TODO(), dst.ID, time.Now(), graph.WithPageSize(10), graph.WithCursor(cursor))
+	c.Assert(err, gc.IsNil)
+	for it.Next() {
+		got = append(got, it.Edge().ID)
+	}
+	c.Assert(it.Error(), gc.IsNil)
+	c.Assert(it.Close(), gc.IsNil)
+	c.Assert(got, gc.DeepEquals, expIDs)
+}
+
+// TestLinkIteratorContextCancellation verifies that link iterators stop and
+// report the context error when their context is cancelled.
+func (s *SuiteBase) TestLinkIteratorContextCancellation(c *gc.C) {
+	for i := 0; i < 10; i++ {
+		c.Assert(s.g.UpsertLink(context.TODO(), &graph.Link{URL: fmt.Sprint(i)}), gc.IsNil)
+	}
+
+	ctx, cancelFn := context.WithCancel(context.TODO())
+	defer cancelFn()
+
+	from, to := s.partitionRange(c, 0, 1)
+	it, err := s.g.Links(ctx, from, to, time.Now())
+	c.Assert(err, gc.IsNil)
+	defer func() { c.Assert(it.Close(), gc.IsNil) }()
+
+	c.Assert(it.Next(), gc.Equals, true)
+	cancelFn()
+	c.Assert(it.Next(), gc.Equals, false, gc.Commentf("expected iterator to stop after context cancellation"))
+	c.Assert(xerrors.Is(it.Error(), context.Canceled), gc.Equals, true)
+}
+
+// TestEdgeIteratorContextCancellation verifies that edge iterators stop and
+// report the context error when their context is cancelled.
+func (s *SuiteBase) TestEdgeIteratorContextCancellation(c *gc.C) {
+	linkUUIDs := make([]uuid.UUID, 10)
+	for i := 0; i < len(linkUUIDs); i++ {
+		link := &graph.Link{URL: fmt.Sprint(i)}
+		c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
+		linkUUIDs[i] = link.ID
+	}
+	for i := 0; i < len(linkUUIDs); i++ {
+		c.Assert(s.g.UpsertEdge(context.TODO(), &graph.Edge{Src: linkUUIDs[0], Dst: linkUUIDs[i]}), gc.IsNil)
+	}
+
+	ctx, cancelFn := context.WithCancel(context.TODO())
+	defer cancelFn()
+
+	from, to := s.partitionRange(c, 0, 1)
+	it, err := s.g.Edges(ctx, from, to, time.Now())
+	c.Assert(err, gc.IsNil)
+	defer func() { c.Assert(it.Close(), gc.IsNil) }()
+
+	c.Assert(it.Next(), gc.Equals, true)
+	cancelFn()
+	c.Assert(it.Next(), gc.Equals, false, gc.Commentf("expected iterator to stop after context cancellation"))
+	c.Assert(xerrors.Is(it.Error(), context.Canceled), gc.Equals, true)
+}
+
+// TestCancelledContext verifies that graph operations invoked with an
+// already cancelled context fail with the context error.
+func (s *SuiteBase) TestCancelledContext(c *gc.C) {
+	src := &graph.Link{URL: "https://example.com"}
+	c.Assert(s.g.UpsertLink(context.TODO(), src), gc.IsNil)
+	dst := &graph.Link{URL: "https://example.com/about"}
+	c.Assert(s.g.UpsertLink(context.TODO(), dst), gc.IsNil)
+
+	ctx, cancelFn := context.WithCancel(context.TODO())
+	cancelFn()
+
+	err := s.g.UpsertLink(ctx, &graph.Link{URL: "https://example.com/contact"})
+	c.Assert(xerrors.Is(err, context.Canceled), gc.Equals, true, gc.Commentf("upsert link"))
+
+	_, err = s.g.FindLink(ctx, src.ID)
+	c.Assert(xerrors.Is(err, context.Canceled), gc.Equals, true, gc.Commentf("find link"))
+
+	err = s.g.UpsertEdge(ctx, &graph.Edge{Src: src.ID, Dst: dst.ID})
+	c.Assert(xerrors.Is(err, context.Canceled), gc.Equals, true, gc.Commentf("upsert edge"))
+
+	err = s.g.RemoveStaleEdges(ctx, src.ID, time.Now())
+	c.Assert(xerrors.Is(err, context.Canceled), gc.Equals, true, gc.Commentf("remove stale edges"))
+}
+
+// TestStats verifies the graph statistics.
+func (s *SuiteBase) TestStats(c *gc.C) {
+	stats, err := s.g.Stats(context.TODO())
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats.Links, gc.Equals, 0)
+	c.Assert(stats.Edges, gc.Equals, 0)
+	c.Assert(stats.OutDegreeHistogram, gc.HasLen, 0)
+	c.Assert(stats.HostLinks, gc.HasLen, 0)
+
+	links := []*graph.Link{
+		{URL: "https://example.com/a", RetrievedAt: time.Now().Add(-time.Hour)},
+		{URL: "https://EXAMPLE.com:8080/b"},
+		{URL: "http://user@other.org/c?q=1"},
+		{URL: "https://example.com/d"},
+	}
+	c.Assert(s.g.UpsertLinks(context.TODO(), links), gc.IsNil)
+	edges := []*graph.Edge{
+		{Src: links[0].ID, Dst: links[1].ID},
+		{Src: links[0].ID, Dst: links[2].ID},
+		{Src: links[0].ID, Dst: links[3].ID},
+		{Src: links[1].ID, Dst: links[0].ID},
+	}
+	c.Assert(s.g.UpsertEdges(context.TODO(), edges), gc.IsNil)
+
+	// Crawling a link updates the count of uncrawled links.
+	c.Assert(s.g.UpsertLink(context.TODO(), &graph.Link{URL: links[3].URL, RetrievedAt: time.Now().Add(-time.Minute)}), gc.IsNil)
+
+	stats, err = s.g.Stats(context.TODO())
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats.Links, gc.Equals, 4)
+	c.Assert(stats.Edges, gc.Equals, 4)
+	c.Assert(stats.UncrawledLinks, gc.Equals, 2)
+	c.Assert(stats.OutDegreeHistogram, gc.DeepEquals, map[int]int{0: 2, 1: 1, 3: 1})
+	c.Assert(stats.HostLinks, gc.DeepEquals, map[string]int{"example.com": 3, "other.org": 1})
+
+	// Removing a link also removes its edges.
+	c.Assert(s.g.RemoveLink(context.TODO(), links[0].ID), gc.IsNil)
+
+	stats, err = s.g.Stats(context.TODO())
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats.Links, gc.Equals, 3)
+	c.Assert(stats.Edges, gc.Equals, 0)
+	c.Assert(stats.UncrawledLinks, gc.Equals, 2)
+	c.Assert(stats.OutDegreeHistogram, gc.DeepEquals, map[int]int{0: 3})
+	c.Assert(stats.HostLinks, gc.DeepEquals, map[string]int{"example.com": 2, "other.org": 1})
+}
+
+// TestStatsHostLinksOfUnusualURLs verifies that links are counted against
+// the host returned by graph.LinkHost.
+func (s *SuiteBase) TestStatsHostLinksOfUnusualURLs(c *gc.C) {
+	links := []*graph.Link{
+		{URL: "https://[::1]:8080/"},
+		{URL: "http://u:p@ss@host/"},
+		{URL: "//example.com/a"},
+		{URL: "http://a b.com/"},
+	}
+	c.Assert(s.g.UpsertLinks(context.TODO(), links), gc.IsNil)
+
+	stats, err := s.g.Stats(context.TODO())
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats.HostLinks, gc.DeepEquals, map[string]int{"::1": 1, "host": 1, "example.com": 1, "": 1})
+}
+
+// TestRestore verifies that graphs implementing graph.Restorer store links
+// and edges with their original IDs and timestamps.
+func (s *SuiteBase) TestRestore(c *gc.C) {
+	r, ok := s.g.(graph.Restorer)
+	if !ok {
+		c.Skip("graph does not implement graph.Restorer")
+	}
+
+	retrievedAt := time.Now().Add(-time.Hour).Truncate(time.Second).UTC()
+	links := []*graph.Link{
+		{ID: uuid.New(), URL: "https://example.com/a", RetrievedAt: retrievedAt, Metadata: graph.LinkMetadata{StatusCode: 200, CrawlDepth: 1, DiscoveredAt: retrievedAt}},
+		{ID: uuid.New(), URL: "https://example.com/b", RetrievedAt: retrievedAt, Metadata: graph.LinkMetadata{DiscoveredAt: retrievedAt}},
+	}
+	err := r.RestoreLinks(context.TODO(), links)
+	if xerrors.Is(err, graph.ErrNotSupported) {
+		c.Skip("wrapped graph does not implement graph.Restorer")
+	}
+	c.Assert(err, gc.IsNil)
+
+	for _, link := range links {
+		stored, err := s.g.FindLinkByURL(context.TODO(), link.URL)
+		c.Assert(err, gc.IsNil)
+		c.Assert(stored.ID, gc.Equals, link.ID)
+		c.Assert(stored.RetrievedAt.Equal(link.RetrievedAt), gc.Equals, true)
+		c.Assert(stored.Metadata.StatusCode, gc.Equals, link.Metadata.StatusCode)
+		c.Assert(stored.Metadata.CrawlDepth, gc.Equals, link.Metadata.CrawlDepth)
+	}
+
+	// Restoring a link with the same ID replaces it.
+	links[1].Metadata.StatusCode = 404
+	c.Assert(r.RestoreLinks(context.TODO(), links[1:]), gc.IsNil)
+	stored, err := s.g.FindLink(context.TODO(), links[1].ID)
+	c.Assert(err, gc.IsNil)
+	c.Assert(stored.Metadata.StatusCode, gc.Equals, 404)
+
+	// A URL that belongs to another link is a conflict.
+	err = r.RestoreLinks(context.TODO(), []*graph.Link{{ID: uuid.New(), URL: links[0].URL}})
+	c.Assert(xerrors.Is(err, graph.ErrConflict), gc.Equals, true)
+
+	updatedAt := time.Now().Add(-time.Hour).Truncate(time.Second).UTC()
+	edge := &graph.Edge{ID: uuid.New(), Src: links[0].ID, Dst: links[1].ID, UpdatedAt: updatedAt, AnchorText: "b"}
+	c.Assert(r.RestoreEdges(context.TODO(), []*graph.Edge{edge}), gc.IsNil)
+
+	it, err := s.g.InboundEdges(context.TODO(), links[1].ID, time.Now())
+	c.Assert(err, gc.IsNil)
+	c.Assert(it.Next(), gc.Equals, true)
+	c.Assert(it.Edge().ID, gc.Equals, edge.ID)
+	c.Assert(it.Edge().UpdatedAt.Equal(updatedAt), gc.Equals, true)
+	c.Assert(it.Edge().AnchorText, gc.Equals, "b")
+	c.Assert(it.Next(), gc.Equals, false)
+	c.Assert(it.Close(), gc.IsNil)
+
+	// Upserting the same edge keeps the restored ID.
+	upserted := &graph.Edge{Src: links[0].ID, Dst: links[1].ID}
+	c.Assert(s.g.UpsertEdge(context.TODO(), upserted), gc.IsNil)
+	c.Assert(upserted.ID, gc.Equals, edge.ID)
+
+	// Another edge between the same links is a conflict.
+	err = r.RestoreEdges(context.TODO(), []*graph.Edge{{ID: uuid.New(), Src: links[0].ID, Dst: links[1].ID}})
+	c.Assert(xerrors.Is(err, graph.ErrConflict), gc.Equals, true)
+
+	// Edges must connect existing links.
+	err = r.RestoreEdges(context.TODO(), []*graph.Edge{{ID: uuid.New(), Src: links[0].ID, Dst: uuid.New()}})
+	c.Assert(xerrors.Is(err, graph.ErrUnknownEdgeLinks), gc.Equals, true)
+}
+
+// TestChangeFeed verifies that graphs implementing graph.ChangeSubscriber
+// report link upserts, edge upserts and stale edge removals in order.
+func (s *SuiteBase) TestChangeFeed(c *gc.C) {
+	sub, ok := s.g.(graph.ChangeSubscriber)
+	if !ok {
+		c.Skip("graph does not implement graph.ChangeSubscriber")
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
+	defer cancel()
+	it, err := sub.Subscribe(ctx)
+	if xerrors.Is(err, graph.ErrNotSupported) {
+		c.Skip("wrapped graph does not implement graph.ChangeSubscriber")
+	}
+	c.Assert(err, gc.IsNil)
+	defer func() { c.Assert(it.Close(), gc.IsNil) }()
+
+	src := &graph.Link{URL: "https://example.com/src"}
+	c.Assert(s.g.UpsertLink(context.TODO(), src), gc.IsNil)
+	dst := &graph.Link{URL: "https://example.com/dst"}
+	c.Assert(s.g.UpsertLink(context.TODO(), dst), gc.IsNil)
+	edge := &graph.Edge{Src: src.ID, Dst: dst.ID}
+	c.Assert(s.g.UpsertEdge(context.TODO(), edge), gc.IsNil)
+
+	// Nothing is removed by the first call, so only the second one is
+	// reported.
+	c.Assert(s.g.RemoveStaleEdges(context.TODO(), src.ID, time.Now().Add(-time.Hour)), gc.IsNil)
+	removedBefore := time.Now().Add(time.Minute).Truncate(time.Second).UTC()
+	c.Assert(s.g.RemoveStaleEdges(context.TODO(), src.ID, removedBefore), gc.IsNil)
+
+	var events []*graph.ChangeEvent
+	for len(events) < 4 && it.Next() {
+		events = append(events, it.Event())
+	}
+	c.Assert(it.Error(), gc.IsNil)
+	c.Assert(events, gc.HasLen, 4)
+
+	c.Assert(events[0].Type, gc.Equals, graph.ChangeLinkUpserted)
+	c.Assert(events[0].Link.ID, gc.Equals, src.ID)
+	c.Assert(events[1].Type, gc.Equals, graph.ChangeLinkUpserted)
+	c.Assert(events[1].Link.ID, gc.Equals, dst.ID)
+	c.Assert(events[2].Type, gc.Equals, graph.ChangeEdgeUpserted)
+	c.Assert(events[2].Edge.ID, gc.Equals, edge.ID)
+	c.Assert(events[3].Type, gc.Equals, graph.ChangeStaleEdgesRemoved)
+	c.Assert(events[3].Src, gc.Equals, src.ID)
+	c.Assert(events[3].UpdatedBefore.Equal(removedBefore), gc.Equals, true)
+}
+
 func (s *SuiteBase) partitionedLinkIterator(c *gc.C, partition, numPartitions int, accessedBefore time.Time) (graph.LinkIterator, error) {
 	from, to := s.partitionRange(c, partition, numPartitions)
-	return s.g.Links(from, to, accessedBefore)
+	return s.g.Links(context.TODO(), from, to, accessedBefore)
 }
 
 func (s *SuiteBase) partitionedEdgeIterator(c *gc.C, partition, numPartitions int, updatedBefore time.Time) (graph.EdgeIterator, error) {
 	from, to := s.partitionRange(c, partition, numPartitions)
-	return s.g.Edges(from, to, updatedBefore)
+	return s.g.Edges(context.TODO(), from, to, updatedBefore)
 }
 
 func (s *SuiteBase) partitionRange(c *gc.C, partition, numPartitions int) (from, to uuid.UUID) {
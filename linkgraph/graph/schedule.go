@@ -0,0 +1,89 @@
+package graph
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultPriority is the crawl priority of links whose Priority is
+	// unset. Priorities range from 1 to MaxPriority.
+	DefaultPriority = 5
+
+	// MaxPriority is the highest crawl priority.
+	MaxPriority = 10
+
+	// DefaultFetchInterval is the initial delay between two fetches of a
+	// link.
+	DefaultFetchInterval = 24 * time.Hour
+
+	// MinFetchInterval and MaxFetchInterval bound the adaptive delay
+	// between two fetches of a link.
+	MinFetchInterval = time.Hour
+	MaxFetchInterval = 30 * 24 * time.Hour
+)
+
+// EffectivePriority returns the crawl priority of the link, substituting
+// DefaultPriority for an unset priority.
+func (m LinkMetadata) EffectivePriority() int {
+	if m.Priority == 0 {
+		return DefaultPriority
+	}
+	return m.Priority
+}
+
+// ScheduleNextFetch updates the scheduling fields of m after a fetch that
+// completed at fetchedAt. Links whose content changed since the previous
+// fetch are refetched twice as often and gain priority; links whose content
+// did not change are refetched half as often and lose priority. The first
+// call initializes the schedule using DefaultFetchInterval.
+func (m *LinkMetadata) ScheduleNextFetch(fetchedAt time.Time, contentChanged bool) {
+	var (
+		interval = m.FetchInterval
+		priority = m.EffectivePriority()
+	)
+	switch {
+	case interval == 0:
+		interval = DefaultFetchInterval
+	case contentChanged:
+		interval, priority = interval/2, priority+1
+	default:
+		interval, priority = interval*2, priority-1
+	}
+
+	if interval < MinFetchInterval {
+		interval = MinFetchInterval
+	} else if interval > MaxFetchInterval {
+		interval = MaxFetchInterval
+	}
+	if priority < 1 {
+		priority = 1
+	} else if priority > MaxPriority {
+		priority = MaxPriority
+	}
+
+	m.FetchInterval = interval
+	m.Priority = priority
+	m.NextFetchAt = fetchedAt.Add(interval)
+}
+
+// IsDue returns true if the link should be fetched at t. Links that have
+// never been scheduled are always due.
+func (l *Link) IsDue(t time.Time) bool {
+	return !l.Metadata.NextFetchAt.After(t)
+}
+
+// SortByDue sorts links by descending effective priority, then by ascending
+// NextFetchAt and finally by ID.
+func SortByDue(links []*Link) {
+	sort.Slice(links, func(i, j int) bool {
+		a, b := links[i], links[j]
+		if pa, pb := a.Metadata.EffectivePriority(), b.Metadata.EffectivePriority(); pa != pb {
+			return pa > pb
+		}
+		if !a.Metadata.NextFetchAt.Equal(b.Metadata.NextFetchAt) {
+			return a.Metadata.NextFetchAt.Before(b.Metadata.NextFetchAt)
+		}
+		return a.ID.String() < b.ID.String()
+	})
+}
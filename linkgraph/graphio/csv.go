@@ -0,0 +1,185 @@
+package graphio
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// csvHeader lists the columns of a CSV edge list.
+var csvHeader = []string{
+	"edge_id", "src_id", "src_url", "dst_id", "dst_url", "updated_at",
+	"anchor_text", "nofollow", "sponsored", "ugc", "position",
+}
+
+// csvEncoder writes an edge list. As each row includes the URLs of the
+// edge endpoints, the encoder keeps track of the URLs of all encoded links.
+type csvEncoder struct {
+	w        *csv.Writer
+	linkURLs map[uuid.UUID]string
+}
+
+func newCSVEncoder(w io.Writer) (*csvEncoder, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	return &csvEncoder{w: cw, linkURLs: make(map[uuid.UUID]string)}, nil
+}
+
+func (e *csvEncoder) encodeLink(link *graph.Link) error {
+	e.linkURLs[link.ID] = link.URL
+	return nil
+}
+
+func (e *csvEncoder) encodeEdge(edge *graph.Edge) error {
+	return e.w.Write([]string{
+		edge.ID.String(),
+		edge.Src.String(),
+		e.linkURLs[edge.Src],
+		edge.Dst.String(),
+		e.linkURLs[edge.Dst],
+		formatTime(edge.UpdatedAt),
+		edge.AnchorText,
+		strconv.FormatBool(edge.NoFollow),
+		strconv.FormatBool(edge.Sponsored),
+		strconv.FormatBool(edge.UGC),
+		strconv.Itoa(edge.Position),
+	})
+}
+
+func (e *csvEncoder) close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// csvDecoder reads an edge list, emitting each endpoint link the first time
+// it is referenced followed by the edge itself.
+type csvDecoder struct {
+	r       *csv.Reader
+	seen    map[uuid.UUID]bool
+	pending []*graph.Link
+	edge    *graph.Edge
+}
+
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(csvHeader)
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, xerrors.Errorf("csv: read header: %w", err)
+	}
+	for i, col := range csvHeader {
+		if header[i] != col {
+			return nil, xerrors.Errorf("csv: expected column %d to be %q; got %q", i+1, col, header[i])
+		}
+	}
+
+	return &csvDecoder{r: cr, seen: make(map[uuid.UUID]bool)}, nil
+}
+
+func (d *csvDecoder) next() (*graph.Link, *graph.Edge, error) {
+	if len(d.pending) == 0 && d.edge == nil {
+		if err := d.readRow(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(d.pending) != 0 {
+		link := d.pending[0]
+		d.pending = d.pending[1:]
+		return link, nil, nil
+	}
+
+	edge := d.edge
+	d.edge = nil
+	return nil, edge, nil
+}
+
+func (d *csvDecoder) readRow() error {
+	row, err := d.r.Read()
+	if err != nil {
+		return err
+	}
+
+	var (
+		edge    graph.Edge
+		line, _ = d.r.FieldPos(0)
+	)
+	if edge.ID, err = uuid.Parse(row[0]); err != nil {
+		return xerrors.Errorf("csv: line %d: edge_id: %w", line, err)
+	}
+	if edge.Src, err = uuid.Parse(row[1]); err != nil {
+		return xerrors.Errorf("csv: line %d: src_id: %w", line, err)
+	}
+	if edge.Dst, err = uuid.Parse(row[3]); err != nil {
+		return xerrors.Errorf("csv: line %d: dst_id: %w", line, err)
+	}
+	if edge.UpdatedAt, err = parseTime(row[5]); err != nil {
+		return xerrors.Errorf("csv: line %d: updated_at: %w", line, err)
+	}
+	edge.AnchorText = row[6]
+	if edge.NoFollow, err = strconv.ParseBool(row[7]); err != nil {
+		return xerrors.Errorf("csv: line %d: nofollow: %w", line, err)
+	}
+	if edge.Sponsored, err = strconv.ParseBool(row[8]); err != nil {
+		return xerrors.Errorf("csv: line %d: sponsored: %w", line, err)
+	}
+	if edge.UGC, err = strconv.ParseBool(row[9]); err != nil {
+		return xerrors.Errorf("csv: line %d: ugc: %w", line, err)
+	}
+	if edge.Position, err = strconv.Atoi(row[10]); err != nil {
+		return xerrors.Errorf("csv: line %d: position: %w", line, err)
+	}
+
+	d.queueLink(edge.Src, row[2])
+	d.queueLink(edge.Dst, row[4])
+	d.edge = &edge
+	return nil
+}
+
+func (d *csvDecoder) queueLink(id uuid.UUID, url string) {
+	if d.seen[id] {
+		return
+	}
+	d.seen[id] = true
+	d.pending = append(d.pending, &graph.Link{ID: id, URL: url})
+}
+
+// formatTime encodes t as an RFC3339 string. Zero times are encoded as an
+// empty string.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// formatDuration encodes d using time.Duration.String. Zero durations are
+// encoded as an empty string.
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
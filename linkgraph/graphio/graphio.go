@@ -0,0 +1,302 @@
+// Package graphio exports and imports link graphs using standard
+// interchange formats.
+package graphio
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// Format identifies a graph serialization format.
+type Format string
+
+const (
+	// FormatJSONL encodes each link and edge as a JSON object on its own
+	// line. It preserves all link and edge fields.
+	FormatJSONL Format = "jsonl"
+
+	// FormatCSV encodes the graph as an edge list with one row per edge.
+	// Links are only described by their IDs and URLs; links without any
+	// edges are not included.
+	FormatCSV Format = "csv"
+
+	// FormatGraphML encodes the graph as a GraphML document with links as
+	// nodes. It preserves all link and edge fields.
+	FormatGraphML Format = "graphml"
+)
+
+// importBatchSize is the number of links or edges stored by each batch
+// operation while importing a graph.
+const importBatchSize = 500
+
+var (
+	// ErrUnknownFormat is returned when an unsupported format is requested.
+	ErrUnknownFormat = xerrors.New("unknown format")
+
+	// maxID is the upper bound of the ID range exported by ExportAll.
+	maxID = uuid.MustParse("ffffffff-ffff-ffff-ffff-ffffffffffff")
+
+	// maxTime is used as the retrieval and update cut-off when iterating
+	// the graph so that no links or edges are filtered out.
+	maxTime = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+)
+
+// encoder serializes a stream of links and edges.
+type encoder interface {
+	encodeLink(link *graph.Link) error
+	encodeEdge(edge *graph.Edge) error
+
+	// close writes any trailing data and flushes the output.
+	close() error
+}
+
+// decoder deserializes a stream of links and edges.
+type decoder interface {
+	// next returns either the next link or the next edge in the stream.
+	// It returns io.EOF once the stream has been exhausted.
+	next() (*graph.Link, *graph.Edge, error)
+}
+
+func newEncoder(w io.Writer, format Format) (encoder, error) {
+	switch format {
+	case FormatJSONL:
+		return newJSONLEncoder(w), nil
+	case FormatCSV:
+		return newCSVEncoder(w)
+	case FormatGraphML:
+		return newGraphMLEncoder(w)
+	default:
+		return nil, xerrors.Errorf("%q: %w", format, ErrUnknownFormat)
+	}
+}
+
+func newDecoder(r io.Reader, format Format) (decoder, error) {
+	switch format {
+	case FormatJSONL:
+		return newJSONLDecoder(r), nil
+	case FormatCSV:
+		return newCSVDecoder(r)
+	case FormatGraphML:
+		return newGraphMLDecoder(r), nil
+	default:
+		return nil, xerrors.Errorf("%q: %w", format, ErrUnknownFormat)
+	}
+}
+
+// ExportAll writes all links and edges of g to w using the specified format.
+func ExportAll(ctx context.Context, g graph.Graph, w io.Writer, format Format) error {
+	return Export(ctx, g, w, format, uuid.Nil, maxID)
+}
+
+// Export writes the links whose IDs belong to the [fromID, toID) range and
+// the edges that originate from them to w using the specified format. The
+// destination links of exported edges are always included so that the
+// output can be imported into an empty graph.
+func Export(ctx context.Context, g graph.Graph, w io.Writer, format Format, fromID, toID uuid.UUID) error {
+	enc, err := newEncoder(w, format)
+	if err != nil {
+		return xerrors.Errorf("export: %w", err)
+	}
+
+	if err = exportLinks(ctx, g, enc, fromID, toID); err != nil {
+		return xerrors.Errorf("export: %w", err)
+	}
+	if err = exportEdges(ctx, g, enc, fromID, toID); err != nil {
+		return xerrors.Errorf("export: %w", err)
+	}
+	if err = enc.close(); err != nil {
+		return xerrors.Errorf("export: %w", err)
+	}
+	return nil
+}
+
+func exportLinks(ctx context.Context, g graph.Graph, enc encoder, fromID, toID uuid.UUID) error {
+	it, err := g.Links(ctx, fromID, toID, maxTime)
+	if err != nil {
+		return err
+	}
+
+	for it.Next() {
+		if err = enc.encodeLink(it.Link()); err != nil {
+			_ = it.Close()
+			return err
+		}
+	}
+	if err = it.Error(); err != nil {
+		_ = it.Close()
+		return err
+	}
+	return it.Close()
+}
+
+func exportEdges(ctx context.Context, g graph.Graph, enc encoder, fromID, toID uuid.UUID) error {
+	it, err := g.Edges(ctx, fromID, toID, maxTime)
+	if err != nil {
+		return err
+	}
+
+	// Track the destination links outside the range that have already
+	// been exported alongside a previous edge.
+	extraLinks := make(map[uuid.UUID]bool)
+	for it.Next() {
+		edge := it.Edge()
+		if !inRange(edge.Dst, fromID, toID) && !extraLinks[edge.Dst] {
+			link, err := g.FindLink(ctx, edge.Dst)
+			if err == nil {
+				err = enc.encodeLink(link)
+			}
+			if err != nil {
+				_ = it.Close()
+				return err
+			}
+			extraLinks[edge.Dst] = true
+		}
+
+		if err = enc.encodeEdge(edge); err != nil {
+			_ = it.Close()
+			return err
+		}
+	}
+	if err = it.Error(); err != nil {
+		_ = it.Close()
+		return err
+	}
+	return it.Close()
+}
+
+func inRange(id, fromID, toID uuid.UUID) bool {
+	return bytes.Compare(id[:], fromID[:]) >= 0 && bytes.Compare(id[:], toID[:]) < 0
+}
+
+// Import reads links and edges encoded in the specified format from r and
+// stores them in g. If g implements graph.Restorer, links and edges keep
+// their IDs and timestamps. Otherwise, or if the restore methods fail with
+// graph.ErrNotSupported, they are upserted and are assigned new IDs; edges
+// are re-targeted to the IDs assigned to their links.
+func Import(ctx context.Context, g graph.Graph, r io.Reader, format Format) error {
+	dec, err := newDecoder(r, format)
+	if err != nil {
+		return xerrors.Errorf("import: %w", err)
+	}
+
+	imp := &importer{g: g, idMap: make(map[uuid.UUID]uuid.UUID)}
+	imp.restorer, _ = g.(graph.Restorer)
+
+	for {
+		link, edge, err := dec.next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return xerrors.Errorf("import: %w", err)
+		}
+
+		if link != nil {
+			err = imp.addLink(ctx, link)
+		} else {
+			err = imp.addEdge(ctx, edge)
+		}
+		if err != nil {
+			return xerrors.Errorf("import: %w", err)
+		}
+	}
+
+	if err = imp.flushEdges(ctx); err != nil {
+		return xerrors.Errorf("import: %w", err)
+	}
+	return nil
+}
+
+// importer buffers decoded links and edges and stores them in batches.
+type importer struct {
+	g        graph.Graph
+	restorer graph.Restorer
+
+	links []*graph.Link
+	edges []*graph.Edge
+
+	// idMap maps the imported link IDs to the IDs assigned by g when it
+	// does not implement graph.Restorer.
+	idMap map[uuid.UUID]uuid.UUID
+}
+
+func (imp *importer) addLink(ctx context.Context, link *graph.Link) error {
+	imp.links = append(imp.links, link)
+	if len(imp.links) < importBatchSize {
+		return nil
+	}
+	return imp.flushLinks(ctx)
+}
+
+func (imp *importer) addEdge(ctx context.Context, edge *graph.Edge) error {
+	imp.edges = append(imp.edges, edge)
+	if len(imp.edges) < importBatchSize {
+		return nil
+	}
+	return imp.flushEdges(ctx)
+}
+
+func (imp *importer) flushLinks(ctx context.Context) error {
+	if len(imp.links) == 0 {
+		return nil
+	}
+
+	var err error
+	if imp.restorer != nil {
+		err = imp.restorer.RestoreLinks(ctx, imp.links)
+		if xerrors.Is(err, graph.ErrNotSupported) {
+			imp.restorer = nil
+			return imp.flushLinks(ctx)
+		}
+	} else {
+		origIDs := make([]uuid.UUID, len(imp.links))
+		for i, link := range imp.links {
+			origIDs[i] = link.ID
+		}
+		if err = imp.g.UpsertLinks(ctx, imp.links); err == nil {
+			for i, link := range imp.links {
+				imp.idMap[origIDs[i]] = link.ID
+			}
+		}
+	}
+
+	imp.links = imp.links[:0]
+	return err
+}
+
+// flushEdges stores the buffered edges after flushing any buffered links
+// they may refer to.
+func (imp *importer) flushEdges(ctx context.Context) error {
+	if err := imp.flushLinks(ctx); err != nil {
+		return err
+	} else if len(imp.edges) == 0 {
+		return nil
+	}
+
+	var err error
+	if imp.restorer != nil {
+		err = imp.restorer.RestoreEdges(ctx, imp.edges)
+		if xerrors.Is(err, graph.ErrNotSupported) {
+			imp.restorer = nil
+			return imp.flushEdges(ctx)
+		}
+	} else {
+		for _, edge := range imp.edges {
+			if id, mapped := imp.idMap[edge.Src]; mapped {
+				edge.Src = id
+			}
+			if id, mapped := imp.idMap[edge.Dst]; mapped {
+				edge.Dst = id
+			}
+		}
+		err = imp.g.UpsertEdges(ctx, imp.edges)
+	}
+
+	imp.edges = imp.edges[:0]
+	return err
+}
@@ -0,0 +1,227 @@
+package graphio
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/store/memory"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+	gc "gopkg.in/check.v1"
+)
+
+var _ = gc.Suite(new(GraphIOTestSuite))
+
+func Test(t *testing.T) { gc.TestingT(t) }
+
+type GraphIOTestSuite struct {
+	src   *memory.InMemoryGraph
+	links []*graph.Link
+	edges []*graph.Edge
+}
+
+func (s *GraphIOTestSuite) SetUpTest(c *gc.C) {
+	s.src = memory.NewInMemoryGraph()
+
+	now := time.Now().Truncate(time.Second).UTC()
+	s.links = []*graph.Link{
+		{URL: "https://example.com/a", RetrievedAt: now.Add(-time.Hour), Metadata: graph.LinkMetadata{StatusCode: 200, ContentType: "text/html", CrawlDepth: 1}},
+		{URL: "https://example.com/b", Metadata: graph.LinkMetadata{FetchError: "timeout", DiscoveredAt: now.Add(-2 * time.Hour), NextFetchAt: now.Add(time.Hour), FetchInterval: 12 * time.Hour, Priority: 7}},
+		{URL: "https://example.com/c?q=<&>"},
+		{URL: "https://example.com/isolated"},
+	}
+	c.Assert(s.src.UpsertLinks(context.TODO(), s.links), gc.IsNil)
+
+	s.edges = []*graph.Edge{
+		{Src: s.links[0].ID, Dst: s.links[1].ID, AnchorText: "b, \"quoted\"", NoFollow: true, Position: 3},
+		{Src: s.links[1].ID, Dst: s.links[2].ID, Sponsored: true, UGC: true},
+		{Src: s.links[2].ID, Dst: s.links[0].ID},
+	}
+	c.Assert(s.src.UpsertEdges(context.TODO(), s.edges), gc.IsNil)
+}
+
+func (s *GraphIOTestSuite) TestJSONLRoundTrip(c *gc.C) {
+	dst := s.roundTrip(c, FormatJSONL, memory.NewInMemoryGraph())
+	s.assertLinks(c, dst, s.links, true)
+	s.assertEdges(c, dst)
+}
+
+func (s *GraphIOTestSuite) TestGraphMLRoundTrip(c *gc.C) {
+	dst := s.roundTrip(c, FormatGraphML, memory.NewInMemoryGraph())
+	s.assertLinks(c, dst, s.links, true)
+	s.assertEdges(c, dst)
+}
+
+func (s *GraphIOTestSuite) TestCSVRoundTrip(c *gc.C) {
+	dst := s.roundTrip(c, FormatCSV, memory.NewInMemoryGraph())
+
+	// The edge list only includes linked pages and their URLs.
+	s.assertLinks(c, dst, s.links[:3], false)
+	s.assertEdges(c, dst)
+	_, err := dst.FindLinkByURL(context.TODO(), s.links[3].URL)
+	c.Assert(xerrors.Is(err, graph.ErrNotFound), gc.Equals, true)
+}
+
+func (s *GraphIOTestSuite) TestImportWithoutRestorer(c *gc.C) {
+	dst := memory.NewInMemoryGraph()
+	s.roundTrip(c, FormatJSONL, struct{ graph.Graph }{dst})
+	s.assertRemapped(c, dst)
+}
+
+func (s *GraphIOTestSuite) TestImportWithUnsupportedRestorer(c *gc.C) {
+	dst := memory.NewInMemoryGraph()
+	s.roundTrip(c, FormatJSONL, unsupportedRestorer{dst})
+	s.assertRemapped(c, dst)
+}
+
+// assertRemapped checks that the links imported into dst were assigned new
+// IDs and that edges were re-targeted to them.
+func (s *GraphIOTestSuite) assertRemapped(c *gc.C, dst graph.Graph) {
+	for _, link := range s.links {
+		stored, err := dst.FindLinkByURL(context.TODO(), link.URL)
+		c.Assert(err, gc.IsNil)
+		c.Assert(stored.ID, gc.Not(gc.Equals), link.ID)
+	}
+
+	for _, edge := range s.edges {
+		srcURL, dstURL := s.urlOf(edge.Src), s.urlOf(edge.Dst)
+		srcLink, err := dst.FindLinkByURL(context.TODO(), srcURL)
+		c.Assert(err, gc.IsNil)
+
+		it, err := dst.Edges(context.TODO(), srcLink.ID, nextID(srcLink.ID), time.Now())
+		c.Assert(err, gc.IsNil)
+		c.Assert(it.Next(), gc.Equals, true)
+		dstLink, err := dst.FindLink(context.TODO(), it.Edge().Dst)
+		c.Assert(err, gc.IsNil)
+		c.Assert(dstLink.URL, gc.Equals, dstURL)
+		c.Assert(it.Edge().AnchorText, gc.Equals, edge.AnchorText)
+		c.Assert(it.Close(), gc.IsNil)
+	}
+}
+
+func (s *GraphIOTestSuite) TestExportPartition(c *gc.C) {
+	// Export a partition containing only the source of the first edge.
+	src := s.edges[0].Src
+	var buf bytes.Buffer
+	c.Assert(Export(context.TODO(), s.src, &buf, FormatJSONL, src, nextID(src)), gc.IsNil)
+
+	// The destination link must be exported alongside the edge.
+	dst := memory.NewInMemoryGraph()
+	c.Assert(Import(context.TODO(), dst, &buf, FormatJSONL), gc.IsNil)
+	s.assertLinks(c, dst, []*graph.Link{s.links[0], s.links[1]}, true)
+
+	out, err := dst.OutDegree(context.TODO(), src)
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.Equals, 1)
+	_, err = dst.FindLink(context.TODO(), s.links[2].ID)
+	c.Assert(xerrors.Is(err, graph.ErrNotFound), gc.Equals, true)
+}
+
+func (s *GraphIOTestSuite) TestUnknownFormat(c *gc.C) {
+	err := ExportAll(context.TODO(), s.src, new(bytes.Buffer), Format("dot"))
+	c.Assert(xerrors.Is(err, ErrUnknownFormat), gc.Equals, true)
+
+	err = Import(context.TODO(), s.src, strings.NewReader(""), Format("dot"))
+	c.Assert(xerrors.Is(err, ErrUnknownFormat), gc.Equals, true)
+}
+
+func (s *GraphIOTestSuite) TestInvalidCSVHeader(c *gc.C) {
+	err := Import(context.TODO(), memory.NewInMemoryGraph(), strings.NewReader("a,b,c,d,e,f,g,h,i,j,k\n"), FormatCSV)
+	c.Assert(err, gc.ErrorMatches, `import: csv: expected column 1 to be "edge_id".*`)
+}
+
+func (s *GraphIOTestSuite) roundTrip(c *gc.C, format Format, dst graph.Graph) graph.Graph {
+	var buf bytes.Buffer
+	c.Assert(ExportAll(context.TODO(), s.src, &buf, format), gc.IsNil)
+	c.Assert(Import(context.TODO(), dst, &buf, format), gc.IsNil)
+	return dst
+}
+
+func (s *GraphIOTestSuite) assertLinks(c *gc.C, g graph.Graph, exp []*graph.Link, withMetadata bool) {
+	it, err := g.Links(context.TODO(), uuid.Nil, maxID, time.Now().Add(time.Hour))
+	c.Assert(err, gc.IsNil)
+
+	var got []*graph.Link
+	for it.Next() {
+		got = append(got, it.Link())
+	}
+	c.Assert(it.Error(), gc.IsNil)
+	c.Assert(it.Close(), gc.IsNil)
+	c.Assert(got, gc.HasLen, len(exp))
+
+	sortLinks(got)
+	exp = append([]*graph.Link(nil), exp...)
+	sortLinks(exp)
+	for i, link := range exp {
+		c.Assert(got[i].ID, gc.Equals, link.ID)
+		c.Assert(got[i].URL, gc.Equals, link.URL)
+		if !withMetadata {
+			continue
+		}
+		c.Assert(got[i].RetrievedAt.Equal(link.RetrievedAt), gc.Equals, true)
+		c.Assert(got[i].Metadata.DiscoveredAt.Equal(link.Metadata.DiscoveredAt), gc.Equals, true)
+		c.Assert(got[i].Metadata.NextFetchAt.Equal(link.Metadata.NextFetchAt), gc.Equals, true)
+		gotMeta, expMeta := got[i].Metadata, link.Metadata
+		gotMeta.DiscoveredAt, expMeta.DiscoveredAt = time.Time{}, time.Time{}
+		gotMeta.NextFetchAt, expMeta.NextFetchAt = time.Time{}, time.Time{}
+		c.Assert(gotMeta, gc.DeepEquals, expMeta)
+	}
+}
+
+func (s *GraphIOTestSuite) assertEdges(c *gc.C, g graph.Graph) {
+	for _, edge := range s.edges {
+		it, err := g.InboundEdges(context.TODO(), edge.Dst, time.Now().Add(time.Hour))
+		c.Assert(err, gc.IsNil)
+		c.Assert(it.Next(), gc.Equals, true)
+
+		got := it.Edge()
+		c.Assert(got.UpdatedAt.Equal(edge.UpdatedAt), gc.Equals, true)
+		got.UpdatedAt = edge.UpdatedAt
+		c.Assert(got, gc.DeepEquals, edge)
+		c.Assert(it.Next(), gc.Equals, false)
+		c.Assert(it.Close(), gc.IsNil)
+	}
+}
+
+func (s *GraphIOTestSuite) urlOf(id uuid.UUID) string {
+	for _, link := range s.links {
+		if link.ID == id {
+			return link.URL
+		}
+	}
+	return ""
+}
+
+func sortLinks(links []*graph.Link) {
+	sort.Slice(links, func(l, r int) bool { return links[l].ID.String() < links[r].ID.String() })
+}
+
+// nextID returns the ID that immediately follows id.
+func nextID(id uuid.UUID) uuid.UUID {
+	for i := len(id) - 1; i >= 0; i-- {
+		id[i]++
+		if id[i] != 0 {
+			break
+		}
+	}
+	return id
+}
+
+// unsupportedRestorer mimics a graph decorator whose wrapped graph does not
+// implement graph.Restorer.
+type unsupportedRestorer struct {
+	graph.Graph
+}
+
+func (unsupportedRestorer) RestoreLinks(context.Context, []*graph.Link) error {
+	return xerrors.Errorf("restore links: %w", graph.ErrNotSupported)
+}
+
+func (unsupportedRestorer) RestoreEdges(context.Context, []*graph.Edge) error {
+	return xerrors.Errorf("restore edges: %w", graph.ErrNotSupported)
+}
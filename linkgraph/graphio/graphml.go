@@ -0,0 +1,314 @@
+package graphio
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+const graphMLNamespace = "http://graphml.graphdrawing.org/xmlns"
+
+// graphMLKeys declares the data attributes of links (nodes) and edges.
+var graphMLKeys = []graphMLKey{
+	{ID: "url", For: "node", Name: "url", Type: "string"},
+	{ID: "retrieved_at", For: "node", Name: "retrieved_at", Type: "string"},
+	{ID: "status_code", For: "node", Name: "status_code", Type: "int"},
+	{ID: "content_type", For: "node", Name: "content_type", Type: "string"},
+	{ID: "content_hash", For: "node", Name: "content_hash", Type: "string"},
+	{ID: "redirect_to", For: "node", Name: "redirect_to", Type: "string"},
+	{ID: "fetch_error", For: "node", Name: "fetch_error", Type: "string"},
+	{ID: "crawl_depth", For: "node", Name: "crawl_depth", Type: "int"},
+	{ID: "discovered_at", For: "node", Name: "discovered_at", Type: "string"},
+	{ID: "next_fetch_at", For: "node", Name: "next_fetch_at", Type: "string"},
+	{ID: "fetch_interval", For: "node", Name: "fetch_interval", Type: "string"},
+	{ID: "priority", For: "node", Name: "priority", Type: "int"},
+	{ID: "updated_at", For: "edge", Name: "updated_at", Type: "string"},
+	{ID: "anchor_text", For: "edge", Name: "anchor_text", Type: "string"},
+	{ID: "nofollow", For: "edge", Name: "nofollow", Type: "boolean"},
+	{ID: "sponsored", For: "edge", Name: "sponsored", Type: "boolean"},
+	{ID: "ugc", For: "edge", Name: "ugc", Type: "boolean"},
+	{ID: "position", For: "edge", Name: "position", Type: "int"},
+}
+
+type graphMLKey struct {
+	XMLName xml.Name `xml:"key"`
+	ID      string   `xml:"id,attr"`
+	For     string   `xml:"for,attr"`
+	Name    string   `xml:"attr.name,attr"`
+	Type    string   `xml:"attr.type,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	XMLName xml.Name      `xml:"node"`
+	ID      string        `xml:"id,attr"`
+	Data    []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	XMLName xml.Name      `xml:"edge"`
+	ID      string        `xml:"id,attr"`
+	Source  string        `xml:"source,attr"`
+	Target  string        `xml:"target,attr"`
+	Data    []graphMLData `xml:"data"`
+}
+
+var (
+	graphMLRoot  = xml.StartElement{Name: xml.Name{Local: "graphml"}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: graphMLNamespace}}}
+	graphMLGraph = xml.StartElement{Name: xml.Name{Local: "graph"}, Attr: []xml.Attr{
+		{Name: xml.Name{Local: "id"}, Value: "links"},
+		{Name: xml.Name{Local: "edgedefault"}, Value: "directed"},
+	}}
+)
+
+type graphMLEncoder struct {
+	w   *bufio.Writer
+	enc *xml.Encoder
+}
+
+func newGraphMLEncoder(w io.Writer) (*graphMLEncoder, error) {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(xml.Header); err != nil {
+		return nil, err
+	}
+
+	enc := xml.NewEncoder(bw)
+	enc.Indent("", "  ")
+	if err := enc.EncodeToken(graphMLRoot); err != nil {
+		return nil, err
+	}
+	for _, key := range graphMLKeys {
+		if err := enc.Encode(key); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.EncodeToken(graphMLGraph); err != nil {
+		return nil, err
+	}
+
+	return &graphMLEncoder{w: bw, enc: enc}, nil
+}
+
+func (e *graphMLEncoder) encodeLink(link *graph.Link) error {
+	node := graphMLNode{ID: link.ID.String()}
+	node.Data = appendData(node.Data, "url", link.URL)
+	node.Data = appendData(node.Data, "retrieved_at", formatTime(link.RetrievedAt))
+	node.Data = appendData(node.Data, "status_code", formatInt(link.Metadata.StatusCode))
+	node.Data = appendData(node.Data, "content_type", link.Metadata.ContentType)
+	node.Data = appendData(node.Data, "content_hash", link.Metadata.ContentHash)
+	if link.Metadata.RedirectTo != uuid.Nil {
+		node.Data = appendData(node.Data, "redirect_to", link.Metadata.RedirectTo.String())
+	}
+	node.Data = appendData(node.Data, "fetch_error", link.Metadata.FetchError)
+	node.Data = appendData(node.Data, "crawl_depth", formatInt(link.Metadata.CrawlDepth))
+	node.Data = appendData(node.Data, "discovered_at", formatTime(link.Metadata.DiscoveredAt))
+	node.Data = appendData(node.Data, "next_fetch_at", formatTime(link.Metadata.NextFetchAt))
+	node.Data = appendData(node.Data, "fetch_interval", formatDuration(link.Metadata.FetchInterval))
+	node.Data = appendData(node.Data, "priority", formatInt(link.Metadata.Priority))
+	return e.enc.Encode(node)
+}
+
+func (e *graphMLEncoder) encodeEdge(edge *graph.Edge) error {
+	el := graphMLEdge{ID: edge.ID.String(), Source: edge.Src.String(), Target: edge.Dst.String()}
+	el.Data = appendData(el.Data, "updated_at", formatTime(edge.UpdatedAt))
+	el.Data = appendData(el.Data, "anchor_text", edge.AnchorText)
+	el.Data = appendData(el.Data, "nofollow", formatBool(edge.NoFollow))
+	el.Data = appendData(el.Data, "sponsored", formatBool(edge.Sponsored))
+	el.Data = appendData(el.Data, "ugc", formatBool(edge.UGC))
+	el.Data = appendData(el.Data, "position", formatInt(edge.Position))
+	return e.enc.Encode(el)
+}
+
+func (e *graphMLEncoder) close() error {
+	if err := e.enc.EncodeToken(graphMLGraph.End()); err != nil {
+		return err
+	}
+	if err := e.enc.EncodeToken(graphMLRoot.End()); err != nil {
+		return err
+	}
+	if err := e.enc.Flush(); err != nil {
+		return err
+	}
+	if _, err := e.w.WriteString("\n"); err != nil {
+		return err
+	}
+	return e.w.Flush()
+}
+
+// graphMLDecoder streams the nodes and edges of a GraphML document. Data
+// values are matched to link and edge fields by the attr.name of their key.
+type graphMLDecoder struct {
+	dec      *xml.Decoder
+	keyNames map[string]string
+}
+
+func newGraphMLDecoder(r io.Reader) *graphMLDecoder {
+	return &graphMLDecoder{dec: xml.NewDecoder(r), keyNames: make(map[string]string)}
+}
+
+func (d *graphMLDecoder) next() (*graph.Link, *graph.Edge, error) {
+	for {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "key":
+			var key graphMLKey
+			if err = d.dec.DecodeElement(&key, &start); err != nil {
+				return nil, nil, xerrors.Errorf("graphml: %w", err)
+			}
+			d.keyNames[key.ID] = key.Name
+		case "node":
+			var node graphMLNode
+			if err = d.dec.DecodeElement(&node, &start); err != nil {
+				return nil, nil, xerrors.Errorf("graphml: %w", err)
+			}
+			link, err := d.parseLink(node)
+			if err != nil {
+				return nil, nil, xerrors.Errorf("graphml: node %q: %w", node.ID, err)
+			}
+			return link, nil, nil
+		case "edge":
+			var el graphMLEdge
+			if err = d.dec.DecodeElement(&el, &start); err != nil {
+				return nil, nil, xerrors.Errorf("graphml: %w", err)
+			}
+			edge, err := d.parseEdge(el)
+			if err != nil {
+				return nil, nil, xerrors.Errorf("graphml: edge %q: %w", el.ID, err)
+			}
+			return nil, edge, nil
+		}
+	}
+}
+
+func (d *graphMLDecoder) parseLink(node graphMLNode) (*graph.Link, error) {
+	var (
+		link = new(graph.Link)
+		err  error
+	)
+	if link.ID, err = uuid.Parse(node.ID); err != nil {
+		return nil, err
+	}
+
+	for _, data := range node.Data {
+		switch d.keyName(data.Key) {
+		case "url":
+			link.URL = data.Value
+		case "retrieved_at":
+			link.RetrievedAt, err = parseTime(data.Value)
+		case "status_code":
+			link.Metadata.StatusCode, err = strconv.Atoi(data.Value)
+		case "content_type":
+			link.Metadata.ContentType = data.Value
+		case "content_hash":
+			link.Metadata.ContentHash = data.Value
+		case "redirect_to":
+			link.Metadata.RedirectTo, err = uuid.Parse(data.Value)
+		case "fetch_error":
+			link.Metadata.FetchError = data.Value
+		case "crawl_depth":
+			link.Metadata.CrawlDepth, err = strconv.Atoi(data.Value)
+		case "discovered_at":
+			link.Metadata.DiscoveredAt, err = parseTime(data.Value)
+		case "next_fetch_at":
+			link.Metadata.NextFetchAt, err = parseTime(data.Value)
+		case "fetch_interval":
+			link.Metadata.FetchInterval, err = parseDuration(data.Value)
+		case "priority":
+			link.Metadata.Priority, err = strconv.Atoi(data.Value)
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("%s: %w", d.keyName(data.Key), err)
+		}
+	}
+	return link, nil
+}
+
+func (d *graphMLDecoder) parseEdge(el graphMLEdge) (*graph.Edge, error) {
+	var (
+		edge = new(graph.Edge)
+		err  error
+	)
+	if edge.ID, err = uuid.Parse(el.ID); err != nil {
+		return nil, err
+	}
+	if edge.Src, err = uuid.Parse(el.Source); err != nil {
+		return nil, xerrors.Errorf("source: %w", err)
+	}
+	if edge.Dst, err = uuid.Parse(el.Target); err != nil {
+		return nil, xerrors.Errorf("target: %w", err)
+	}
+
+	for _, data := range el.Data {
+		switch d.keyName(data.Key) {
+		case "updated_at":
+			edge.UpdatedAt, err = parseTime(data.Value)
+		case "anchor_text":
+			edge.AnchorText = data.Value
+		case "nofollow":
+			edge.NoFollow, err = strconv.ParseBool(data.Value)
+		case "sponsored":
+			edge.Sponsored, err = strconv.ParseBool(data.Value)
+		case "ugc":
+			edge.UGC, err = strconv.ParseBool(data.Value)
+		case "position":
+			edge.Position, err = strconv.Atoi(data.Value)
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("%s: %w", d.keyName(data.Key), err)
+		}
+	}
+	return edge, nil
+}
+
+// keyName returns the attr.name declared for the specified key ID, falling
+// back to the ID itself for undeclared keys.
+func (d *graphMLDecoder) keyName(id string) string {
+	if name, ok := d.keyNames[id]; ok {
+		return name
+	}
+	return id
+}
+
+// appendData appends a data element for key unless value is empty.
+func appendData(data []graphMLData, key, value string) []graphMLData {
+	if value == "" {
+		return data
+	}
+	return append(data, graphMLData{Key: key, Value: value})
+}
+
+// formatInt encodes v as a string. Zero values are encoded as an empty
+// string.
+func formatInt(v int) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.Itoa(v)
+}
+
+// formatBool encodes v as a string. False values are encoded as an empty
+// string.
+func formatBool(v bool) string {
+	if !v {
+		return ""
+	}
+	return strconv.FormatBool(v)
+}
@@ -0,0 +1,193 @@
+package graphio
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+const (
+	jsonTypeLink = "link"
+	jsonTypeEdge = "edge"
+)
+
+// jsonRecord is a single line of a JSON Lines export.
+type jsonRecord struct {
+	Type string    `json:"type"`
+	Link *jsonLink `json:"link,omitempty"`
+	Edge *jsonEdge `json:"edge,omitempty"`
+}
+
+type jsonLink struct {
+	ID           uuid.UUID  `json:"id"`
+	URL          string     `json:"url"`
+	RetrievedAt  *time.Time `json:"retrieved_at,omitempty"`
+	StatusCode   int        `json:"status_code,omitempty"`
+	ContentType  string     `json:"content_type,omitempty"`
+	ContentHash  string     `json:"content_hash,omitempty"`
+	RedirectTo   *uuid.UUID `json:"redirect_to,omitempty"`
+	FetchError   string     `json:"fetch_error,omitempty"`
+	CrawlDepth   int        `json:"crawl_depth,omitempty"`
+	DiscoveredAt *time.Time `json:"discovered_at,omitempty"`
+
+	NextFetchAt   *time.Time `json:"next_fetch_at,omitempty"`
+	FetchInterval string     `json:"fetch_interval,omitempty"`
+	Priority      int        `json:"priority,omitempty"`
+}
+
+type jsonEdge struct {
+	ID         uuid.UUID  `json:"id"`
+	Src        uuid.UUID  `json:"src"`
+	Dst        uuid.UUID  `json:"dst"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+	AnchorText string     `json:"anchor_text,omitempty"`
+	NoFollow   bool       `json:"nofollow,omitempty"`
+	Sponsored  bool       `json:"sponsored,omitempty"`
+	UGC        bool       `json:"ugc,omitempty"`
+	Position   int        `json:"position,omitempty"`
+}
+
+type jsonlEncoder struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func newJSONLEncoder(w io.Writer) *jsonlEncoder {
+	bw := bufio.NewWriter(w)
+	return &jsonlEncoder{w: bw, enc: json.NewEncoder(bw)}
+}
+
+func (e *jsonlEncoder) encodeLink(link *graph.Link) error {
+	return e.enc.Encode(jsonRecord{
+		Type: jsonTypeLink,
+		Link: &jsonLink{
+			ID:           link.ID,
+			URL:          link.URL,
+			RetrievedAt:  optionalTime(link.RetrievedAt),
+			StatusCode:   link.Metadata.StatusCode,
+			ContentType:  link.Metadata.ContentType,
+			ContentHash:  link.Metadata.ContentHash,
+			RedirectTo:   optionalID(link.Metadata.RedirectTo),
+			FetchError:   link.Metadata.FetchError,
+			CrawlDepth:   link.Metadata.CrawlDepth,
+			DiscoveredAt: optionalTime(link.Metadata.DiscoveredAt),
+
+			NextFetchAt:   optionalTime(link.Metadata.NextFetchAt),
+			FetchInterval: formatDuration(link.Metadata.FetchInterval),
+			Priority:      link.Metadata.Priority,
+		},
+	})
+}
+
+func (e *jsonlEncoder) encodeEdge(edge *graph.Edge) error {
+	return e.enc.Encode(jsonRecord{
+		Type: jsonTypeEdge,
+		Edge: &jsonEdge{
+			ID:         edge.ID,
+			Src:        edge.Src,
+			Dst:        edge.Dst,
+			UpdatedAt:  optionalTime(edge.UpdatedAt),
+			AnchorText: edge.AnchorText,
+			NoFollow:   edge.NoFollow,
+			Sponsored:  edge.Sponsored,
+			UGC:        edge.UGC,
+			Position:   edge.Position,
+		},
+	})
+}
+
+func (e *jsonlEncoder) close() error {
+	return e.w.Flush()
+}
+
+type jsonlDecoder struct {
+	dec *json.Decoder
+}
+
+func newJSONLDecoder(r io.Reader) *jsonlDecoder {
+	return &jsonlDecoder{dec: json.NewDecoder(r)}
+}
+
+func (d *jsonlDecoder) next() (*graph.Link, *graph.Edge, error) {
+	var rec jsonRecord
+	if err := d.dec.Decode(&rec); err != nil {
+		return nil, nil, err
+	}
+
+	switch {
+	case rec.Type == jsonTypeLink && rec.Link != nil:
+		l := rec.Link
+		fetchInterval, err := parseDuration(l.FetchInterval)
+		if err != nil {
+			return nil, nil, xerrors.Errorf("jsonl: fetch_interval: %w", err)
+		}
+		return &graph.Link{
+			ID:          l.ID,
+			URL:         l.URL,
+			RetrievedAt: timeValue(l.RetrievedAt),
+			Metadata: graph.LinkMetadata{
+				StatusCode:   l.StatusCode,
+				ContentType:  l.ContentType,
+				ContentHash:  l.ContentHash,
+				RedirectTo:   idValue(l.RedirectTo),
+				FetchError:   l.FetchError,
+				CrawlDepth:   l.CrawlDepth,
+				DiscoveredAt: timeValue(l.DiscoveredAt),
+
+				NextFetchAt:   timeValue(l.NextFetchAt),
+				FetchInterval: fetchInterval,
+				Priority:      l.Priority,
+			},
+		}, nil, nil
+	case rec.Type == jsonTypeEdge && rec.Edge != nil:
+		e := rec.Edge
+		return nil, &graph.Edge{
+			ID:         e.ID,
+			Src:        e.Src,
+			Dst:        e.Dst,
+			UpdatedAt:  timeValue(e.UpdatedAt),
+			AnchorText: e.AnchorText,
+			NoFollow:   e.NoFollow,
+			Sponsored:  e.Sponsored,
+			UGC:        e.UGC,
+			Position:   e.Position,
+		}, nil
+	default:
+		return nil, nil, xerrors.Errorf("jsonl: invalid record of type %q", rec.Type)
+	}
+}
+
+// optionalTime returns nil for zero times so that they are omitted.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func timeValue(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// optionalID returns nil for uuid.Nil so that it is omitted.
+func optionalID(id uuid.UUID) *uuid.UUID {
+	if id == uuid.Nil {
+		return nil
+	}
+	return &id
+}
+
+func idValue(id *uuid.UUID) uuid.UUID {
+	if id == nil {
+		return uuid.Nil
+	}
+	return *id
+}
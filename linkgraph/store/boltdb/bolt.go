@@ -0,0 +1,912 @@
+package boltdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/xerrors"
+)
+
+// defaultPageSize is the number of items fetched per page by link and edge
+// iterators unless overridden via graph.WithPageSize.
+const defaultPageSize = 1000
+
+var (
+	// linksBucket maps link IDs to JSON-encoded links.
+	linksBucket = []byte("links")
+
+	// linkURLsBucket maps link URLs to link IDs.
+	linkURLsBucket = []byte("link_urls")
+
+	// edgesBucket maps edge IDs to JSON-encoded edges.
+	edgesBucket = []byte("edges")
+
+	// edgeKeysBucket maps (src, dst) ID pairs to edge IDs.
+	edgeKeysBucket = []byte("edge_keys")
+
+	// outEdgesBucket and inEdgesBucket index edges by their (src, edge ID)
+	// and (dst, edge ID) pairs respectively.
+	outEdgesBucket = []byte("out_edges")
+	inEdgesBucket  = []byte("in_edges")
+
+	// edgeSrcsBucket maps edge IDs to the IDs of their source links. It
+	// allows edge scans to filter edges by source in ID order without
+	// decoding them.
+	edgeSrcsBucket = []byte("edge_srcs")
+
+	// Compile-time checks for ensuring BoltGraph implements Graph and
+	// Restorer.
+	_ graph.Graph    = (*BoltGraph)(nil)
+	_ graph.Restorer = (*BoltGraph)(nil)
+)
+
+// BoltGraph implements a graph that persists its links and edges to an
+// embedded bbolt database file.
+type BoltGraph struct {
+	db *bolt.DB
+}
+
+// NewBoltGraph returns a BoltGraph instance that stores its data in the
+// bbolt database file at path. The file is created if it does not exist.
+func NewBoltGraph(path string) (*BoltGraph, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		// Files created before the edge_srcs index was introduced need
+		// to have it populated from the stored edges.
+		populateEdgeSrcs := tx.Bucket(edgeSrcsBucket) == nil
+
+		for _, name := range [][]byte{linksBucket, linkURLsBucket, edgesBucket, edgeKeysBucket, outEdgesBucket, inEdgesBucket, edgeSrcsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		if populateEdgeSrcs {
+			return indexEdgeSrcs(tx)
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &BoltGraph{db: db}, nil
+}
+
+// Close releases the database file.
+func (g *BoltGraph) Close() error {
+	return g.db.Close()
+}
+
+// UpsertLink creates a new link or updates an existing link.
+func (g *BoltGraph) UpsertLink(ctx context.Context, link *graph.Link) error {
+	if err := ctx.Err(); err != nil {
+		return xerrors.Errorf("upsert link: %w", err)
+	}
+
+	err := g.db.Update(func(tx *bolt.Tx) error {
+		return upsertLink(tx, link)
+	})
+	if err != nil {
+		return xerrors.Errorf("upsert link: %w", err)
+	}
+	return nil
+}
+
+// UpsertLinks creates or updates a batch of links in a single transaction.
+func (g *BoltGraph) UpsertLinks(ctx context.Context, links []*graph.Link) error {
+	if err := ctx.Err(); err != nil {
+		return xerrors.Errorf("upsert links: %w", err)
+	}
+
+	err := g.db.Update(func(tx *bolt.Tx) error {
+		for _, link := range links {
+			if err := upsertLink(tx, link); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("upsert links: %w", err)
+	}
+	return nil
+}
+
+// upsertLink creates or updates a link within tx.
+func upsertLink(tx *bolt.Tx, link *graph.Link) error {
+	var (
+		links = tx.Bucket(linksBucket)
+		urls  = tx.Bucket(linkURLsBucket)
+	)
+	link.RetrievedAt = normalizeTime(link.RetrievedAt)
+	link.Metadata.DiscoveredAt = normalizeTime(link.Metadata.DiscoveredAt)
+	link.Metadata.NextFetchAt = normalizeTime(link.Metadata.NextFetchAt)
+
+	// Check if a link with the same URL already exists. If so, convert
+	// this into an update and point the link ID to the existing link.
+	if idBytes := urls.Get([]byte(link.URL)); idBytes != nil {
+		existing, err := getLink(links, idBytes)
+		if err != nil {
+			return err
+		}
+
+		if link.RetrievedAt.After(existing.RetrievedAt) {
+			existing.RetrievedAt = link.RetrievedAt
+		}
+		existing.Metadata.Merge(link.Metadata)
+		if err = putJSON(links, existing.ID[:], existing); err != nil {
+			return err
+		}
+
+		*link = *existing
+		return nil
+	}
+
+	// Assign new ID and insert link
+	for {
+		link.ID = uuid.New()
+		if links.Get(link.ID[:]) == nil {
+			break
+		}
+	}
+	if link.Metadata.DiscoveredAt.IsZero() {
+		link.Metadata.DiscoveredAt = normalizeTime(time.Now())
+	}
+
+	if err := putJSON(links, link.ID[:], link); err != nil {
+		return err
+	}
+	return urls.Put([]byte(link.URL), link.ID[:])
+}
+
+// FindLink looks up a link by its ID.
+func (g *BoltGraph) FindLink(ctx context.Context, id uuid.UUID) (*graph.Link, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, xerrors.Errorf("find link: %w", err)
+	}
+
+	var link *graph.Link
+	err := g.db.View(func(tx *bolt.Tx) (err error) {
+		link, err = getLink(tx.Bucket(linksBucket), id[:])
+		return err
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("find link: %w", err)
+	}
+	return link, nil
+}
+
+// FindLinkByURL looks up a link by its URL.
+func (g *BoltGraph) FindLinkByURL(ctx context.Context, url string) (*graph.Link, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, xerrors.Errorf("find link by URL: %w", err)
+	}
+
+	var link *graph.Link
+	err := g.db.View(func(tx *bolt.Tx) (err error) {
+		idBytes := tx.Bucket(linkURLsBucket).Get([]byte(url))
+		if idBytes == nil {
+			return graph.ErrNotFound
+		}
+
+		link, err = getLink(tx.Bucket(linksBucket), idBytes)
+		return err
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("find link by URL: %w", err)
+	}
+	return link, nil
+}
+
+// FindLinksByURL looks up a batch of links by their URLs. URLs that do not
+// correspond to a known link are omitted from the returned list and
+// duplicate URLs are only returned once.
+func (g *BoltGraph) FindLinksByURL(ctx context.Context, urls []string) ([]*graph.Link, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, xerrors.Errorf("find links by URL: %w", err)
+	}
+
+	var list []*graph.Link
+	err := g.db.View(func(tx *bolt.Tx) error {
+		var (
+			links = tx.Bucket(linksBucket)
+			index = tx.Bucket(linkURLsBucket)
+			seen  = make(map[string]bool, len(urls))
+		)
+		for _, url := range urls {
+			idBytes := index.Get([]byte(url))
+			if idBytes == nil || seen[url] {
+				continue
+			}
+			seen[url] = true
+
+			link, err := getLink(links, idBytes)
+			if err != nil {
+				return err
+			}
+			list = append(list, link)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("find links by URL: %w", err)
+	}
+	return list, nil
+}
+
+// RemoveLink removes the link with the specified ID together with any edges
+// that originate from or point to it.
+func (g *BoltGraph) RemoveLink(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return xerrors.Errorf("remove link: %w", err)
+	}
+
+	err := g.db.Update(func(tx *bolt.Tx) error {
+		link, err := getLink(tx.Bucket(linksBucket), id[:])
+		if err != nil {
+			return err
+		}
+
+		edgeKeys := append(
+			prefixKeys(tx.Bucket(outEdgesBucket), id[:]),
+			prefixKeys(tx.Bucket(inEdgesBucket), id[:])...,
+		)
+		for _, key := range edgeKeys {
+			edge, err := getEdge(tx.Bucket(edgesBucket), key[len(id):])
+			if xerrors.Is(err, graph.ErrNotFound) {
+				// Self-referencing edges appear in both indices.
+				continue
+			} else if err != nil {
+				return err
+			}
+			if err = removeEdge(tx, edge); err != nil {
+				return err
+			}
+		}
+
+		if err = tx.Bucket(linkURLsBucket).Delete([]byte(link.URL)); err != nil {
+			return err
+		}
+		return tx.Bucket(linksBucket).Delete(id[:])
+	})
+	if err != nil {
+		return xerrors.Errorf("remove link: %w", err)
+	}
+	return nil
+}
+
+// UpsertEdge creates a new edge or updates an existing edge.
+func (g *BoltGraph) UpsertEdge(ctx context.Context, edge *graph.Edge) error {
+	if err := ctx.Err(); err != nil {
+		return xerrors.Errorf("upsert edge: %w", err)
+	}
+
+	err := g.db.Update(func(tx *bolt.Tx) error {
+		return upsertEdge(tx, edge)
+	})
+	if err != nil {
+		return xerrors.Errorf("upsert edge: %w", err)
+	}
+	return nil
+}
+
+// UpsertEdges creates or updates a batch of edges in a single transaction.
+// Edges whose source or destination links are unknown are skipped and
+// reported via a *graph.BatchError.
+func (g *BoltGraph) UpsertEdges(ctx context.Context, edges []*graph.Edge) error {
+	if err := ctx.Err(); err != nil {
+		return xerrors.Errorf("upsert edges: %w", err)
+	}
+
+	var batchErr *graph.BatchError
+	err := g.db.Update(func(tx *bolt.Tx) error {
+		for i, edge := range edges {
+			err := upsertEdge(tx, edge)
+			if xerrors.Is(err, graph.ErrUnknownEdgeLinks) {
+				if batchErr == nil {
+					batchErr = &graph.BatchError{Errors: make([]error, len(edges))}
+				}
+				batchErr.Errors[i] = err
+				continue
+			} else if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("upsert edges: %w", err)
+	} else if batchErr != nil {
+		return xerrors.Errorf("upsert edges: %w", batchErr)
+	}
+	return nil
+}
+
+// upsertEdge creates or updates an edge within tx.
+func upsertEdge(tx *bolt.Tx, edge *graph.Edge) error {
+	links := tx.Bucket(linksBucket)
+	if links.Get(edge.Src[:]) == nil || links.Get(edge.Dst[:]) == nil {
+		return graph.ErrUnknownEdgeLinks
+	}
+
+	var (
+		edges    = tx.Bucket(edgesBucket)
+		edgeKeys = tx.Bucket(edgeKeysBucket)
+		key      = indexKey(edge.Src, edge.Dst)
+	)
+	edge.UpdatedAt = normalizeTime(time.Now())
+
+	// If the edge already exists, replace its attributes with the most
+	// recent ones.
+	if idBytes := edgeKeys.Get(key); idBytes != nil {
+		id, err := uuid.FromBytes(idBytes)
+		if err != nil {
+			return err
+		}
+		edge.ID = id
+		return putJSON(edges, edge.ID[:], edge)
+	}
+
+	for {
+		edge.ID = uuid.New()
+		if edges.Get(edge.ID[:]) == nil {
+			break
+		}
+	}
+
+	return insertEdge(tx, edge)
+}
+
+// insertEdge stores a new edge and its index entries within tx.
+func insertEdge(tx *bolt.Tx, edge *graph.Edge) error {
+	if err := putJSON(tx.Bucket(edgesBucket), edge.ID[:], edge); err != nil {
+		return err
+	}
+	if err := tx.Bucket(edgeKeysBucket).Put(indexKey(edge.Src, edge.Dst), edge.ID[:]); err != nil {
+		return err
+	}
+	if err := tx.Bucket(outEdgesBucket).Put(indexKey(edge.Src, edge.ID), nil); err != nil {
+		return err
+	}
+	if err := tx.Bucket(edgeSrcsBucket).Put(edge.ID[:], edge.Src[:]); err != nil {
+		return err
+	}
+	return tx.Bucket(inEdgesBucket).Put(indexKey(edge.Dst, edge.ID), nil)
+}
+
+// indexEdgeSrcs adds an edge_srcs entry for every stored edge within tx.
+func indexEdgeSrcs(tx *bolt.Tx) error {
+	srcs := tx.Bucket(edgeSrcsBucket)
+	return tx.Bucket(edgesBucket).ForEach(func(id, data []byte) error {
+		edge := new(graph.Edge)
+		if err := json.Unmarshal(data, edge); err != nil {
+			return err
+		}
+		return srcs.Put(id, edge.Src[:])
+	})
+}
+
+// RemoveEdge removes the edge with the specified ID.
+func (g *BoltGraph) RemoveEdge(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return xerrors.Errorf("remove edge: %w", err)
+	}
+
+	err := g.db.Update(func(tx *bolt.Tx) error {
+		edge, err := getEdge(tx.Bucket(edgesBucket), id[:])
+		if err != nil {
+			return err
+		}
+		return removeEdge(tx, edge)
+	})
+	if err != nil {
+		return xerrors.Errorf("remove edge: %w", err)
+	}
+	return nil
+}
+
+// RemoveStaleEdges removes any edge that originates from the specified link ID
+// and was updated before the specified timestamp.
+func (g *BoltGraph) RemoveStaleEdges(ctx context.Context, fromID uuid.UUID, updatedBefore time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return xerrors.Errorf("remove stale edges: %w", err)
+	}
+
+	err := g.db.Update(func(tx *bolt.Tx) error {
+		for _, key := range prefixKeys(tx.Bucket(outEdgesBucket), fromID[:]) {
+			edge, err := getEdge(tx.Bucket(edgesBucket), key[len(fromID):])
+			if err != nil {
+				return err
+			}
+			if !edge.UpdatedAt.Before(updatedBefore) {
+				continue
+			}
+			if err = removeEdge(tx, edge); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("remove stale edges: %w", err)
+	}
+	return nil
+}
+
+// removeEdge deletes edge and its index entries within tx.
+func removeEdge(tx *bolt.Tx, edge *graph.Edge) error {
+	if err := tx.Bucket(edgeKeysBucket).Delete(indexKey(edge.Src, edge.Dst)); err != nil {
+		return err
+	}
+	if err := tx.Bucket(outEdgesBucket).Delete(indexKey(edge.Src, edge.ID)); err != nil {
+		return err
+	}
+	if err := tx.Bucket(inEdgesBucket).Delete(indexKey(edge.Dst, edge.ID)); err != nil {
+		return err
+	}
+	if err := tx.Bucket(edgeSrcsBucket).Delete(edge.ID[:]); err != nil {
+		return err
+	}
+	return tx.Bucket(edgesBucket).Delete(edge.ID[:])
+}
+
+// RestoreLinks inserts links or replaces the links with the same ID,
+// preserving their IDs and timestamps.
+func (g *BoltGraph) RestoreLinks(ctx context.Context, links []*graph.Link) error {
+	if err := ctx.Err(); err != nil {
+		return xerrors.Errorf("restore links: %w", err)
+	}
+
+	err := g.db.Update(func(tx *bolt.Tx) error {
+		var (
+			linkBucket = tx.Bucket(linksBucket)
+			urls       = tx.Bucket(linkURLsBucket)
+		)
+		for _, link := range links {
+			if idBytes := urls.Get([]byte(link.URL)); idBytes != nil && !bytes.Equal(idBytes, link.ID[:]) {
+				return xerrors.Errorf("url %q: %w", link.URL, graph.ErrConflict)
+			}
+			if existing, err := getLink(linkBucket, link.ID[:]); err == nil && existing.URL != link.URL {
+				if err = urls.Delete([]byte(existing.URL)); err != nil {
+					return err
+				}
+			}
+
+			if link.Metadata.DiscoveredAt.IsZero() {
+				link.Metadata.DiscoveredAt = time.Now()
+			}
+			link.RetrievedAt = normalizeTime(link.RetrievedAt)
+			link.Metadata.DiscoveredAt = normalizeTime(link.Metadata.DiscoveredAt)
+			link.Metadata.NextFetchAt = normalizeTime(link.Metadata.NextFetchAt)
+			if err := putJSON(linkBucket, link.ID[:], link); err != nil {
+				return err
+			}
+			if err := urls.Put([]byte(link.URL), link.ID[:]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("restore links: %w", err)
+	}
+	return nil
+}
+
+// RestoreEdges inserts edges or replaces the edges with the same ID,
+// preserving their IDs and timestamps.
+func (g *BoltGraph) RestoreEdges(ctx context.Context, edges []*graph.Edge) error {
+	if err := ctx.Err(); err != nil {
+		return xerrors.Errorf("restore edges: %w", err)
+	}
+
+	err := g.db.Update(func(tx *bolt.Tx) error {
+		var (
+			links      = tx.Bucket(linksBucket)
+			edgeBucket = tx.Bucket(edgesBucket)
+			edgeKeys   = tx.Bucket(edgeKeysBucket)
+		)
+		for _, edge := range edges {
+			if links.Get(edge.Src[:]) == nil || links.Get(edge.Dst[:]) == nil {
+				return xerrors.Errorf("edge %s: %w", edge.ID, graph.ErrUnknownEdgeLinks)
+			}
+
+			key := indexKey(edge.Src, edge.Dst)
+			if idBytes := edgeKeys.Get(key); idBytes != nil && !bytes.Equal(idBytes, edge.ID[:]) {
+				return xerrors.Errorf("edge %s: %w", edge.ID, graph.ErrConflict)
+			}
+			if existing, err := getEdge(edgeBucket, edge.ID[:]); err == nil {
+				if err = removeEdge(tx, existing); err != nil {
+					return err
+				}
+			}
+
+			edge.UpdatedAt = normalizeTime(edge.UpdatedAt)
+			if err := insertEdge(tx, edge); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("restore edges: %w", err)
+	}
+	return nil
+}
+
+// Links returns an iterator for the set of links whose IDs belong to the
+// [fromID, toID) range and were retrieved before the provided timestamp.
+func (g *BoltGraph) Links(ctx context.Context, fromID, toID uuid.UUID, retrievedBefore time.Time, opts ...graph.IteratorOption) (graph.LinkIterator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, xerrors.Errorf("links: %w", err)
+	}
+
+	itOpts := graph.ApplyIteratorOptions(opts...)
+	cursor, err := graph.ParseCursor(itOpts.Cursor)
+	if err != nil {
+		return nil, xerrors.Errorf("links: %w", err)
+	}
+
+	fetchFn := func(after uuid.UUID, limit int) ([]*graph.Link, error) {
+		var page []*graph.Link
+		err := g.db.View(func(tx *bolt.Tx) error {
+			c := tx.Bucket(linksBucket).Cursor()
+			for k, v := seekAfter(c, fromID, after); k != nil && bytes.Compare(k, toID[:]) < 0 && len(page) < limit; k, v = c.Next() {
+				link := new(graph.Link)
+				if err := json.Unmarshal(v, link); err != nil {
+					return err
+				}
+				if !link.RetrievedAt.Before(retrievedBefore) {
+					continue
+				}
+				if itOpts.HostHashes != nil && !itOpts.HostHashes.Contains(graph.HostHash(link.URL)) {
+					continue
+				}
+				page = append(page, link)
+			}
+			return nil
+		})
+		return page, err
+	}
+
+	return &linkIterator{ctx: ctx, fetchFn: fetchFn, pageSize: pageSize(itOpts), lastID: cursor}, nil
+}
+
+// MostDueLinks returns up to limit links in the [fromID, toID) range that
+// are due at dueAt, ordered by descending priority and ascending next fetch
+// time.
+func (g *BoltGraph) MostDueLinks(ctx context.Context, fromID, toID uuid.UUID, dueAt time.Time, limit int, opts ...graph.IteratorOption) ([]*graph.Link, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, xerrors.Errorf("most due links: %w", err)
+	} else if limit < 0 {
+		return nil, xerrors.Errorf("most due links: %w", graph.ErrInvalidLimit)
+	} else if limit == 0 {
+		return nil, nil
+	}
+
+	itOpts := graph.ApplyIteratorOptions(opts...)
+	var list []*graph.Link
+	err := g.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(linksBucket).Cursor()
+		for k, v := c.Seek(fromID[:]); k != nil && bytes.Compare(k, toID[:]) < 0; k, v = c.Next() {
+			link := new(graph.Link)
+			if err := json.Unmarshal(v, link); err != nil {
+				return err
+			}
+			if !link.IsDue(dueAt) {
+				continue
+			}
+			if itOpts.HostHashes != nil && !itOpts.HostHashes.Contains(graph.HostHash(link.URL)) {
+				continue
+			}
+			list = append(list, link)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("most due links: %w", err)
+	}
+
+	graph.SortByDue(list)
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+// Edges returns an iterator for the set of edges whose source vertex IDs
+// belong to the [fromID, toID) range and were last updated before the provided
+// value. Each page only holds up to the page size edges in memory, but the
+// index entries of edges from outside the range are skipped as well, so a
+// full scan reads one index entry per stored edge.
+func (g *BoltGraph) Edges(ctx context.Context, fromID, toID uuid.UUID, updatedBefore time.Time, opts ...graph.IteratorOption) (graph.EdgeIterator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, xerrors.Errorf("edges: %w", err)
+	}
+
+	itOpts := graph.ApplyIteratorOptions(opts...)
+	cursor, err := graph.ParseCursor(itOpts.Cursor)
+	if err != nil {
+		return nil, xerrors.Errorf("edges: %w", err)
+	}
+
+	// Edges are returned in ID order so that scans can be resumed. Each
+	// page walks the edge_srcs index from the cursor and only decodes the
+	// edges whose source belongs to the [fromID, toID) range.
+	fetchFn := func(after uuid.UUID, limit int) ([]*graph.Edge, error) {
+		var page []*graph.Edge
+		err := g.db.View(func(tx *bolt.Tx) error {
+			var (
+				edges = tx.Bucket(edgesBucket)
+				c     = tx.Bucket(edgeSrcsBucket).Cursor()
+			)
+			for id, src := c.Seek(after[:]); id != nil && len(page) < limit; id, src = c.Next() {
+				if bytes.Equal(id, after[:]) || bytes.Compare(src, fromID[:]) < 0 || bytes.Compare(src, toID[:]) >= 0 {
+					continue
+				}
+
+				edge, err := getEdge(edges, id)
+				if err != nil {
+					return err
+				}
+				if edgeMatches(edge, updatedBefore, itOpts) {
+					page = append(page, edge)
+				}
+			}
+			return nil
+		})
+		return page, err
+	}
+
+	return &edgeIterator{ctx: ctx, fetchFn: fetchFn, pageSize: pageSize(itOpts), lastID: cursor}, nil
+}
+
+// InboundEdges returns an iterator for the set of edges that point to the
+// specified link ID and were last updated before the provided value.
+func (g *BoltGraph) InboundEdges(ctx context.Context, dstID uuid.UUID, updatedBefore time.Time, opts ...graph.IteratorOption) (graph.EdgeIterator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, xerrors.Errorf("inbound edges: %w", err)
+	}
+
+	itOpts := graph.ApplyIteratorOptions(opts...)
+	cursor, err := graph.ParseCursor(itOpts.Cursor)
+	if err != nil {
+		return nil, xerrors.Errorf("inbound edges: %w", err)
+	}
+
+	fetchFn := func(after uuid.UUID, limit int) ([]*graph.Edge, error) {
+		var page []*graph.Edge
+		err := g.db.View(func(tx *bolt.Tx) error {
+			// Index keys are sorted by edge ID, so the scan starts at the
+			// cursor and stops as soon as the page is full.
+			var (
+				edges = tx.Bucket(edgesBucket)
+				c     = tx.Bucket(inEdgesBucket).Cursor()
+			)
+			for k, _ := c.Seek(indexKey(dstID, after)); k != nil && bytes.HasPrefix(k, dstID[:]) && len(page) < limit; k, _ = c.Next() {
+				edgeID := k[len(dstID):]
+				if bytes.Equal(edgeID, after[:]) {
+					continue
+				}
+
+				edge, err := getEdge(edges, edgeID)
+				if err != nil {
+					return err
+				}
+				if edgeMatches(edge, updatedBefore, itOpts) {
+					page = append(page, edge)
+				}
+			}
+			return nil
+		})
+		return page, err
+	}
+
+	return &edgeIterator{ctx: ctx, fetchFn: fetchFn, pageSize: pageSize(itOpts), lastID: cursor}, nil
+}
+
+// InDegree returns the number of edges that point to the specified link ID.
+func (g *BoltGraph) InDegree(ctx context.Context, id uuid.UUID) (int, error) {
+	degree, err := g.countIndexEntries(ctx, inEdgesBucket, id)
+	if err != nil {
+		return 0, xerrors.Errorf("in degree: %w", err)
+	}
+	return degree, nil
+}
+
+// OutDegree returns the number of edges that originate from the specified
+// link ID.
+func (g *BoltGraph) OutDegree(ctx context.Context, id uuid.UUID) (int, error) {
+	degree, err := g.countIndexEntries(ctx, outEdgesBucket, id)
+	if err != nil {
+		return 0, xerrors.Errorf("out degree: %w", err)
+	}
+	return degree, nil
+}
+
+// Stats returns a summary of the graph contents. Unlike the other stores,
+// BoltGraph does not maintain counters; the link and edge index buckets are
+// scanned instead.
+func (g *BoltGraph) Stats(ctx context.Context) (*graph.Stats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, xerrors.Errorf("stats: %w", err)
+	}
+
+	stats := &graph.Stats{
+		OutDegreeHistogram: make(map[int]int),
+		HostLinks:          make(map[string]int),
+	}
+	err := g.db.View(func(tx *bolt.Tx) error {
+		stats.Edges = tx.Bucket(edgesBucket).Stats().KeyN
+
+		err := tx.Bucket(linksBucket).ForEach(func(_, v []byte) error {
+			link := new(graph.Link)
+			if err := json.Unmarshal(v, link); err != nil {
+				return err
+			}
+
+			stats.Links++
+			if link.RetrievedAt.IsZero() {
+				stats.UncrawledLinks++
+			}
+			stats.HostLinks[graph.LinkHost(link.URL)]++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// Out-edge index keys are grouped by source link ID.
+		var (
+			linksWithEdges, degree int
+			src                    []byte
+		)
+		countDegree := func() {
+			if degree > 0 {
+				stats.OutDegreeHistogram[degree]++
+				linksWithEdges++
+			}
+		}
+		c := tx.Bucket(outEdgesBucket).Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			if !bytes.Equal(k[:len(uuid.Nil)], src) {
+				countDegree()
+				src = append(src[:0], k[:len(uuid.Nil)]...)
+				degree = 0
+			}
+			degree++
+		}
+		countDegree()
+
+		if linksWithoutEdges := stats.Links - linksWithEdges; linksWithoutEdges > 0 {
+			stats.OutDegreeHistogram[0] = linksWithoutEdges
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("stats: %w", err)
+	}
+	return stats, nil
+}
+
+// countIndexEntries returns the number of entries in the specified edge index
+// bucket that belong to the link with the specified ID.
+func (g *BoltGraph) countIndexEntries(ctx context.Context, bucket []byte, id uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	var count int
+	err := g.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(linksBucket).Get(id[:]) == nil {
+			return graph.ErrNotFound
+		}
+
+		c := tx.Bucket(bucket).Cursor()
+		for k, _ := c.Seek(id[:]); k != nil && bytes.HasPrefix(k, id[:]); k, _ = c.Next() {
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// edgeMatches returns true if edge was updated before the specified
+// timestamp and is not excluded by the iterator options.
+func edgeMatches(edge *graph.Edge, updatedBefore time.Time, opts graph.IteratorOptions) bool {
+	if opts.ExcludeNoFollow && edge.NoFollow {
+		return false
+	}
+	return edge.UpdatedAt.Before(updatedBefore)
+}
+
+// seekAfter positions c at the first key that is greater than after and not
+// less than from.
+func seekAfter(c *bolt.Cursor, from, after uuid.UUID) ([]byte, []byte) {
+	if after == uuid.Nil || bytes.Compare(after[:], from[:]) < 0 {
+		return c.Seek(from[:])
+	}
+
+	k, v := c.Seek(after[:])
+	if k != nil && bytes.Equal(k, after[:]) {
+		k, v = c.Next()
+	}
+	return k, v
+}
+
+// prefixKeys returns a copy of all keys in b that start with prefix.
+func prefixKeys(b *bolt.Bucket, prefix []byte) [][]byte {
+	var (
+		keys [][]byte
+		c    = b.Cursor()
+	)
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		keys = append(keys, append([]byte(nil), k...))
+	}
+	return keys
+}
+
+// indexKey concatenates two IDs into a single index key.
+func indexKey(a, b uuid.UUID) []byte {
+	key := make([]byte, 0, len(a)+len(b))
+	key = append(key, a[:]...)
+	return append(key, b[:]...)
+}
+
+func getLink(b *bolt.Bucket, id []byte) (*graph.Link, error) {
+	data := b.Get(id)
+	if data == nil {
+		return nil, graph.ErrNotFound
+	}
+
+	link := new(graph.Link)
+	if err := json.Unmarshal(data, link); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+func getEdge(b *bolt.Bucket, id []byte) (*graph.Edge, error) {
+	data := b.Get(id)
+	if data == nil {
+		return nil, graph.ErrNotFound
+	}
+
+	edge := new(graph.Edge)
+	if err := json.Unmarshal(data, edge); err != nil {
+		return nil, err
+	}
+	return edge, nil
+}
+
+func putJSON(b *bolt.Bucket, key []byte, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, data)
+}
+
+// normalizeTime converts t to UTC and strips its monotonic clock reading so
+// that it round-trips through the JSON encoding unchanged.
+func normalizeTime(t time.Time) time.Time {
+	return t.UTC().Round(0)
+}
+
+// pageSize returns the page size requested via opts or the default one.
+func pageSize(opts graph.IteratorOptions) int {
+	if opts.PageSize > 0 {
+		return opts.PageSize
+	}
+	return defaultPageSize
+}
@@ -0,0 +1,116 @@
+package boltdb
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph/graphtest"
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+	gc "gopkg.in/check.v1"
+)
+
+var _ = gc.Suite(new(BoltGraphTestSuite))
+
+func Test(t *testing.T) { gc.TestingT(t) }
+
+type BoltGraphTestSuite struct {
+	graphtest.SuiteBase
+	g *BoltGraph
+}
+
+func (s *BoltGraphTestSuite) SetUpTest(c *gc.C) {
+	g, err := NewBoltGraph(filepath.Join(c.MkDir(), "graph.db"))
+	c.Assert(err, gc.IsNil)
+	s.SetGraph(g)
+	s.g = g
+}
+
+func (s *BoltGraphTestSuite) TearDownTest(c *gc.C) {
+	if s.g != nil {
+		c.Assert(s.g.Close(), gc.IsNil)
+	}
+}
+
+func (s *BoltGraphTestSuite) TestReopenPersistsData(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "reopen.db")
+	g, err := NewBoltGraph(path)
+	c.Assert(err, gc.IsNil)
+
+	src := &graph.Link{URL: "https://example.com/a"}
+	dst := &graph.Link{URL: "https://example.com/b"}
+	c.Assert(g.UpsertLinks(context.TODO(), []*graph.Link{src, dst}), gc.IsNil)
+	c.Assert(g.UpsertEdge(context.TODO(), &graph.Edge{Src: src.ID, Dst: dst.ID}), gc.IsNil)
+	c.Assert(g.Close(), gc.IsNil)
+
+	g, err = NewBoltGraph(path)
+	c.Assert(err, gc.IsNil)
+	defer func() { c.Assert(g.Close(), gc.IsNil) }()
+
+	got, err := g.FindLinkByURL(context.TODO(), src.URL)
+	c.Assert(err, gc.IsNil)
+	c.Assert(got.ID, gc.Equals, src.ID)
+
+	degree, err := g.OutDegree(context.TODO(), src.ID)
+	c.Assert(err, gc.IsNil)
+	c.Assert(degree, gc.Equals, 1)
+}
+
+func (s *BoltGraphTestSuite) TestReopenIndexesEdgeSources(c *gc.C) {
+	path := filepath.Join(c.MkDir(), "legacy.db")
+	g, err := NewBoltGraph(path)
+	c.Assert(err, gc.IsNil)
+
+	src := &graph.Link{URL: "https://example.com/a"}
+	dst := &graph.Link{URL: "https://example.com/b"}
+	c.Assert(g.UpsertLinks(context.TODO(), []*graph.Link{src, dst}), gc.IsNil)
+	edge := &graph.Edge{Src: src.ID, Dst: dst.ID}
+	c.Assert(g.UpsertEdge(context.TODO(), edge), gc.IsNil)
+
+	// Mimic a file created before the edge_srcs index was introduced.
+	err = g.db.Update(func(tx *bolt.Tx) error { return tx.DeleteBucket(edgeSrcsBucket) })
+	c.Assert(err, gc.IsNil)
+	c.Assert(g.Close(), gc.IsNil)
+
+	g, err = NewBoltGraph(path)
+	c.Assert(err, gc.IsNil)
+	defer func() { c.Assert(g.Close(), gc.IsNil) }()
+
+	it, err := g.Edges(context.TODO(), src.ID, maxUUID, time.Now())
+	c.Assert(err, gc.IsNil)
+	c.Assert(it.Next(), gc.Equals, true)
+	c.Assert(it.Edge().ID, gc.Equals, edge.ID)
+	c.Assert(it.Next(), gc.Equals, false)
+	c.Assert(it.Close(), gc.IsNil)
+}
+
+func (s *BoltGraphTestSuite) TestEdgesAddedDuringScan(c *gc.C) {
+	var links []*graph.Link
+	for _, url := range []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"} {
+		links = append(links, &graph.Link{URL: url})
+	}
+	c.Assert(s.g.UpsertLinks(context.TODO(), links), gc.IsNil)
+
+	// Restore the edges with fixed IDs so that the second one follows
+	// the first page.
+	first := &graph.Edge{ID: uuid.MustParse("00000000-0000-0000-0000-000000000001"), Src: links[0].ID, Dst: links[1].ID}
+	c.Assert(s.g.RestoreEdges(context.TODO(), []*graph.Edge{first}), gc.IsNil)
+
+	it, err := s.g.Edges(context.TODO(), uuid.Nil, maxUUID, time.Now().Add(time.Hour), graph.WithPageSize(1))
+	c.Assert(err, gc.IsNil)
+	c.Assert(it.Next(), gc.Equals, true)
+	c.Assert(it.Edge().ID, gc.Equals, first.ID)
+
+	second := &graph.Edge{ID: uuid.MustParse("00000000-0000-0000-0000-000000000002"), Src: links[1].ID, Dst: links[2].ID}
+	c.Assert(s.g.RestoreEdges(context.TODO(), []*graph.Edge{second}), gc.IsNil)
+	c.Assert(it.Next(), gc.Equals, true)
+	c.Assert(it.Edge().ID, gc.Equals, second.ID)
+	c.Assert(it.Next(), gc.Equals, false)
+	c.Assert(it.Error(), gc.IsNil)
+	c.Assert(it.Close(), gc.IsNil)
+}
+
+var maxUUID = uuid.MustParse("ffffffff-ffff-ffff-ffff-ffffffffffff")
@@ -0,0 +1,150 @@
+package boltdb
+
+import (
+	"context"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+	"github.com/google/uuid"
+)
+
+// linkIterator is a graph.LinkIterator implementation for the bolt graph.
+// Links are fetched one page at a time, each in its own read transaction.
+type linkIterator struct {
+	ctx      context.Context
+	fetchFn  func(after uuid.UUID, limit int) ([]*graph.Link, error)
+	pageSize int
+
+	page        []*graph.Link
+	pageIndex   int
+	done        bool
+	lastID      uuid.UUID
+	latchedLink *graph.Link
+	lastErr     error
+}
+
+// Next implements graph.LinkIterator.
+func (i *linkIterator) Next() bool {
+	if i.lastErr != nil {
+		return false
+	}
+	if i.lastErr = i.ctx.Err(); i.lastErr != nil {
+		return false
+	}
+
+	if i.pageIndex >= len(i.page) {
+		if i.done {
+			return false
+		}
+		if i.page, i.lastErr = i.fetchFn(i.lastID, i.pageSize); i.lastErr != nil {
+			return false
+		}
+		i.pageIndex = 0
+
+		// A partially filled page indicates that there are no more links.
+		i.done = len(i.page) < i.pageSize
+		if len(i.page) == 0 {
+			return false
+		}
+	}
+
+	i.latchedLink = i.page[i.pageIndex]
+	i.lastID = i.latchedLink.ID
+	i.pageIndex++
+	return true
+}
+
+// Error implements graph.LinkIterator.
+func (i *linkIterator) Error() error {
+	return i.lastErr
+}
+
+// Cursor implements graph.LinkIterator.
+func (i *linkIterator) Cursor() string {
+	if i.lastID == uuid.Nil {
+		return ""
+	}
+	return i.lastID.String()
+}
+
+// Close implements graph.LinkIterator.
+func (i *linkIterator) Close() error {
+	i.done = true
+	i.page = nil
+	return nil
+}
+
+// Link implements graph.LinkIterator.
+func (i *linkIterator) Link() *graph.Link {
+	return i.latchedLink
+}
+
+// edgeIterator is a graph.EdgeIterator implementation for the bolt graph.
+// Edges are fetched one page at a time, each in its own read transaction.
+type edgeIterator struct {
+	ctx      context.Context
+	fetchFn  func(after uuid.UUID, limit int) ([]*graph.Edge, error)
+	pageSize int
+
+	page        []*graph.Edge
+	pageIndex   int
+	done        bool
+	lastID      uuid.UUID
+	latchedEdge *graph.Edge
+	lastErr     error
+}
+
+// Next implements graph.EdgeIterator.
+func (i *edgeIterator) Next() bool {
+	if i.lastErr != nil {
+		return false
+	}
+	if i.lastErr = i.ctx.Err(); i.lastErr != nil {
+		return false
+	}
+
+	if i.pageIndex >= len(i.page) {
+		if i.done {
+			return false
+		}
+		if i.page, i.lastErr = i.fetchFn(i.lastID, i.pageSize); i.lastErr != nil {
+			return false
+		}
+		i.pageIndex = 0
+
+		// A partially filled page indicates that there are no more edges.
+		i.done = len(i.page) < i.pageSize
+		if len(i.page) == 0 {
+			return false
+		}
+	}
+
+	i.latchedEdge = i.page[i.pageIndex]
+	i.lastID = i.latchedEdge.ID
+	i.pageIndex++
+	return true
+}
+
+// Error implements graph.EdgeIterator.
+func (i *edgeIterator) Error() error {
+	return i.lastErr
+}
+
+// Cursor implements graph.EdgeIterator.
+func (i *edgeIterator) Cursor() string {
+	if i.lastID == uuid.Nil {
+		return ""
+	}
+	return i.lastID.String()
+}
+
+// Close implements graph.EdgeIterator.
+func (i *edgeIterator) Close() error {
+	i.done = true
+	i.page = nil
+	return nil
+}
+
+// Edge implements graph.EdgeIterator.
+func (i *edgeIterator) Edge() *graph.Edge {
+	return i.latchedEdge
+}
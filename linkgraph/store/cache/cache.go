@@ -0,0 +1,260 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// Compile-time checks for ensuring CachingGraph implements Graph and the
+// optional graph interfaces.
+var (
+	_ graph.Graph            = (*CachingGraph)(nil)
+	_ graph.Restorer         = (*CachingGraph)(nil)
+	_ graph.ChangeSubscriber = (*CachingGraph)(nil)
+)
+
+// CacheStats holds the hit and miss counters of a CachingGraph.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// CachingGraph wraps a graph.Graph and caches the links returned by the
+// FindLink* methods in a bounded LRU cache. Cached links are invalidated by
+// the link mutations performed through the wrapper; mutations applied to the
+// wrapped graph by other means are not detected. Calls to the
+// graph.Restorer and graph.ChangeSubscriber methods are forwarded to the
+// wrapped graph and fail with graph.ErrNotSupported if it does not implement
+// them.
+type CachingGraph struct {
+	graph.Graph
+
+	mu       sync.Mutex
+	size     int
+	lru      *list.List
+	byID     map[uuid.UUID]*list.Element
+	byURL    map[string]*list.Element
+	stats    CacheStats
+	mutation uint64
+}
+
+// NewCachingGraph returns a CachingGraph that caches up to size links
+// fetched from g.
+func NewCachingGraph(g graph.Graph, size int) (*CachingGraph, error) {
+	if size <= 0 {
+		return nil, xerrors.Errorf("caching graph: invalid cache size %d", size)
+	}
+
+	return &CachingGraph{
+		Graph: g,
+		size:  size,
+		lru:   list.New(),
+		byID:  make(map[uuid.UUID]*list.Element),
+		byURL: make(map[string]*list.Element),
+	}, nil
+}
+
+// CacheStats returns the hit, miss and eviction counters of the cache.
+func (c *CachingGraph) CacheStats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// FindLink looks up a link by its ID, consulting the cache first.
+func (c *CachingGraph) FindLink(ctx context.Context, id uuid.UUID) (*graph.Link, error) {
+	c.mu.Lock()
+	if link := c.lookup(c.byID[id]); link != nil {
+		c.mu.Unlock()
+		return link, nil
+	}
+	mutation := c.mutation
+	c.mu.Unlock()
+
+	link, err := c.Graph.FindLink(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.store(mutation, link)
+	return link, nil
+}
+
+// FindLinkByURL looks up a link by its URL, consulting the cache first.
+func (c *CachingGraph) FindLinkByURL(ctx context.Context, url string) (*graph.Link, error) {
+	c.mu.Lock()
+	if link := c.lookup(c.byURL[url]); link != nil {
+		c.mu.Unlock()
+		return link, nil
+	}
+	mutation := c.mutation
+	c.mu.Unlock()
+
+	link, err := c.Graph.FindLinkByURL(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	c.store(mutation, link)
+	return link, nil
+}
+
+// FindLinksByURL looks up a list of links by their URLs. Only the URLs that
+// are not cached are looked up in the wrapped graph.
+func (c *CachingGraph) FindLinksByURL(ctx context.Context, urls []string) ([]*graph.Link, error) {
+	var (
+		found  = make(map[string]*graph.Link, len(urls))
+		missed []string
+	)
+	c.mu.Lock()
+	for _, url := range urls {
+		if _, seen := found[url]; seen {
+			continue
+		}
+		if link := c.lookup(c.byURL[url]); link != nil {
+			found[url] = link
+			continue
+		}
+		found[url] = nil
+		missed = append(missed, url)
+	}
+	mutation := c.mutation
+	c.mu.Unlock()
+
+	if len(missed) != 0 {
+		fetched, err := c.Graph.FindLinksByURL(ctx, missed)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range fetched {
+			found[link.URL] = link
+			c.store(mutation, link)
+		}
+	}
+
+	// Return the links in the order of the requested URLs.
+	var list []*graph.Link
+	for _, url := range urls {
+		if link := found[url]; link != nil {
+			list = append(list, link)
+			delete(found, url)
+		}
+	}
+	return list, nil
+}
+
+// UpsertLink creates or updates a link and invalidates any cached copy.
+func (c *CachingGraph) UpsertLink(ctx context.Context, link *graph.Link) error {
+	defer c.invalidate(link)
+	return c.Graph.UpsertLink(ctx, link)
+}
+
+// UpsertLinks creates or updates a batch of links and invalidates any cached
+// copies.
+func (c *CachingGraph) UpsertLinks(ctx context.Context, links []*graph.Link) error {
+	defer c.invalidate(links...)
+	return c.Graph.UpsertLinks(ctx, links)
+}
+
+// RemoveLink removes a link and invalidates any cached copy.
+func (c *CachingGraph) RemoveLink(ctx context.Context, id uuid.UUID) error {
+	defer c.invalidate(&graph.Link{ID: id})
+	return c.Graph.RemoveLink(ctx, id)
+}
+
+// RestoreLinks stores links with their original IDs and invalidates any
+// cached copies of the links they replace.
+func (c *CachingGraph) RestoreLinks(ctx context.Context, links []*graph.Link) error {
+	r, ok := c.Graph.(graph.Restorer)
+	if !ok {
+		return xerrors.Errorf("restore links: %w", graph.ErrNotSupported)
+	}
+	defer c.invalidate(links...)
+	return r.RestoreLinks(ctx, links)
+}
+
+// RestoreEdges stores edges with their original IDs.
+func (c *CachingGraph) RestoreEdges(ctx context.Context, edges []*graph.Edge) error {
+	r, ok := c.Graph.(graph.Restorer)
+	if !ok {
+		return xerrors.Errorf("restore edges: %w", graph.ErrNotSupported)
+	}
+	return r.RestoreEdges(ctx, edges)
+}
+
+// Subscribe returns an iterator over the changes applied to the wrapped
+// graph.
+func (c *CachingGraph) Subscribe(ctx context.Context) (graph.ChangeIterator, error) {
+	sub, ok := c.Graph.(graph.ChangeSubscriber)
+	if !ok {
+		return nil, xerrors.Errorf("subscribe: %w", graph.ErrNotSupported)
+	}
+	return sub.Subscribe(ctx)
+}
+
+// lookup returns a copy of the link cached in elem and updates the hit and
+// miss counters. Callers must hold c.mu.
+func (c *CachingGraph) lookup(elem *list.Element) *graph.Link {
+	if elem == nil {
+		c.stats.Misses++
+		return nil
+	}
+
+	c.stats.Hits++
+	c.lru.MoveToFront(elem)
+	lCopy := new(graph.Link)
+	*lCopy = *elem.Value.(*graph.Link)
+	return lCopy
+}
+
+// store caches a copy of link unless the cache has been invalidated since
+// the mutation counter was sampled, as the link may then be stale.
+func (c *CachingGraph) store(mutation uint64, link *graph.Link) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if mutation != c.mutation {
+		return
+	}
+
+	lCopy := new(graph.Link)
+	*lCopy = *link
+	c.remove(c.byID[link.ID])
+	c.remove(c.byURL[link.URL])
+
+	elem := c.lru.PushFront(lCopy)
+	c.byID[lCopy.ID] = elem
+	c.byURL[lCopy.URL] = elem
+
+	for c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+// invalidate drops the cached copies of the specified links, matching them
+// both by ID and by URL.
+func (c *CachingGraph) invalidate(links ...*graph.Link) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.mutation++
+	for _, link := range links {
+		c.remove(c.byID[link.ID])
+		c.remove(c.byURL[link.URL])
+	}
+}
+
+// remove drops elem from the cache. Callers must hold c.mu.
+func (c *CachingGraph) remove(elem *list.Element) {
+	if elem == nil {
+		return
+	}
+
+	link := c.lru.Remove(elem).(*graph.Link)
+	delete(c.byID, link.ID)
+	delete(c.byURL, link.URL)
+}
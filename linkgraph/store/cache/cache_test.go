@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph/graphtest"
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/store/memory"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+	gc "gopkg.in/check.v1"
+)
+
+var _ = gc.Suite(new(CachingGraphTestSuite))
+
+func Test(t *testing.T) { gc.TestingT(t) }
+
+type CachingGraphTestSuite struct {
+	graphtest.SuiteBase
+	g *CachingGraph
+}
+
+func (s *CachingGraphTestSuite) SetUpTest(c *gc.C) {
+	// Use a small cache so that the shared tests also exercise evictions.
+	g, err := NewCachingGraph(memory.NewInMemoryGraph(), 8)
+	c.Assert(err, gc.IsNil)
+	s.SetGraph(g)
+	s.g = g
+}
+
+func (s *CachingGraphTestSuite) TestHitsAndMisses(c *gc.C) {
+	link := &graph.Link{URL: "https://example.com"}
+	c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
+
+	for i := 0; i < 3; i++ {
+		got, err := s.g.FindLink(context.TODO(), link.ID)
+		c.Assert(err, gc.IsNil)
+		c.Assert(got.URL, gc.Equals, link.URL)
+	}
+	got, err := s.g.FindLinkByURL(context.TODO(), link.URL)
+	c.Assert(err, gc.IsNil)
+	c.Assert(got.ID, gc.Equals, link.ID)
+	c.Assert(s.g.CacheStats(), gc.DeepEquals, CacheStats{Hits: 3, Misses: 1})
+
+	// Callers cannot modify the cached copy.
+	got.URL = "https://example.com/modified"
+	got, err = s.g.FindLink(context.TODO(), link.ID)
+	c.Assert(err, gc.IsNil)
+	c.Assert(got.URL, gc.Equals, link.URL)
+}
+
+func (s *CachingGraphTestSuite) TestInvalidation(c *gc.C) {
+	link := &graph.Link{URL: "https://example.com"}
+	c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
+	_, err := s.g.FindLink(context.TODO(), link.ID)
+	c.Assert(err, gc.IsNil)
+
+	c.Assert(s.g.UpsertLink(context.TODO(), &graph.Link{
+		URL:      link.URL,
+		Metadata: graph.LinkMetadata{StatusCode: 200},
+	}), gc.IsNil)
+	got, err := s.g.FindLink(context.TODO(), link.ID)
+	c.Assert(err, gc.IsNil)
+	c.Assert(got.Metadata.StatusCode, gc.Equals, 200)
+
+	c.Assert(s.g.RemoveLink(context.TODO(), link.ID), gc.IsNil)
+	_, err = s.g.FindLinkByURL(context.TODO(), link.URL)
+	c.Assert(err, gc.ErrorMatches, ".*not found")
+}
+
+func (s *CachingGraphTestSuite) TestRestoreInvalidation(c *gc.C) {
+	link := &graph.Link{URL: "https://example.com"}
+	c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
+	_, err := s.g.FindLink(context.TODO(), link.ID)
+	c.Assert(err, gc.IsNil)
+
+	// Restoring a link replaces the link with the same ID, including its
+	// URL.
+	restored := &graph.Link{ID: link.ID, URL: "https://example.com/moved", Metadata: graph.LinkMetadata{StatusCode: 301}}
+	c.Assert(s.g.RestoreLinks(context.TODO(), []*graph.Link{restored}), gc.IsNil)
+	got, err := s.g.FindLink(context.TODO(), link.ID)
+	c.Assert(err, gc.IsNil)
+	c.Assert(got.URL, gc.Equals, restored.URL)
+	c.Assert(got.Metadata.StatusCode, gc.Equals, 301)
+	_, err = s.g.FindLinkByURL(context.TODO(), link.URL)
+	c.Assert(xerrors.Is(err, graph.ErrNotFound), gc.Equals, true)
+}
+
+func (s *CachingGraphTestSuite) TestOptionalInterfacesNotSupported(c *gc.C) {
+	// Hide the optional interfaces implemented by the in-memory graph.
+	g, err := NewCachingGraph(struct{ graph.Graph }{memory.NewInMemoryGraph()}, 8)
+	c.Assert(err, gc.IsNil)
+
+	err = g.RestoreLinks(context.TODO(), []*graph.Link{{ID: uuid.New(), URL: "https://example.com"}})
+	c.Assert(xerrors.Is(err, graph.ErrNotSupported), gc.Equals, true)
+	err = g.RestoreEdges(context.TODO(), []*graph.Edge{{ID: uuid.New(), Src: uuid.New(), Dst: uuid.New()}})
+	c.Assert(xerrors.Is(err, graph.ErrNotSupported), gc.Equals, true)
+	_, err = g.Subscribe(context.TODO())
+	c.Assert(xerrors.Is(err, graph.ErrNotSupported), gc.Equals, true)
+}
+
+func (s *CachingGraphTestSuite) TestEviction(c *gc.C) {
+	var links []*graph.Link
+	for i := 0; i < 10; i++ {
+		link := &graph.Link{URL: fmt.Sprint("https://example.com/", i)}
+		c.Assert(s.g.UpsertLink(context.TODO(), link), gc.IsNil)
+		links = append(links, link)
+	}
+
+	var urls []string
+	for _, link := range links {
+		urls = append(urls, link.URL)
+	}
+	got, err := s.g.FindLinksByURL(context.TODO(), urls)
+	c.Assert(err, gc.IsNil)
+	c.Assert(got, gc.HasLen, len(links))
+	for i, link := range got {
+		c.Assert(link.ID, gc.Equals, links[i].ID)
+	}
+	c.Assert(s.g.CacheStats(), gc.DeepEquals, CacheStats{Misses: 10, Evictions: 2})
+
+	// The least recently used links have been evicted.
+	_, err = s.g.FindLink(context.TODO(), links[9].ID)
+	c.Assert(err, gc.IsNil)
+	_, err = s.g.FindLink(context.TODO(), links[0].ID)
+	c.Assert(err, gc.IsNil)
+	c.Assert(s.g.CacheStats(), gc.DeepEquals, CacheStats{Hits: 1, Misses: 11, Evictions: 3})
+}
@@ -1,7 +1,14 @@
 package cdb
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
@@ -10,31 +17,104 @@ import (
 	"golang.org/x/xerrors"
 )
 
+const (
+	// batchUpsertSize caps the number of rows inserted by a single
+	// multi-row upsert statement.
+	batchUpsertSize = 500
+
+	// defaultPageSize is the number of rows fetched per page by link and
+	// edge iterators unless overridden via graph.WithPageSize.
+	defaultPageSize = 1000
+)
+
+// commitTimestampExpr evaluates to the commit timestamp of the current
+// transaction. CockroachDB guarantees that a transaction observing its
+// timestamp commits at exactly that timestamp (retrying if necessary), which
+// allows the change feed to track changes by commit order.
+const commitTimestampExpr = "cluster_logical_timestamp()"
+
+// linkColumns lists the links table columns in the order expected by scanLink.
+const linkColumns = "id, url, retrieved_at, status_code, content_type, content_hash, redirect_to, fetch_error, crawl_depth, discovered_at, next_fetch_at, fetch_interval, priority"
+
+// edgeColumns lists the edges table columns in the order expected by scanEdge.
+const edgeColumns = "id, src, dst, updated_at, anchor_text, nofollow, sponsored, ugc, anchor_position"
+
+// upsertLinkConflictClause merges the metadata of an upserted link with the
+// existing row using the same rules as graph.LinkMetadata.Merge.
+const upsertLinkConflictClause = `
+ON CONFLICT (url) DO UPDATE SET
+	retrieved_at=GREATEST(links.retrieved_at, excluded.retrieved_at),
+	status_code=CASE WHEN excluded.status_code <> 0 THEN excluded.status_code ELSE links.status_code END,
+	content_type=CASE WHEN excluded.content_type <> '' THEN excluded.content_type ELSE links.content_type END,
+	content_hash=CASE WHEN excluded.content_hash <> '' THEN excluded.content_hash ELSE links.content_hash END,
+	redirect_to=COALESCE(excluded.redirect_to, links.redirect_to),
+	fetch_error=CASE WHEN excluded.fetch_error <> '' THEN excluded.fetch_error ELSE links.fetch_error END,
+	crawl_depth=CASE WHEN links.crawl_depth IS NULL OR excluded.crawl_depth < links.crawl_depth THEN excluded.crawl_depth ELSE links.crawl_depth END,
+	discovered_at=LEAST(links.discovered_at, excluded.discovered_at),
+	next_fetch_at=COALESCE(excluded.next_fetch_at, links.next_fetch_at),
+	fetch_interval=CASE WHEN excluded.fetch_interval <> 0 THEN excluded.fetch_interval ELSE links.fetch_interval END,
+	priority=CASE WHEN excluded.priority <> 0 THEN excluded.priority ELSE links.priority END,
+	changed_at=now(),
+	changed_ts=` + commitTimestampExpr + `
+RETURNING ` + linkColumns
+
 var (
-	upsertLinkQuery = `
-INSERT INTO links (url, retrieved_at) VALUES ($1, $2) 
-ON CONFLICT (url) DO UPDATE SET retrieved_at=GREATEST(links.retrieved_at, $2)
-RETURNING id, retrieved_at
-`
-	findLinkQuery         = "SELECT url, retrieved_at FROM links WHERE id=$1"
-	linksInPartitionQuery = "SELECT id, url, retrieved_at FROM links WHERE id >= $1 AND id < $2 AND retrieved_at < $3"
-
-	upsertEdgeQuery = `
-INSERT INTO edges (src, dst, updated_at) VALUES ($1, $2, NOW())
-ON CONFLICT (src,dst) DO UPDATE SET updated_at=NOW()
-RETURNING id, updated_at
-`
-	edgesInPartitionQuery = "SELECT id, src, dst, updated_at FROM edges WHERE src >= $1 AND src < $2 AND updated_at < $3"
+	upsertLinkQuery       = batchUpsertLinksQuery(1)
+	findLinkQuery         = "SELECT " + linkColumns + " FROM links WHERE id=$1"
+	findLinkByURLQuery    = "SELECT " + linkColumns + " FROM links WHERE url=$1"
+	findLinksByURLQuery   = "SELECT " + linkColumns + " FROM links WHERE url = ANY($1)"
+	removeLinkQuery       = "DELETE FROM links WHERE id=$1"
+	linksInPartitionQuery = "SELECT " + linkColumns + " FROM links WHERE id >= $1 AND id < $2 AND retrieved_at < $3"
+	hostHashFilter        = " AND url_host_hash >= $4 AND url_host_hash < $5"
+	mostDueLinksQuery     = "SELECT " + linkColumns + " FROM links WHERE id >= $1 AND id < $2 AND (next_fetch_at IS NULL OR next_fetch_at <= $3)"
+	mostDueLinksOrder     = fmt.Sprintf(" ORDER BY CASE WHEN priority = 0 THEN %d ELSE priority END DESC, next_fetch_at NULLS FIRST, id LIMIT $%%d", graph.DefaultPriority)
+
+	upsertEdgeQuery      = batchUpsertEdgesQuery(1)
+	existingLinkIDsQuery = "SELECT id FROM links WHERE id = ANY($1::UUID[])"
+
+	edgesInPartitionQuery = "SELECT " + edgeColumns + " FROM edges WHERE src >= $1 AND src < $2 AND updated_at < $3"
+	inboundEdgesQuery     = "SELECT " + edgeColumns + " FROM edges WHERE dst=$1 AND updated_at < $2"
+	excludeNoFollowFilter = " AND NOT nofollow"
+	inDegreeQuery         = "SELECT COUNT(edges.id) FROM links LEFT JOIN edges ON edges.dst=links.id WHERE links.id=$1 GROUP BY links.id"
+	outDegreeQuery        = "SELECT COUNT(edges.id) FROM links LEFT JOIN edges ON edges.src=links.id WHERE links.id=$1 GROUP BY links.id"
 	removeStaleEdgesQuery = "DELETE FROM edges WHERE src=$1 AND updated_at < $2"
+	recordStaleEdgesQuery = "INSERT INTO stale_edge_removals (src, updated_before, removed_ts) VALUES ($1, $2, " + commitTimestampExpr + ")"
+	pruneRemovalsQuery    = "DELETE FROM stale_edge_removals WHERE removed_at < now() - $1 * INTERVAL '1 second'"
+	removeEdgeQuery       = "DELETE FROM edges WHERE id=$1"
 
-	// Compile-time check for ensuring CockroachDbGraph implements Graph.
-	_ graph.Graph = (*CockroachDBGraph)(nil)
+	countsQuery             = "SELECT (SELECT COUNT(*) FROM links), (SELECT COUNT(*) FROM edges), (SELECT COUNT(*) FROM links WHERE retrieved_at=$1)"
+	outDegreeHistogramQuery = "SELECT degree, COUNT(*) FROM (SELECT COUNT(edges.id) AS degree FROM links LEFT JOIN edges ON edges.src=links.id GROUP BY links.id) GROUP BY degree"
+	hostLinksQuery          = "SELECT COALESCE(url_host, ''), COUNT(*) FROM links GROUP BY url_host"
+
+	restoreLinkQuery = `
+INSERT INTO links (` + linkColumns + `, url_host, url_host_hash, changed_ts) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, ` + commitTimestampExpr + `)
+ON CONFLICT (id) DO UPDATE SET
+	url=excluded.url, url_host=excluded.url_host, url_host_hash=excluded.url_host_hash,
+	retrieved_at=excluded.retrieved_at, status_code=excluded.status_code,
+	content_type=excluded.content_type, content_hash=excluded.content_hash, redirect_to=excluded.redirect_to,
+	fetch_error=excluded.fetch_error, crawl_depth=excluded.crawl_depth, discovered_at=excluded.discovered_at,
+	next_fetch_at=excluded.next_fetch_at, fetch_interval=excluded.fetch_interval, priority=excluded.priority,
+	changed_at=now(), changed_ts=excluded.changed_ts`
+	restoreEdgeQuery = `
+INSERT INTO edges (` + edgeColumns + `, changed_ts) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, ` + commitTimestampExpr + `)
+ON CONFLICT (id) DO UPDATE SET
+	src=excluded.src, dst=excluded.dst, updated_at=excluded.updated_at, anchor_text=excluded.anchor_text,
+	nofollow=excluded.nofollow, sponsored=excluded.sponsored, ugc=excluded.ugc, anchor_position=excluded.anchor_position,
+	changed_at=now(), changed_ts=excluded.changed_ts`
+
+	// Compile-time checks for ensuring CockroachDbGraph implements Graph
+	// and Restorer.
+	_ graph.Graph    = (*CockroachDBGraph)(nil)
+	_ graph.Restorer = (*CockroachDBGraph)(nil)
 )
 
 // CockroachDBGraph implements a graph that persists its links and edges to a
 // cockroachdb instance.
 type CockroachDBGraph struct {
 	db *sql.DB
+
+	retry   retryPolicy
+	breaker *circuitBreaker
 }
 
 // NewCockroachDbGraph returns a CockroachDbGraph instance that connects to the cockroachdb
@@ -45,7 +125,21 @@ func NewCockroachDbGraph(dsn string) (*CockroachDBGraph, error) {
 		return nil, err
 	}
 
-	return &CockroachDBGraph{db: db}, nil
+	return newCockroachDBGraph(db), nil
+}
+
+// newCockroachDBGraph returns a CockroachDBGraph that uses db and the
+// default retry and circuit breaker settings.
+func newCockroachDBGraph(db *sql.DB) *CockroachDBGraph {
+	return &CockroachDBGraph{
+		db: db,
+		retry: retryPolicy{
+			maxAttempts: defaultMaxAttempts,
+			baseDelay:   defaultRetryBaseDelay,
+			maxDelay:    defaultRetryMaxDelay,
+		},
+		breaker: newCircuitBreaker(defaultBreakerThreshold, defaultBreakerCooldown),
+	}
 }
 
 // Close terminates the connection to the backing cockroachdb instance.
@@ -54,21 +148,97 @@ func (c *CockroachDBGraph) Close() error {
 }
 
 // UpsertLink creates a new link or updates an existing link.
-func (c *CockroachDBGraph) UpsertLink(link *graph.Link) error {
-	row := c.db.QueryRow(upsertLinkQuery, link.URL, link.RetrievedAt.UTC())
-	if err := row.Scan(&link.ID, &link.RetrievedAt); err != nil {
+func (c *CockroachDBGraph) UpsertLink(ctx context.Context, link *graph.Link) error {
+	err := c.withRetry(ctx, func() error {
+		row := c.db.QueryRowContext(ctx, upsertLinkQuery, linkArgs(link)...)
+		return scanLink(row, link)
+	})
+	if err != nil {
 		return xerrors.Errorf("upsert link: %w", err)
 	}
+	return nil
+}
+
+// UpsertLinks creates or updates a batch of links using multi-row upsert
+// statements. Links that share the same URL are merged and are assigned the
+// same ID.
+func (c *CockroachDBGraph) UpsertLinks(ctx context.Context, links []*graph.Link) error {
+	// Merge links that share the same URL; a multi-row upsert cannot
+	// modify the same row twice.
+	var (
+		merged      []*graph.Link
+		mergedByURL = make(map[string]*graph.Link)
+		urlToLink   = make(map[string][]*graph.Link)
+	)
+	for _, link := range links {
+		urlToLink[link.URL] = append(urlToLink[link.URL], link)
+		m := mergedByURL[link.URL]
+		if m == nil {
+			m = new(graph.Link)
+			*m = *link
+			mergedByURL[link.URL] = m
+			merged = append(merged, m)
+			continue
+		}
+
+		if link.RetrievedAt.After(m.RetrievedAt) {
+			m.RetrievedAt = link.RetrievedAt
+		}
+		m.Metadata.Merge(link.Metadata)
+	}
+
+	for start := 0; start < len(merged); start += batchUpsertSize {
+		end := start + batchUpsertSize
+		if end > len(merged) {
+			end = len(merged)
+		}
+
+		var args []interface{}
+		for _, link := range merged[start:end] {
+			args = append(args, linkArgs(link)...)
+		}
+
+		err := c.withRetry(ctx, func() error {
+			return c.upsertLinksBatch(ctx, end-start, args, urlToLink)
+		})
+		if err != nil {
+			return xerrors.Errorf("upsert links: %w", err)
+		}
+	}
 
-	link.RetrievedAt = link.RetrievedAt.UTC()
 	return nil
 }
 
+// upsertLinksBatch runs a multi-row upsert statement for numRows links and
+// copies the stored links to the links with the same URL.
+func (c *CockroachDBGraph) upsertLinksBatch(ctx context.Context, numRows int, args []interface{}, urlToLink map[string][]*graph.Link) error {
+	rows, err := c.db.QueryContext(ctx, batchUpsertLinksQuery(numRows), args...)
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		stored := new(graph.Link)
+		if err = scanLink(rows, stored); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		for _, link := range urlToLink[stored.URL] {
+			*link = *stored
+		}
+	}
+	if err = rows.Err(); err != nil {
+		_ = rows.Close()
+		return err
+	}
+	return rows.Close()
+}
+
 // FindLink looks up a link by its ID.
-func (c *CockroachDBGraph) FindLink(id uuid.UUID) (*graph.Link, error) {
-	row := c.db.QueryRow(findLinkQuery, id)
-	link := &graph.Link{ID: id}
-	if err := row.Scan(&link.URL, &link.RetrievedAt); err != nil {
+func (c *CockroachDBGraph) FindLink(ctx context.Context, id uuid.UUID) (*graph.Link, error) {
+	row := c.db.QueryRowContext(ctx, findLinkQuery, id)
+	link := new(graph.Link)
+	if err := scanLink(row, link); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, xerrors.Errorf("find link: %w", graph.ErrNotFound)
 		}
@@ -76,51 +246,390 @@ func (c *CockroachDBGraph) FindLink(id uuid.UUID) (*graph.Link, error) {
 		return nil, xerrors.Errorf("find link: %w", err)
 	}
 
-	link.RetrievedAt = link.RetrievedAt.UTC()
 	return link, nil
 }
 
+// FindLinkByURL looks up a link by its URL.
+func (c *CockroachDBGraph) FindLinkByURL(ctx context.Context, url string) (*graph.Link, error) {
+	row := c.db.QueryRowContext(ctx, findLinkByURLQuery, url)
+	link := new(graph.Link)
+	if err := scanLink(row, link); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, xerrors.Errorf("find link by URL: %w", graph.ErrNotFound)
+		}
+
+		return nil, xerrors.Errorf("find link by URL: %w", err)
+	}
+
+	return link, nil
+}
+
+// FindLinksByURL looks up a batch of links by their URLs. URLs that do not
+// correspond to a known link are omitted from the returned list and
+// duplicate URLs are only returned once.
+func (c *CockroachDBGraph) FindLinksByURL(ctx context.Context, urls []string) ([]*graph.Link, error) {
+	if len(urls) == 0 {
+		return nil, nil
+	}
+
+	rows, err := c.db.QueryContext(ctx, findLinksByURLQuery, pq.Array(urls))
+	if err != nil {
+		return nil, xerrors.Errorf("find links by URL: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	byURL := make(map[string]*graph.Link, len(urls))
+	for rows.Next() {
+		link := new(graph.Link)
+		if err = scanLink(rows, link); err != nil {
+			return nil, xerrors.Errorf("find links by URL: %w", err)
+		}
+		byURL[link.URL] = link
+	}
+	if err = rows.Err(); err != nil {
+		return nil, xerrors.Errorf("find links by URL: %w", err)
+	}
+
+	// Return links in the same order as the requested URLs.
+	var list []*graph.Link
+	for _, url := range urls {
+		if link := byURL[url]; link != nil {
+			list = append(list, link)
+			delete(byURL, url)
+		}
+	}
+	return list, nil
+}
+
 // Links returns an iterator for the set of links whose IDs belong to the
 // [fromID, toID) range and were last accessed before the provided value.
-func (c *CockroachDBGraph) Links(fromID, toID uuid.UUID, accessedBefore time.Time) (graph.LinkIterator, error) {
-	rows, err := c.db.Query(linksInPartitionQuery, fromID, toID, accessedBefore.UTC())
+// Links are fetched in pages of ascending ID so that scans can be resumed
+// via graph.WithCursor. Host hash ranges are matched against the
+// url_host_hash column, which is populated with graph.HostHash on insert.
+func (c *CockroachDBGraph) Links(ctx context.Context, fromID, toID uuid.UUID, accessedBefore time.Time, opts ...graph.IteratorOption) (graph.LinkIterator, error) {
+	itOpts := graph.ApplyIteratorOptions(opts...)
+	q := pageQuery{
+		query: linksInPartitionQuery,
+		args:  []interface{}{fromID, toID, accessedBefore.UTC()},
+	}
+	if r := itOpts.HostHashes; r != nil {
+		q.args = append(q.args, int64(r.From), int64(r.To))
+		q.query += hostHashFilter
+	}
+	p, err := c.newPager(ctx, q, itOpts)
 	if err != nil {
 		return nil, xerrors.Errorf("links: %w", err)
 	}
 
-	return &linkIterator{rows: rows}, nil
+	return &linkIterator{pager: p}, nil
+}
+
+// MostDueLinks returns up to limit links in the [fromID, toID) range that
+// are due at dueAt, ordered by descending priority and ascending next fetch
+// time.
+func (c *CockroachDBGraph) MostDueLinks(ctx context.Context, fromID, toID uuid.UUID, dueAt time.Time, limit int, opts ...graph.IteratorOption) ([]*graph.Link, error) {
+	if limit < 0 {
+		return nil, xerrors.Errorf("most due links: %w", graph.ErrInvalidLimit)
+	} else if limit == 0 {
+		return nil, nil
+	}
+
+	var (
+		itOpts = graph.ApplyIteratorOptions(opts...)
+		query  = mostDueLinksQuery
+		args   = []interface{}{fromID, toID, dueAt.UTC()}
+	)
+	if r := itOpts.HostHashes; r != nil {
+		args = append(args, int64(r.From), int64(r.To))
+		query += hostHashFilter
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(mostDueLinksOrder, len(args))
+
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, xerrors.Errorf("most due links: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var list []*graph.Link
+	for rows.Next() {
+		link := new(graph.Link)
+		if err = scanLink(rows, link); err != nil {
+			return nil, xerrors.Errorf("most due links: %w", err)
+		}
+		list = append(list, link)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, xerrors.Errorf("most due links: %w", err)
+	}
+	return list, nil
 }
 
 // UpsertEdge creates a new edge or updates an existing edge.
-func (c *CockroachDBGraph) UpsertEdge(edge *graph.Edge) error {
-	row := c.db.QueryRow(upsertEdgeQuery, edge.Src, edge.Dst)
-	if err := row.Scan(&edge.ID, &edge.UpdatedAt); err != nil {
+func (c *CockroachDBGraph) UpsertEdge(ctx context.Context, edge *graph.Edge) error {
+	err := c.withRetry(ctx, func() error {
+		row := c.db.QueryRowContext(ctx, upsertEdgeQuery, edgeArgs(edge)...)
+		return scanEdge(row, edge)
+	})
+	if err != nil {
 		if isForeignKeyViolationError(err) {
 			err = graph.ErrUnknownEdgeLinks
 		}
 		return xerrors.Errorf("upsert edge: %w", err)
 	}
+	return nil
+}
 
-	edge.UpdatedAt = edge.UpdatedAt.UTC()
+// UpsertEdges creates or updates a batch of edges using multi-row upsert
+// statements. Edges whose source or destination links are unknown are
+// skipped and reported via a *graph.BatchError. Each chunk of edges is
+// checked and upserted in a single transaction so that links removed
+// concurrently cannot fail the whole chunk.
+func (c *CockroachDBGraph) UpsertEdges(ctx context.Context, edges []*graph.Edge) error {
+	// Deduplicate edges by (src, dst); a multi-row upsert cannot modify
+	// the same row twice. For duplicate edges, the attributes of the last
+	// edge in the batch win.
+	var (
+		keys       [][2]uuid.UUID
+		keyToEdges = make(map[[2]uuid.UUID][]*graph.Edge)
+		keyToIdx   = make(map[[2]uuid.UUID][]int)
+	)
+	for i, edge := range edges {
+		key := [2]uuid.UUID{edge.Src, edge.Dst}
+		if _, seen := keyToEdges[key]; !seen {
+			keys = append(keys, key)
+		}
+		keyToEdges[key] = append(keyToEdges[key], edge)
+		keyToIdx[key] = append(keyToIdx[key], i)
+	}
+
+	var batchErr *graph.BatchError
+	for start := 0; start < len(keys); start += batchUpsertSize {
+		end := start + batchUpsertSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		var unknown [][2]uuid.UUID
+		err := c.inRetryableTx(ctx, func(tx *sql.Tx) error {
+			var err error
+			unknown, err = c.upsertEdgesChunk(ctx, tx, keys[start:end], keyToEdges)
+			return err
+		})
+		if err != nil {
+			if isForeignKeyViolationError(err) {
+				err = graph.ErrUnknownEdgeLinks
+			}
+			return xerrors.Errorf("upsert edges: %w", err)
+		}
+
+		for _, key := range unknown {
+			if batchErr == nil {
+				batchErr = &graph.BatchError{Errors: make([]error, len(edges))}
+			}
+			for _, i := range keyToIdx[key] {
+				batchErr.Errors[i] = graph.ErrUnknownEdgeLinks
+			}
+		}
+	}
+
+	if batchErr != nil {
+		return xerrors.Errorf("upsert edges: %w", batchErr)
+	}
 	return nil
 }
 
+// upsertEdgesChunk upserts the edges with the specified keys whose endpoints
+// refer to known links and copies the stored edges to the edges with the
+// same endpoints. It returns the keys of the edges that were skipped.
+func (c *CockroachDBGraph) upsertEdgesChunk(ctx context.Context, tx *sql.Tx, keys [][2]uuid.UUID, keyToEdges map[[2]uuid.UUID][]*graph.Edge) ([][2]uuid.UUID, error) {
+	known, err := existingLinkIDs(ctx, tx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		args    []interface{}
+		numRows int
+		unknown [][2]uuid.UUID
+	)
+	for _, key := range keys {
+		if !known[key[0]] || !known[key[1]] {
+			unknown = append(unknown, key)
+			continue
+		}
+		dups := keyToEdges[key]
+		args = append(args, edgeArgs(dups[len(dups)-1])...)
+		numRows++
+	}
+	if numRows == 0 {
+		return unknown, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, batchUpsertEdgesQuery(numRows), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		stored := new(graph.Edge)
+		if err = scanEdge(rows, stored); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		for _, edge := range keyToEdges[[2]uuid.UUID{stored.Src, stored.Dst}] {
+			*edge = *stored
+		}
+	}
+	if err = rows.Err(); err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+	return unknown, rows.Close()
+}
+
+// existingLinkIDs returns the set of endpoint IDs of the edges with the
+// specified keys that refer to known links.
+func existingLinkIDs(ctx context.Context, tx *sql.Tx, keys [][2]uuid.UUID) (map[uuid.UUID]bool, error) {
+	var (
+		ids  []string
+		seen = make(map[uuid.UUID]bool)
+	)
+	for _, key := range keys {
+		for _, id := range key {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id.String())
+			}
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, existingLinkIDsQuery, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	known := make(map[uuid.UUID]bool, len(ids))
+	for rows.Next() {
+		var id uuid.UUID
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		known[id] = true
+	}
+
+	return known, rows.Err()
+}
+
 // Edges returns an iterator for the set of edges whose source vertex IDs
 // belong to the [fromID, toID) range and were last updated before the provided
 // value.
-func (c *CockroachDBGraph) Edges(fromID, toID uuid.UUID, updatedBefore time.Time) (graph.EdgeIterator, error) {
-	rows, err := c.db.Query(edgesInPartitionQuery, fromID, toID, updatedBefore.UTC())
+func (c *CockroachDBGraph) Edges(ctx context.Context, fromID, toID uuid.UUID, updatedBefore time.Time, opts ...graph.IteratorOption) (graph.EdgeIterator, error) {
+	itOpts := graph.ApplyIteratorOptions(opts...)
+	q := pageQuery{
+		query: edgesInPartitionQuery,
+		args:  []interface{}{fromID, toID, updatedBefore.UTC()},
+	}
+	if itOpts.ExcludeNoFollow {
+		q.query += excludeNoFollowFilter
+	}
+
+	p, err := c.newPager(ctx, q, itOpts)
 	if err != nil {
 		return nil, xerrors.Errorf("edges: %w", err)
 	}
 
-	return &edgeIterator{rows: rows}, nil
+	return &edgeIterator{pager: p}, nil
+}
+
+// InboundEdges returns an iterator for the set of edges that point to the
+// specified link ID and were last updated before the provided value.
+func (c *CockroachDBGraph) InboundEdges(ctx context.Context, dstID uuid.UUID, updatedBefore time.Time, opts ...graph.IteratorOption) (graph.EdgeIterator, error) {
+	itOpts := graph.ApplyIteratorOptions(opts...)
+	q := pageQuery{
+		query: inboundEdgesQuery,
+		args:  []interface{}{dstID, updatedBefore.UTC()},
+	}
+	if itOpts.ExcludeNoFollow {
+		q.query += excludeNoFollowFilter
+	}
+
+	p, err := c.newPager(ctx, q, itOpts)
+	if err != nil {
+		return nil, xerrors.Errorf("inbound edges: %w", err)
+	}
+
+	return &edgeIterator{pager: p}, nil
+}
+
+// newPager returns a pager for q that resumes from the cursor specified in
+// opts and eagerly fetches the first page of results.
+func (c *CockroachDBGraph) newPager(ctx context.Context, q pageQuery, opts graph.IteratorOptions) (pager, error) {
+	cursor, err := graph.ParseCursor(opts.Cursor)
+	if err != nil {
+		return pager{}, err
+	}
+
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	p := pager{ctx: ctx, db: c.db, query: q, pageSize: pageSize, lastID: cursor}
+	if p.rows, err = p.fetchPage(); err != nil {
+		return pager{}, err
+	}
+	return p, nil
+}
+
+// InDegree returns the number of edges that point to the specified link ID.
+func (c *CockroachDBGraph) InDegree(ctx context.Context, id uuid.UUID) (int, error) {
+	var degree int
+	if err := c.db.QueryRowContext(ctx, inDegreeQuery, id).Scan(&degree); err != nil {
+		if err == sql.ErrNoRows {
+			err = graph.ErrNotFound
+		}
+		return 0, xerrors.Errorf("in degree: %w", err)
+	}
+	return degree, nil
+}
+
+// OutDegree returns the number of edges that originate from the specified
+// link ID.
+func (c *CockroachDBGraph) OutDegree(ctx context.Context, id uuid.UUID) (int, error) {
+	var degree int
+	if err := c.db.QueryRowContext(ctx, outDegreeQuery, id).Scan(&degree); err != nil {
+		if err == sql.ErrNoRows {
+			err = graph.ErrNotFound
+		}
+		return 0, xerrors.Errorf("out degree: %w", err)
+	}
+	return degree, nil
 }
 
 // RemoveStaleEdges removes any edge that originates from the specified link ID
 // and was updated before the specified timestamp.
-func (c *CockroachDBGraph) RemoveStaleEdges(fromID uuid.UUID, updatedBefore time.Time) error {
-	_, err := c.db.Exec(removeStaleEdgesQuery, fromID, updatedBefore.UTC())
+//
+// Each removal that affects at least one edge is recorded so that it can be
+// reported to change feed subscribers.
+func (c *CockroachDBGraph) RemoveStaleEdges(ctx context.Context, fromID uuid.UUID, updatedBefore time.Time) error {
+	err := c.inRetryableTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, removeStaleEdgesQuery, fromID, updatedBefore.UTC())
+		if err != nil {
+			return err
+		}
+		if count, err := res.RowsAffected(); err != nil || count == 0 {
+			return err
+		}
+
+		if _, err = tx.ExecContext(ctx, recordStaleEdgesQuery, fromID, updatedBefore.UTC()); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, pruneRemovalsQuery, int64(staleEdgeRemovalRetention/time.Second))
+		return err
+	})
 	if err != nil {
 		return xerrors.Errorf("remove stale edges: %w", err)
 	}
@@ -128,6 +637,359 @@ func (c *CockroachDBGraph) RemoveStaleEdges(fromID uuid.UUID, updatedBefore time
 	return nil
 }
 
+// RemoveLink removes the link with the specified ID. Any edges that
+// originate from or point to the link are removed by the ON DELETE CASCADE
+// constraints of the edges table.
+func (c *CockroachDBGraph) RemoveLink(ctx context.Context, id uuid.UUID) error {
+	err := c.withRetry(ctx, func() error {
+		res, err := c.db.ExecContext(ctx, removeLinkQuery, id)
+		if err != nil {
+			return err
+		}
+		return expectAffectedRows(res)
+	})
+	if err != nil {
+		return xerrors.Errorf("remove link: %w", err)
+	}
+	return nil
+}
+
+// RemoveEdge removes the edge with the specified ID.
+func (c *CockroachDBGraph) RemoveEdge(ctx context.Context, id uuid.UUID) error {
+	err := c.withRetry(ctx, func() error {
+		res, err := c.db.ExecContext(ctx, removeEdgeQuery, id)
+		if err != nil {
+			return err
+		}
+		return expectAffectedRows(res)
+	})
+	if err != nil {
+		return xerrors.Errorf("remove edge: %w", err)
+	}
+	return nil
+}
+
+// Stats returns a summary of the graph contents computed using aggregate
+// queries.
+func (c *CockroachDBGraph) Stats(ctx context.Context) (*graph.Stats, error) {
+	stats := &graph.Stats{
+		OutDegreeHistogram: make(map[int]int),
+		HostLinks:          make(map[string]int),
+	}
+
+	// Links that have never been retrieved are stored with a zero timestamp.
+	err := c.db.QueryRowContext(ctx, countsQuery, time.Time{}).Scan(&stats.Links, &stats.Edges, &stats.UncrawledLinks)
+	if err != nil {
+		return nil, xerrors.Errorf("stats: %w", err)
+	}
+
+	err = c.queryCounts(ctx, outDegreeHistogramQuery, func(row scanner) error {
+		var degree, count int
+		if err := row.Scan(&degree, &count); err != nil {
+			return err
+		}
+		stats.OutDegreeHistogram[degree] = count
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("stats: %w", err)
+	}
+
+	err = c.queryCounts(ctx, hostLinksQuery, func(row scanner) error {
+		var (
+			host  string
+			count int
+		)
+		if err := row.Scan(&host, &count); err != nil {
+			return err
+		}
+		stats.HostLinks[host] = count
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// queryCounts runs query and invokes scanFn for each returned row.
+func (c *CockroachDBGraph) queryCounts(ctx context.Context, query string, scanFn func(row scanner) error) error {
+	rows, err := c.db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		if err = scanFn(rows); err != nil {
+			_ = rows.Close()
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		_ = rows.Close()
+		return err
+	}
+	return rows.Close()
+}
+
+// RestoreLinks inserts links or replaces the links with the same ID,
+// preserving their IDs and timestamps. The batch is applied in a single
+// transaction.
+func (c *CockroachDBGraph) RestoreLinks(ctx context.Context, links []*graph.Link) error {
+	err := c.inRetryableTx(ctx, func(tx *sql.Tx) error {
+		for _, link := range links {
+			args := append([]interface{}{link.ID}, linkArgs(link)...)
+			if _, err := tx.ExecContext(ctx, restoreLinkQuery, args...); err != nil {
+				if isUniqueViolationError(err) {
+					return xerrors.Errorf("url %q: %w", link.URL, graph.ErrConflict)
+				}
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("restore links: %w", err)
+	}
+	return nil
+}
+
+// RestoreEdges inserts edges or replaces the edges with the same ID,
+// preserving their IDs and timestamps. The batch is applied in a single
+// transaction.
+func (c *CockroachDBGraph) RestoreEdges(ctx context.Context, edges []*graph.Edge) error {
+	err := c.inRetryableTx(ctx, func(tx *sql.Tx) error {
+		for _, edge := range edges {
+			_, err := tx.ExecContext(ctx, restoreEdgeQuery,
+				edge.ID, edge.Src, edge.Dst, edge.UpdatedAt.UTC(), edge.AnchorText,
+				edge.NoFollow, edge.Sponsored, edge.UGC, edge.Position,
+			)
+			if err != nil {
+				if isForeignKeyViolationError(err) {
+					return xerrors.Errorf("edge %s: %w", edge.ID, graph.ErrUnknownEdgeLinks)
+				} else if isUniqueViolationError(err) {
+					return xerrors.Errorf("edge %s: %w", edge.ID, graph.ErrConflict)
+				}
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("restore edges: %w", err)
+	}
+	return nil
+}
+
+// inTx runs fn in a transaction which is committed if fn succeeds and rolled
+// back otherwise.
+func (c *CockroachDBGraph) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// inRetryableTx runs fn in a transaction that is retried via withRetry if it
+// fails with a retryable error.
+func (c *CockroachDBGraph) inRetryableTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return c.withRetry(ctx, func() error { return c.inTx(ctx, fn) })
+}
+
+// expectAffectedRows returns graph.ErrNotFound if res reports that no rows
+// were affected by the executed statement.
+func expectAffectedRows(res sql.Result) error {
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	} else if count == 0 {
+		return graph.ErrNotFound
+	}
+	return nil
+}
+
+// batchUpsertLinksQuery returns a multi-row link upsert statement for
+// numRows links.
+func batchUpsertLinksQuery(numRows int) string {
+	return fmt.Sprintf(`
+INSERT INTO links (url, retrieved_at, status_code, content_type, content_hash, redirect_to, fetch_error, crawl_depth, discovered_at, next_fetch_at, fetch_interval, priority, url_host, url_host_hash, changed_ts)
+VALUES %s%s
+`, valuePlaceholders(numRows, 14, commitTimestampExpr), upsertLinkConflictClause)
+}
+
+// linkArgs returns the values for the columns populated by
+// batchUpsertLinksQuery. Unset optional metadata fields are mapped to NULL.
+// The host columns are derived with graph.LinkHost and graph.HostHash so
+// that they match the other graph implementations.
+func linkArgs(link *graph.Link) []interface{} {
+	var (
+		redirectTo   interface{}
+		crawlDepth   interface{}
+		nextFetchAt  interface{}
+		discoveredAt = link.Metadata.DiscoveredAt
+	)
+	if link.Metadata.RedirectTo != uuid.Nil {
+		redirectTo = link.Metadata.RedirectTo
+	}
+	if link.Metadata.CrawlDepth != 0 {
+		crawlDepth = link.Metadata.CrawlDepth
+	}
+	if !link.Metadata.NextFetchAt.IsZero() {
+		nextFetchAt = link.Metadata.NextFetchAt.UTC()
+	}
+	if discoveredAt.IsZero() {
+		discoveredAt = time.Now()
+	}
+
+	return []interface{}{
+		link.URL,
+		link.RetrievedAt.UTC(),
+		link.Metadata.StatusCode,
+		link.Metadata.ContentType,
+		link.Metadata.ContentHash,
+		redirectTo,
+		link.Metadata.FetchError,
+		crawlDepth,
+		discoveredAt.UTC(),
+		nextFetchAt,
+		int64(link.Metadata.FetchInterval),
+		link.Metadata.Priority,
+		graph.LinkHost(link.URL),
+		int64(graph.HostHash(link.URL)),
+	}
+}
+
+// scanner is implemented by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanLink populates link from a row whose columns match linkColumns.
+func scanLink(row scanner, link *graph.Link) error {
+	var (
+		crawlDepth    sql.NullInt64
+		nextFetchAt   pq.NullTime
+		fetchInterval int64
+	)
+	link.Metadata.RedirectTo = uuid.Nil
+	err := row.Scan(
+		&link.ID,
+		&link.URL,
+		&link.RetrievedAt,
+		&link.Metadata.StatusCode,
+		&link.Metadata.ContentType,
+		&link.Metadata.ContentHash,
+		&link.Metadata.RedirectTo,
+		&link.Metadata.FetchError,
+		&crawlDepth,
+		&link.Metadata.DiscoveredAt,
+		&nextFetchAt,
+		&fetchInterval,
+		&link.Metadata.Priority,
+	)
+	if err != nil {
+		return err
+	}
+
+	link.RetrievedAt = link.RetrievedAt.UTC()
+	link.Metadata.CrawlDepth = int(crawlDepth.Int64)
+	link.Metadata.DiscoveredAt = link.Metadata.DiscoveredAt.UTC()
+	link.Metadata.NextFetchAt = time.Time{}
+	if nextFetchAt.Valid {
+		link.Metadata.NextFetchAt = nextFetchAt.Time.UTC()
+	}
+	link.Metadata.FetchInterval = time.Duration(fetchInterval)
+	return nil
+}
+
+// batchUpsertEdgesQuery returns a multi-row edge upsert statement for
+// numRows edges.
+func batchUpsertEdgesQuery(numRows int) string {
+	return fmt.Sprintf(`
+INSERT INTO edges (src, dst, anchor_text, nofollow, sponsored, ugc, anchor_position, updated_at, changed_ts) VALUES %s
+ON CONFLICT (src,dst) DO UPDATE SET
+	updated_at=NOW(),
+	anchor_text=excluded.anchor_text,
+	nofollow=excluded.nofollow,
+	sponsored=excluded.sponsored,
+	ugc=excluded.ugc,
+	anchor_position=excluded.anchor_position,
+	changed_at=NOW(),
+	changed_ts=excluded.changed_ts
+RETURNING %s
+`, valuePlaceholders(numRows, 7, "NOW(), "+commitTimestampExpr), edgeColumns)
+}
+
+// edgeArgs returns the values for the columns populated by
+// batchUpsertEdgesQuery.
+func edgeArgs(edge *graph.Edge) []interface{} {
+	return []interface{}{
+		edge.Src,
+		edge.Dst,
+		edge.AnchorText,
+		edge.NoFollow,
+		edge.Sponsored,
+		edge.UGC,
+		edge.Position,
+	}
+}
+
+// scanEdge populates edge from a row whose columns match edgeColumns.
+func scanEdge(row scanner, edge *graph.Edge) error {
+	err := row.Scan(
+		&edge.ID,
+		&edge.Src,
+		&edge.Dst,
+		&edge.UpdatedAt,
+		&edge.AnchorText,
+		&edge.NoFollow,
+		&edge.Sponsored,
+		&edge.UGC,
+		&edge.Position,
+	)
+	if err != nil {
+		return err
+	}
+
+	edge.UpdatedAt = edge.UpdatedAt.UTC()
+	return nil
+}
+
+// valuePlaceholders returns a list of numRows value tuples with numArgs
+// positional placeholders each, followed by the optional extra expression.
+func valuePlaceholders(numRows, numArgs int, extra string) string {
+	var (
+		sb     strings.Builder
+		nextID = 1
+	)
+	for row := 0; row < numRows; row++ {
+		if row > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteByte('(')
+		for arg := 0; arg < numArgs; arg++ {
+			if arg > 0 {
+				sb.WriteByte(',')
+			}
+			fmt.Fprintf(&sb, "$%d", nextID)
+			nextID++
+		}
+		if extra != "" {
+			sb.WriteByte(',')
+			sb.WriteString(extra)
+		}
+		sb.WriteByte(')')
+	}
+	return sb.String()
+}
+
 // isForeignKeyViolationError returns true if err indicates a foreign key
 // constraint violation.
 func isForeignKeyViolationError(err error) bool {
@@ -138,3 +1000,54 @@ func isForeignKeyViolationError(err error) bool {
 
 	return pqErr.Code.Name() == "foreign_key_violation"
 }
+
+// isRetryableError returns true if err indicates a transaction conflict that
+// clients are expected to retry (SQLSTATE 40001) or a transient connection
+// failure.
+func isRetryableError(err error) bool {
+	var pqErr *pq.Error
+	if xerrors.As(err, &pqErr) && pqErr.Code == "40001" {
+		return true
+	}
+
+	return isConnectionError(err)
+}
+
+// isConnectionError returns true if err indicates that the cockroachdb
+// instance could not be reached or dropped the connection. Errors caused by
+// a cancelled or expired context are not connection errors.
+func isConnectionError(err error) bool {
+	if isContextError(err) {
+		return false
+	}
+
+	var pqErr *pq.Error
+	if xerrors.As(err, &pqErr) {
+		return pqErr.Code.Class() == "08"
+	}
+
+	var netErr net.Error
+	return xerrors.Is(err, driver.ErrBadConn) ||
+		xerrors.Is(err, io.EOF) ||
+		xerrors.Is(err, io.ErrUnexpectedEOF) ||
+		xerrors.Is(err, syscall.ECONNRESET) ||
+		xerrors.Is(err, syscall.ECONNREFUSED) ||
+		xerrors.As(err, &netErr)
+}
+
+// isContextError returns true if err was caused by the cancellation or
+// expiry of the caller's context.
+func isContextError(err error) bool {
+	return xerrors.Is(err, context.Canceled) || xerrors.Is(err, context.DeadlineExceeded)
+}
+
+// isUniqueViolationError returns true if err indicates a unique constraint
+// violation.
+func isUniqueViolationError(err error) bool {
+	pqErr, valid := err.(*pq.Error)
+	if !valid {
+		return false
+	}
+
+	return pqErr.Code.Name() == "unique_violation"
+}
@@ -1,10 +1,15 @@
 package cdb
 
 import (
+	"context"
 	"database/sql"
+	"os"
 	"testing"
+	"time"
 
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
 	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph/graphtest"
+	"golang.org/x/xerrors"
 	gc "gopkg.in/check.v1"
 )
 
@@ -15,23 +20,21 @@ func Test(t *testing.T) { gc.TestingT(t) }
 type CockroachDbGraphTestSuite struct {
 	graphtest.SuiteBase
 	db *sql.DB
+	g  *CockroachDBGraph
 }
 
 func (s *CockroachDbGraphTestSuite) SetUpSuite(c *gc.C) {
-	// postgres://<username>:<password>@<host>:<port>/<database>?<parameters>
-	// postgres://
-
-	//dsn := os.Getenv("CDB_DSN")
 	// CDB_DSN='postgresql://root@localhost:26257/linkgraph?sslmode=disable'
-	dsn := "postgresql://root@localhost:26257/linkgraph?sslmode=disable"
-	if dsn == "" {
+	if os.Getenv(DSNEnvVar) == "" {
 		c.Skip("Missing CDB_DSN envvar; skipping cockroachdb-backed graph test suite")
 	}
 
-	g, err := NewCockroachDbGraph(dsn)
+	g, err := NewCockroachDbGraphWithOptions(context.TODO(), WithApplicationName("linkgraph-test"))
 	c.Assert(err, gc.IsNil)
+	c.Assert(g.Migrate(context.TODO()), gc.IsNil)
 	s.SetGraph(g)
 	s.db = g.db
+	s.g = g
 }
 
 func (s *CockroachDbGraphTestSuite) SetUpTest(c *gc.C) {
@@ -46,6 +49,55 @@ func (s *CockroachDbGraphTestSuite) TearDownSuite(c *gc.C) {
 	}
 }
 
+// TestMigrateDownAndUp verifies that all embedded migrations can be
+// reverted and re-applied.
+func (s *CockroachDbGraphTestSuite) TestMigrateDownAndUp(c *gc.C) {
+	migrations, err := loadMigrations()
+	c.Assert(err, gc.IsNil)
+	latest := migrations[len(migrations)-1].version
+
+	version, dirty, err := s.g.SchemaVersion(context.TODO())
+	c.Assert(err, gc.IsNil)
+	c.Assert(version, gc.Equals, latest)
+	c.Assert(dirty, gc.Equals, false)
+
+	c.Assert(s.g.MigrateTo(context.TODO(), 0), gc.IsNil)
+	version, _, err = s.g.SchemaVersion(context.TODO())
+	c.Assert(err, gc.IsNil)
+	c.Assert(version, gc.Equals, uint(0))
+
+	c.Assert(s.g.Migrate(context.TODO()), gc.IsNil)
+	version, _, err = s.g.SchemaVersion(context.TODO())
+	c.Assert(err, gc.IsNil)
+	c.Assert(version, gc.Equals, latest)
+
+	err = s.g.MigrateTo(context.TODO(), latest+1)
+	c.Assert(xerrors.Is(err, ErrUnknownSchemaVersion), gc.Equals, true)
+}
+
+// TestChangeFeedLongTransaction verifies that changes written by
+// transactions that commit long after they started are still reported.
+func (s *CockroachDbGraphTestSuite) TestChangeFeedLongTransaction(c *gc.C) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	it, err := s.g.Subscribe(ctx)
+	c.Assert(err, gc.IsNil)
+	defer func() { c.Assert(it.Close(), gc.IsNil) }()
+
+	link := &graph.Link{URL: "https://example.com/slow"}
+	tx, err := s.db.BeginTx(ctx, nil)
+	c.Assert(err, gc.IsNil)
+	_, err = tx.ExecContext(ctx, upsertLinkQuery, linkArgs(link)...)
+	c.Assert(err, gc.IsNil)
+	time.Sleep(3 * changeSettleDelay)
+	c.Assert(tx.Commit(), gc.IsNil)
+
+	c.Assert(it.Next(), gc.Equals, true, gc.Commentf("change feed error: %v", it.Error()))
+	c.Assert(it.Event().Type, gc.Equals, graph.ChangeLinkUpserted)
+	c.Assert(it.Event().Link.URL, gc.Equals, link.URL)
+}
+
 func (s *CockroachDbGraphTestSuite) flushDB(c *gc.C) {
 	_, err := s.db.Exec("DELETE FROM links")
 	c.Assert(err, gc.IsNil)
@@ -0,0 +1,267 @@
+package cdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// changePollInterval is the delay between two consecutive polls for
+	// changes when a subscriber has caught up.
+	changePollInterval = time.Second
+
+	// changeSettleDelay is the age a change must reach before it is
+	// reported. Polls read the changes as of a timestamp this far in the
+	// past so that they rarely have to wait for in-flight transactions.
+	changeSettleDelay = time.Second
+
+	// staleEdgeRemovalRetention is the time for which RemoveStaleEdges
+	// calls are kept in the stale_edge_removals table.
+	staleEdgeRemovalRetention = 24 * time.Hour
+)
+
+var (
+	currentTimestampQuery = "SELECT (" + commitTimestampExpr + " - $1::DECIMAL)::STRING"
+
+	// The change queries are formatted with the AS OF SYSTEM TIME clause
+	// of the poll.
+	changedLinksQuery      = "SELECT " + linkColumns + ", changed_ts::STRING FROM links %s WHERE changed_ts > $1::DECIMAL AND changed_ts <= $2::DECIMAL ORDER BY changed_ts, id"
+	changedEdgesQuery      = "SELECT " + edgeColumns + ", changed_ts::STRING FROM edges %s WHERE changed_ts > $1::DECIMAL AND changed_ts <= $2::DECIMAL ORDER BY changed_ts, id"
+	staleEdgeRemovalsQuery = "SELECT src, updated_before, removed_ts::STRING FROM stale_edge_removals %s WHERE removed_ts > $1::DECIMAL AND removed_ts <= $2::DECIMAL ORDER BY removed_ts, id"
+
+	// Compile-time check for ensuring CockroachDBGraph implements
+	// ChangeSubscriber.
+	_ graph.ChangeSubscriber = (*CockroachDBGraph)(nil)
+)
+
+// Subscribe returns an iterator that polls the database for changes applied
+// to the graph after the call.
+//
+// Changes are tracked via the commit timestamp of the transaction that
+// wrote each row, so multiple updates to the same link or edge between two
+// polls are reported as a single event that reflects the latest state. Each
+// poll reads the changes committed up to a timestamp T as of T; as
+// cockroachdb forces any transaction that has not committed by then to
+// commit after T, no change is missed regardless of how long its
+// transaction runs.
+func (c *CockroachDBGraph) Subscribe(ctx context.Context) (graph.ChangeIterator, error) {
+	now, err := queryTimestamp(ctx, c.db, 0)
+	if err != nil {
+		return nil, xerrors.Errorf("subscribe: %w", err)
+	}
+
+	return &changeIterator{
+		ctx:       ctx,
+		db:        c.db,
+		watermark: now,
+		closedCh:  make(chan struct{}),
+	}, nil
+}
+
+// queryTimestamp returns the current cluster timestamp minus delay.
+func queryTimestamp(ctx context.Context, db *sql.DB, delay time.Duration) (*big.Rat, error) {
+	var ts string
+	if err := db.QueryRowContext(ctx, currentTimestampQuery, int64(delay)).Scan(&ts); err != nil {
+		return nil, err
+	}
+	return parseTimestamp(ts)
+}
+
+// parseTimestamp parses a cockroachdb HLC timestamp, i.e. a decimal whose
+// integer part is the wall time in nanoseconds and whose fractional part is
+// the logical clock.
+func parseTimestamp(ts string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(ts)
+	if !ok {
+		return nil, xerrors.Errorf("invalid HLC timestamp %q", ts)
+	}
+	return r, nil
+}
+
+// formatTimestamp formats an HLC timestamp parsed by parseTimestamp.
+func formatTimestamp(ts *big.Rat) string {
+	return ts.FloatString(10)
+}
+
+// timedChange is a change event together with the commit timestamp of the
+// transaction that recorded it.
+type timedChange struct {
+	ts    string
+	at    *big.Rat
+	event graph.ChangeEvent
+}
+
+// changeIterator is a graph.ChangeIterator implementation for the cdb graph.
+type changeIterator struct {
+	ctx       context.Context
+	db        *sql.DB
+	watermark *big.Rat
+
+	pending      []graph.ChangeEvent
+	latchedEvent *graph.ChangeEvent
+	lastErr      error
+
+	closeOnce sync.Once
+	closedCh  chan struct{}
+}
+
+// Next implements graph.ChangeIterator.
+func (i *changeIterator) Next() bool {
+	for len(i.pending) == 0 {
+		if i.lastErr != nil || i.isClosed() {
+			return false
+		}
+
+		if err := i.poll(); err != nil {
+			i.lastErr = xerrors.Errorf("change feed: %w", err)
+			return false
+		} else if len(i.pending) != 0 {
+			break
+		}
+
+		select {
+		case <-i.ctx.Done():
+			i.lastErr = i.ctx.Err()
+			return false
+		case <-i.closedCh:
+			return false
+		case <-time.After(changePollInterval):
+		}
+	}
+
+	i.latchedEvent = &i.pending[0]
+	i.pending = i.pending[1:]
+	return true
+}
+
+// poll fetches the changes committed after the watermark that are old enough
+// to be reported and advances the watermark.
+func (i *changeIterator) poll() error {
+	upTo, err := queryTimestamp(i.ctx, i.db, changeSettleDelay)
+	if err != nil {
+		return err
+	}
+	if upTo.Cmp(i.watermark) <= 0 {
+		return nil
+	}
+
+	var changes []timedChange
+	err = i.queryChanges(changedLinksQuery, upTo, func(row scanner) (timedChange, error) {
+		var (
+			tc   = timedChange{event: graph.ChangeEvent{Type: graph.ChangeLinkUpserted, Link: new(graph.Link)}}
+			cols = extraColumnScanner{row: row, extra: []interface{}{&tc.ts}}
+		)
+		err := scanLink(cols, tc.event.Link)
+		return tc, err
+	}, &changes)
+	if err != nil {
+		return err
+	}
+
+	err = i.queryChanges(changedEdgesQuery, upTo, func(row scanner) (timedChange, error) {
+		var (
+			tc   = timedChange{event: graph.ChangeEvent{Type: graph.ChangeEdgeUpserted, Edge: new(graph.Edge)}}
+			cols = extraColumnScanner{row: row, extra: []interface{}{&tc.ts}}
+		)
+		err := scanEdge(cols, tc.event.Edge)
+		return tc, err
+	}, &changes)
+	if err != nil {
+		return err
+	}
+
+	err = i.queryChanges(staleEdgeRemovalsQuery, upTo, func(row scanner) (timedChange, error) {
+		tc := timedChange{event: graph.ChangeEvent{Type: graph.ChangeStaleEdgesRemoved}}
+		err := row.Scan(&tc.event.Src, &tc.event.UpdatedBefore, &tc.ts)
+		tc.event.UpdatedBefore = tc.event.UpdatedBefore.UTC()
+		return tc, err
+	}, &changes)
+	if err != nil {
+		return err
+	}
+
+	// Merge the changes by time. Changes written by the same transaction
+	// share a timestamp and are reported as links, then edges, then edge
+	// removals.
+	sort.SliceStable(changes, func(a, b int) bool { return changes[a].at.Cmp(changes[b].at) < 0 })
+	for _, tc := range changes {
+		i.pending = append(i.pending, tc.event)
+	}
+	i.watermark = upTo
+	return nil
+}
+
+// queryChanges runs a change query for the (watermark, upTo] interval as of
+// upTo and appends the changes returned by scanFn for each row to changes.
+func (i *changeIterator) queryChanges(query string, upTo *big.Rat, scanFn func(row scanner) (timedChange, error), changes *[]timedChange) error {
+	var (
+		upToTS = formatTimestamp(upTo)
+		asOf   = fmt.Sprintf("AS OF SYSTEM TIME %s", upToTS)
+	)
+	rows, err := i.db.QueryContext(i.ctx, fmt.Sprintf(query, asOf), formatTimestamp(i.watermark), upToTS)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		tc, err := scanFn(rows)
+		if err != nil {
+			return err
+		}
+		if tc.at, err = parseTimestamp(tc.ts); err != nil {
+			return err
+		}
+		*changes = append(*changes, tc)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	return rows.Close()
+}
+
+func (i *changeIterator) isClosed() bool {
+	select {
+	case <-i.closedCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// Event implements graph.ChangeIterator.
+func (i *changeIterator) Event() *graph.ChangeEvent {
+	return i.latchedEvent
+}
+
+// Error implements graph.ChangeIterator.
+func (i *changeIterator) Error() error {
+	return i.lastErr
+}
+
+// Close implements graph.ChangeIterator.
+func (i *changeIterator) Close() error {
+	i.closeOnce.Do(func() { close(i.closedCh) })
+	return nil
+}
+
+// extraColumnScanner scans a row whose leading columns are handled by a
+// scan helper such as scanLink and whose trailing columns are stored in
+// extra.
+type extraColumnScanner struct {
+	row   scanner
+	extra []interface{}
+}
+
+// Scan implements scanner.
+func (s extraColumnScanner) Scan(dest ...interface{}) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
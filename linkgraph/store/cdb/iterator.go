@@ -1,32 +1,129 @@
 package cdb
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 
 	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+	"github.com/google/uuid"
 	"golang.org/x/xerrors"
 )
 
+// pageQuery describes a keyset-paginated query. The query must select the
+// id column and end with a WHERE clause; the pager appends the cursor
+// condition, the ordering and the page size limit.
+type pageQuery struct {
+	query string
+	args  []interface{}
+}
+
+// pager fetches the results of a pageQuery one page at a time, ordered by
+// ID.
+type pager struct {
+	ctx      context.Context
+	db       *sql.DB
+	query    pageQuery
+	pageSize int
+
+	rows       *sql.Rows
+	rowsInPage int
+	done       bool
+	lastID     uuid.UUID
+	lastErr    error
+}
+
+// next advances the pager to the next row, fetching a new page if the
+// current one has been exhausted.
+func (p *pager) next() bool {
+	if p.lastErr != nil {
+		return false
+	}
+	if p.lastErr = p.ctx.Err(); p.lastErr != nil {
+		return false
+	}
+
+	for {
+		if p.rows == nil {
+			if p.done {
+				return false
+			}
+			if p.rows, p.lastErr = p.fetchPage(); p.lastErr != nil {
+				return false
+			}
+			p.rowsInPage = 0
+		}
+
+		if p.rows.Next() {
+			p.rowsInPage++
+			return true
+		}
+
+		if p.lastErr = p.rows.Err(); p.lastErr != nil {
+			return false
+		}
+		if p.lastErr = p.rows.Close(); p.lastErr != nil {
+			return false
+		}
+		p.rows = nil
+
+		// A partially filled page indicates that there are no more rows.
+		p.done = p.rowsInPage < p.pageSize
+	}
+}
+
+// fetchPage runs the query for the page that follows lastID.
+func (p *pager) fetchPage() (*sql.Rows, error) {
+	query := p.query.query
+	args := append([]interface{}(nil), p.query.args...)
+	if p.lastID != uuid.Nil {
+		args = append(args, p.lastID)
+		query += fmt.Sprintf(" AND id > $%d", len(args))
+	}
+	args = append(args, p.pageSize)
+	query += fmt.Sprintf(" ORDER BY id LIMIT $%d", len(args))
+
+	return p.db.QueryContext(p.ctx, query, args...)
+}
+
+// cursor implements graph.Iterator.Cursor for iterators backed by a pager.
+func (p *pager) cursor() string {
+	if p.lastID == uuid.Nil {
+		return ""
+	}
+	return p.lastID.String()
+}
+
+// close releases the rows of the current page.
+func (p *pager) close() error {
+	p.done = true
+	if p.rows == nil {
+		return nil
+	}
+
+	err := p.rows.Close()
+	p.rows = nil
+	return err
+}
+
 // linkIterator is a graph.LinkIterator implementation for the cdb graph.
 type linkIterator struct {
-	rows        *sql.Rows
-	lastErr     error
+	pager
 	latchedLink *graph.Link
 }
 
 // Next implements graph.LinkIterator.
 func (i *linkIterator) Next() bool {
-	if i.lastErr != nil || !i.rows.Next() {
+	if !i.next() {
 		return false
 	}
 
 	l := new(graph.Link)
-	i.lastErr = i.rows.Scan(&l.ID, &l.URL, &l.RetrievedAt)
-	if i.lastErr != nil {
+	if i.lastErr = scanLink(i.rows, l); i.lastErr != nil {
 		return false
 	}
-	l.RetrievedAt = l.RetrievedAt.UTC()
 
+	i.lastID = l.ID
 	i.latchedLink = l
 	return true
 }
@@ -36,9 +133,14 @@ func (i *linkIterator) Error() error {
 	return i.lastErr
 }
 
+// Cursor implements graph.LinkIterator.
+func (i *linkIterator) Cursor() string {
+	return i.cursor()
+}
+
 // Close implements graph.LinkIterator.
 func (i *linkIterator) Close() error {
-	err := i.rows.Close()
+	err := i.close()
 	if err != nil {
 		return xerrors.Errorf("link iterator: %w", err)
 	}
@@ -52,24 +154,22 @@ func (i *linkIterator) Link() *graph.Link {
 
 // edgeIterator is a graph.EdgeIterator implementation for the cdb graph.
 type edgeIterator struct {
-	rows        *sql.Rows
-	lastErr     error
+	pager
 	latchedEdge *graph.Edge
 }
 
 // Next implements graph.EdgeIterator.
 func (i *edgeIterator) Next() bool {
-	if i.lastErr != nil || !i.rows.Next() {
+	if !i.next() {
 		return false
 	}
 
 	e := new(graph.Edge)
-	i.lastErr = i.rows.Scan(&e.ID, &e.Src, &e.Dst, &e.UpdatedAt)
-	if i.lastErr != nil {
+	if i.lastErr = scanEdge(i.rows, e); i.lastErr != nil {
 		return false
 	}
-	e.UpdatedAt = e.UpdatedAt.UTC()
 
+	i.lastID = e.ID
 	i.latchedEdge = e
 	return true
 }
@@ -79,9 +179,14 @@ func (i *edgeIterator) Error() error {
 	return i.lastErr
 }
 
+// Cursor implements graph.EdgeIterator.
+func (i *edgeIterator) Cursor() string {
+	return i.cursor()
+}
+
 // Close implements graph.EdgeIterator.
 func (i *edgeIterator) Close() error {
-	err := i.rows.Close()
+	err := i.close()
 	if err != nil {
 		return xerrors.Errorf("edge iterator: %w", err)
 	}
@@ -0,0 +1,306 @@
+package cdb
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+var (
+	// ErrDirtySchema is returned when a previous migration failed half-way
+	// and the schema needs to be repaired manually.
+	ErrDirtySchema = xerrors.New("schema is in a dirty state")
+
+	// ErrUnknownSchemaVersion is returned when attempting to migrate to a
+	// version that does not match any of the embedded migrations.
+	ErrUnknownSchemaVersion = xerrors.New("unknown schema version")
+
+	migrationFileRegex = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+)
+
+// The version tracking table uses the same layout as the one maintained by
+// the migrate tool so that databases migrated with either can be managed by
+// the other.
+const (
+	createMigrationsTableQuery = "CREATE TABLE IF NOT EXISTS schema_migrations (version INT8 NOT NULL PRIMARY KEY, dirty BOOL NOT NULL)"
+	getSchemaVersionQuery      = "SELECT version, dirty FROM schema_migrations LIMIT 1"
+	clearSchemaVersionQuery    = "DELETE FROM schema_migrations"
+	setSchemaVersionQuery      = "INSERT INTO schema_migrations (version, dirty) VALUES ($1, $2)"
+)
+
+// linkHostMigration is the version of the migration that adds the url_host
+// and url_host_hash columns. As their values are derived in Go, rows
+// created by earlier versions are backfilled once it has been applied.
+const (
+	linkHostMigration     = 9
+	linkHostBackfillBatch = 1000
+
+	missingLinkHostsQuery = "SELECT id, url FROM links WHERE url_host IS NULL LIMIT $1"
+	setLinkHostQuery      = "UPDATE links SET url_host=$2, url_host_hash=$3 WHERE id=$1"
+)
+
+// migration describes a schema change and the statements that revert it.
+type migration struct {
+	version uint
+	name    string
+	up      string
+	down    string
+}
+
+// Migrate applies all pending embedded migrations to the database.
+func (c *CockroachDBGraph) Migrate(ctx context.Context) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return xerrors.Errorf("migrate: %w", err)
+	}
+
+	if err = c.migrateTo(ctx, migrations, migrations[len(migrations)-1].version); err != nil {
+		return xerrors.Errorf("migrate: %w", err)
+	}
+	return nil
+}
+
+// MigrateTo applies or reverts embedded migrations until the schema
+// matches the specified version. Version zero reverts all migrations.
+func (c *CockroachDBGraph) MigrateTo(ctx context.Context, version uint) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return xerrors.Errorf("migrate to: %w", err)
+	}
+
+	if err = c.migrateTo(ctx, migrations, version); err != nil {
+		return xerrors.Errorf("migrate to: %w", err)
+	}
+	return nil
+}
+
+// SchemaVersion returns the currently applied schema version and whether a
+// migration to that version failed half-way. A zero version indicates that
+// no migrations have been applied.
+func (c *CockroachDBGraph) SchemaVersion(ctx context.Context) (uint, bool, error) {
+	if _, err := c.db.ExecContext(ctx, createMigrationsTableQuery); err != nil {
+		return 0, false, xerrors.Errorf("schema version: %w", err)
+	}
+
+	var (
+		version int64
+		dirty   bool
+	)
+	err := c.db.QueryRowContext(ctx, getSchemaVersionQuery).Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, xerrors.Errorf("schema version: %w", err)
+	}
+
+	return uint(version), dirty, nil
+}
+
+func (c *CockroachDBGraph) migrateTo(ctx context.Context, migrations []migration, target uint) error {
+	if target != 0 && findMigration(migrations, target) < 0 {
+		return xerrors.Errorf("version %d: %w", target, ErrUnknownSchemaVersion)
+	}
+
+	current, dirty, err := c.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	} else if dirty {
+		return xerrors.Errorf("version %d: %w", current, ErrDirtySchema)
+	}
+
+	// Apply pending migrations in ascending order
+	for _, m := range migrations {
+		if m.version <= current || m.version > target {
+			continue
+		}
+		if err = c.applyMigration(ctx, m.version, m.up); err != nil {
+			return xerrors.Errorf("apply migration %d_%s: %w", m.version, m.name, err)
+		}
+	}
+
+	// Revert migrations in descending order
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.version > current || m.version <= target {
+			continue
+		}
+
+		var prevVersion uint
+		if i > 0 {
+			prevVersion = migrations[i-1].version
+		}
+		if err = c.applyMigration(ctx, prevVersion, m.down); err != nil {
+			return xerrors.Errorf("revert migration %d_%s: %w", m.version, m.name, err)
+		}
+	}
+
+	if target >= linkHostMigration {
+		if err = c.backfillLinkHosts(ctx); err != nil {
+			return xerrors.Errorf("backfill link hosts: %w", err)
+		}
+	}
+	return nil
+}
+
+// backfillLinkHosts populates the host columns of links whose host has not
+// been derived yet.
+func (c *CockroachDBGraph) backfillLinkHosts(ctx context.Context) error {
+	for {
+		rows, err := c.db.QueryContext(ctx, missingLinkHostsQuery, linkHostBackfillBatch)
+		if err != nil {
+			return err
+		}
+
+		var (
+			ids  []uuid.UUID
+			urls []string
+		)
+		for rows.Next() {
+			var (
+				id  uuid.UUID
+				url string
+			)
+			if err = rows.Scan(&id, &url); err != nil {
+				_ = rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+			urls = append(urls, url)
+		}
+		if err = rows.Err(); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		if err = rows.Close(); err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		err = c.inRetryableTx(ctx, func(tx *sql.Tx) error {
+			for i, id := range ids {
+				host, hash := graph.LinkHost(urls[i]), int64(graph.HostHash(urls[i]))
+				if _, err := tx.ExecContext(ctx, setLinkHostQuery, id, host, hash); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// applyMigration executes stmts and records version as the current schema
+// version. The schema is flagged as dirty while the statements execute so
+// that a failed migration is detected by subsequent runs.
+func (c *CockroachDBGraph) applyMigration(ctx context.Context, version uint, stmts string) error {
+	if err := c.setSchemaVersion(ctx, version, true); err != nil {
+		return err
+	}
+
+	if _, err := c.db.ExecContext(ctx, stmts); err != nil {
+		return err
+	}
+
+	if version == 0 {
+		_, err := c.db.ExecContext(ctx, clearSchemaVersionQuery)
+		return err
+	}
+	return c.setSchemaVersion(ctx, version, false)
+}
+
+func (c *CockroachDBGraph) setSchemaVersion(ctx context.Context, version uint, dirty bool) error {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, clearSchemaVersionQuery); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, setSchemaVersionQuery, int64(version), dirty); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// loadMigrations parses the embedded migration files and returns them
+// sorted by version.
+func loadMigrations() ([]migration, error) {
+	files, err := fs.ReadDir(migrationFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*migration)
+	for _, file := range files {
+		match := migrationFileRegex.FindStringSubmatch(file.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil {
+			return nil, xerrors.Errorf("parse migration version for %q: %w", file.Name(), err)
+		}
+
+		stmts, err := migrationFS.ReadFile(path.Join("migrations", file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[uint(version)]
+		if m == nil {
+			m = &migration{version: uint(version), name: match[2]}
+			byVersion[m.version] = m
+		}
+		if match[3] == "up" {
+			m.up = string(stmts)
+		} else {
+			m.down = string(stmts)
+		}
+	}
+
+	var migrations []migration
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, xerrors.Errorf("migration %d_%s must provide both an up and a down script", m.version, m.name)
+		}
+		migrations = append(migrations, *m)
+	}
+	if len(migrations) == 0 {
+		return nil, xerrors.New("no embedded migrations found")
+	}
+
+	sort.Slice(migrations, func(l, r int) bool { return migrations[l].version < migrations[r].version })
+	return migrations, nil
+}
+
+// findMigration returns the index of the migration with the specified
+// version or -1 if no such migration exists.
+func findMigration(migrations []migration, version uint) int {
+	for i, m := range migrations {
+		if m.version == version {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,27 @@
+package cdb
+
+import (
+	gc "gopkg.in/check.v1"
+)
+
+var _ = gc.Suite(new(MigrationsTestSuite))
+
+// MigrationsTestSuite checks the embedded migrations and does not require a
+// running cockroachdb instance.
+type MigrationsTestSuite struct{}
+
+// TestLoadMigrations verifies that every embedded migration provides both an
+// up and a down script and that versions are sorted.
+func (s *MigrationsTestSuite) TestLoadMigrations(c *gc.C) {
+	migrations, err := loadMigrations()
+	c.Assert(err, gc.IsNil)
+	c.Assert(len(migrations) > 0, gc.Equals, true)
+
+	for i, m := range migrations {
+		c.Assert(m.version, gc.Equals, uint(i+1), gc.Commentf("expected migration versions to be sequential"))
+		c.Assert(m.up, gc.Not(gc.Equals), "")
+		c.Assert(m.down, gc.Not(gc.Equals), "")
+	}
+	c.Assert(findMigration(migrations, migrations[0].version), gc.Equals, 0)
+	c.Assert(findMigration(migrations, 0), gc.Equals, -1)
+}
@@ -0,0 +1,7 @@
+ALTER TABLE links DROP COLUMN IF EXISTS discovered_at;
+ALTER TABLE links DROP COLUMN IF EXISTS crawl_depth;
+ALTER TABLE links DROP COLUMN IF EXISTS fetch_error;
+ALTER TABLE links DROP COLUMN IF EXISTS redirect_to;
+ALTER TABLE links DROP COLUMN IF EXISTS content_hash;
+ALTER TABLE links DROP COLUMN IF EXISTS content_type;
+ALTER TABLE links DROP COLUMN IF EXISTS status_code;
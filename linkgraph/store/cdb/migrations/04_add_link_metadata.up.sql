@@ -0,0 +1,7 @@
+ALTER TABLE links ADD COLUMN IF NOT EXISTS status_code INT NOT NULL DEFAULT 0;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS content_type STRING NOT NULL DEFAULT '';
+ALTER TABLE links ADD COLUMN IF NOT EXISTS content_hash STRING NOT NULL DEFAULT '';
+ALTER TABLE links ADD COLUMN IF NOT EXISTS redirect_to UUID;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS fetch_error STRING NOT NULL DEFAULT '';
+ALTER TABLE links ADD COLUMN IF NOT EXISTS crawl_depth INT;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS discovered_at TIMESTAMP NOT NULL DEFAULT now();
@@ -0,0 +1,5 @@
+ALTER TABLE edges DROP COLUMN IF EXISTS anchor_position;
+ALTER TABLE edges DROP COLUMN IF EXISTS ugc;
+ALTER TABLE edges DROP COLUMN IF EXISTS sponsored;
+ALTER TABLE edges DROP COLUMN IF EXISTS nofollow;
+ALTER TABLE edges DROP COLUMN IF EXISTS anchor_text;
@@ -0,0 +1,5 @@
+ALTER TABLE edges ADD COLUMN IF NOT EXISTS anchor_text STRING NOT NULL DEFAULT '';
+ALTER TABLE edges ADD COLUMN IF NOT EXISTS nofollow BOOL NOT NULL DEFAULT false;
+ALTER TABLE edges ADD COLUMN IF NOT EXISTS sponsored BOOL NOT NULL DEFAULT false;
+ALTER TABLE edges ADD COLUMN IF NOT EXISTS ugc BOOL NOT NULL DEFAULT false;
+ALTER TABLE edges ADD COLUMN IF NOT EXISTS anchor_position INT NOT NULL DEFAULT 0;
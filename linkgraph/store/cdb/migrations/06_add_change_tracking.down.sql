@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS stale_edge_removals;
+DROP INDEX IF EXISTS edges@edges_changed_at_idx;
+DROP INDEX IF EXISTS links@links_changed_at_idx;
+ALTER TABLE edges DROP COLUMN IF EXISTS changed_at;
+ALTER TABLE links DROP COLUMN IF EXISTS changed_at;
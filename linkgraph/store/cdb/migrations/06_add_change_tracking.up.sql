@@ -0,0 +1,11 @@
+ALTER TABLE links ADD COLUMN IF NOT EXISTS changed_at TIMESTAMP NOT NULL DEFAULT now();
+ALTER TABLE edges ADD COLUMN IF NOT EXISTS changed_at TIMESTAMP NOT NULL DEFAULT now();
+CREATE INDEX IF NOT EXISTS links_changed_at_idx ON links (changed_at);
+CREATE INDEX IF NOT EXISTS edges_changed_at_idx ON edges (changed_at);
+CREATE TABLE IF NOT EXISTS stale_edge_removals (
+	id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+	src UUID NOT NULL,
+	updated_before TIMESTAMP NOT NULL,
+	removed_at TIMESTAMP NOT NULL DEFAULT now(),
+	INDEX stale_edge_removals_removed_at_idx (removed_at)
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS links@links_host_hash_idx;
+ALTER TABLE links DROP COLUMN IF EXISTS host_hash;
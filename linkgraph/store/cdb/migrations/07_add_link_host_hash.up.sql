@@ -0,0 +1,2 @@
+ALTER TABLE links ADD COLUMN IF NOT EXISTS host_hash INT8 AS (fnv32a(COALESCE(lower(substring(url, '^[a-zA-Z][a-zA-Z0-9+.-]*://(?:[^/?#@]*@)?([^/?#:]*)')), ''))) STORED;
+CREATE INDEX IF NOT EXISTS links_host_hash_idx ON links (host_hash);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS links@links_next_fetch_at_idx;
+ALTER TABLE links DROP COLUMN IF EXISTS priority;
+ALTER TABLE links DROP COLUMN IF EXISTS fetch_interval;
+ALTER TABLE links DROP COLUMN IF EXISTS next_fetch_at;
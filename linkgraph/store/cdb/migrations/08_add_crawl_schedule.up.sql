@@ -0,0 +1,4 @@
+ALTER TABLE links ADD COLUMN IF NOT EXISTS next_fetch_at TIMESTAMP;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS fetch_interval INT8 NOT NULL DEFAULT 0;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS priority INT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS links_next_fetch_at_idx ON links (next_fetch_at);
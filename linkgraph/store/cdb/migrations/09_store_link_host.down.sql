@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS links@links_url_host_hash_idx;
+ALTER TABLE links DROP COLUMN IF EXISTS url_host_hash;
+ALTER TABLE links DROP COLUMN IF EXISTS url_host;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS host_hash INT8 AS (fnv32a(COALESCE(lower(substring(url, '^[a-zA-Z][a-zA-Z0-9+.-]*://(?:[^/?#@]*@)?([^/?#:]*)')), ''))) STORED;
+CREATE INDEX IF NOT EXISTS links_host_hash_idx ON links (host_hash);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS links@links_host_hash_idx;
+ALTER TABLE links DROP COLUMN IF EXISTS host_hash;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS url_host STRING;
+ALTER TABLE links ADD COLUMN IF NOT EXISTS url_host_hash INT8;
+CREATE INDEX IF NOT EXISTS links_url_host_hash_idx ON links (url_host_hash);
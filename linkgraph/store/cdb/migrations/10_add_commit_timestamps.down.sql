@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS stale_edge_removals@stale_edge_removals_removed_ts_idx;
+DROP INDEX IF EXISTS edges@edges_changed_ts_idx;
+DROP INDEX IF EXISTS links@links_changed_ts_idx;
+ALTER TABLE stale_edge_removals DROP COLUMN IF EXISTS removed_ts;
+ALTER TABLE edges DROP COLUMN IF EXISTS changed_ts;
+ALTER TABLE links DROP COLUMN IF EXISTS changed_ts;
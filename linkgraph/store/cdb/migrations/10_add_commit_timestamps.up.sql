@@ -0,0 +1,6 @@
+ALTER TABLE links ADD COLUMN IF NOT EXISTS changed_ts DECIMAL;
+ALTER TABLE edges ADD COLUMN IF NOT EXISTS changed_ts DECIMAL;
+ALTER TABLE stale_edge_removals ADD COLUMN IF NOT EXISTS removed_ts DECIMAL;
+CREATE INDEX IF NOT EXISTS links_changed_ts_idx ON links (changed_ts);
+CREATE INDEX IF NOT EXISTS edges_changed_ts_idx ON edges (changed_ts);
+CREATE INDEX IF NOT EXISTS stale_edge_removals_removed_ts_idx ON stale_edge_removals (removed_ts);
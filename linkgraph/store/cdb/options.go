@@ -0,0 +1,190 @@
+package cdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// DSNEnvVar is the environment variable that NewCockroachDbGraphWithOptions
+// reads the DSN from when none is specified via WithDSN.
+const DSNEnvVar = "CDB_DSN"
+
+// ErrMissingDSN is returned when no DSN is specified and DSNEnvVar is not
+// set.
+var ErrMissingDSN = xerrors.New("missing cockroachdb DSN")
+
+// Options configures a CockroachDBGraph created via
+// NewCockroachDbGraphWithOptions. Zero values select the database/sql and
+// server defaults.
+type Options struct {
+	// DSN of the cockroachdb instance. If empty, it is read from
+	// DSNEnvVar.
+	DSN string
+
+	// Connection pool settings.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+
+	// Session settings applied to every connection.
+	StatementTimeout time.Duration
+	ApplicationName  string
+
+	// The number of times the database is pinged on start-up before giving
+	// up and the delay between two attempts.
+	PingAttempts int
+	PingInterval time.Duration
+}
+
+// Option is a function that configures Options.
+type Option func(*Options)
+
+// WithDSN sets the DSN of the cockroachdb instance.
+func WithDSN(dsn string) Option {
+	return func(o *Options) { o.DSN = dsn }
+}
+
+// WithMaxOpenConns limits the number of open connections to the database.
+func WithMaxOpenConns(n int) Option {
+	return func(o *Options) { o.MaxOpenConns = n }
+}
+
+// WithMaxIdleConns limits the number of idle connections kept in the pool.
+func WithMaxIdleConns(n int) Option {
+	return func(o *Options) { o.MaxIdleConns = n }
+}
+
+// WithConnMaxLifetime sets the maximum amount of time a connection may be
+// reused.
+func WithConnMaxLifetime(d time.Duration) Option {
+	return func(o *Options) { o.ConnMaxLifetime = d }
+}
+
+// WithStatementTimeout aborts statements that run for longer than d.
+func WithStatementTimeout(d time.Duration) Option {
+	return func(o *Options) { o.StatementTimeout = d }
+}
+
+// WithApplicationName sets the application name reported to the database.
+func WithApplicationName(name string) Option {
+	return func(o *Options) { o.ApplicationName = name }
+}
+
+// WithPingRetries configures the number of start-up connectivity checks and
+// the delay between them.
+func WithPingRetries(attempts int, interval time.Duration) Option {
+	return func(o *Options) { o.PingAttempts, o.PingInterval = attempts, interval }
+}
+
+// defaultOptions returns the options used unless overridden.
+func defaultOptions() Options {
+	return Options{
+		PingAttempts: 5,
+		PingInterval: time.Second,
+	}
+}
+
+// NewCockroachDbGraphWithOptions returns a CockroachDBGraph configured via
+// opts. Unlike NewCockroachDbGraph, it verifies that the database can be
+// reached before returning.
+func NewCockroachDbGraphWithOptions(ctx context.Context, opts ...Option) (*CockroachDBGraph, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.DSN == "" {
+		o.DSN = os.Getenv(DSNEnvVar)
+	}
+	if o.DSN == "" {
+		return nil, xerrors.Errorf("new cockroachdb graph: %w", ErrMissingDSN)
+	}
+
+	params := make(map[string]string)
+	if o.ApplicationName != "" {
+		params["application_name"] = o.ApplicationName
+	}
+	if o.StatementTimeout > 0 {
+		params["statement_timeout"] = fmt.Sprint(o.StatementTimeout.Milliseconds())
+	}
+	dsn, err := dsnWithParams(o.DSN, params)
+	if err != nil {
+		return nil, xerrors.Errorf("new cockroachdb graph: %w", err)
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, xerrors.Errorf("new cockroachdb graph: %w", err)
+	}
+	db.SetMaxOpenConns(o.MaxOpenConns)
+	if o.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(o.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(o.ConnMaxLifetime)
+
+	if err = ping(ctx, db, o.PingAttempts, o.PingInterval); err != nil {
+		_ = db.Close()
+		return nil, xerrors.Errorf("new cockroachdb graph: %w", err)
+	}
+	return newCockroachDBGraph(db), nil
+}
+
+// ping checks that db can be reached, making up to attempts attempts.
+func ping(ctx context.Context, db *sql.DB, attempts int, interval time.Duration) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = db.PingContext(ctx); err == nil || attempt >= attempts {
+			return err
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// dsnWithParams adds params to dsn, which may either be a URL or a list of
+// key=value pairs.
+func dsnWithParams(dsn string, params map[string]string) (string, error) {
+	if len(params) == 0 {
+		return dsn, nil
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", err
+		}
+		q := u.Query()
+		for _, key := range keys {
+			q.Set(key, params[key])
+		}
+		u.RawQuery = q.Encode()
+		return u.String(), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(dsn)
+	for _, key := range keys {
+		value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(params[key])
+		fmt.Fprintf(&sb, " %s='%s'", key, value)
+	}
+	return sb.String(), nil
+}
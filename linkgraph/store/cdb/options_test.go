@@ -0,0 +1,52 @@
+package cdb
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"golang.org/x/xerrors"
+	gc "gopkg.in/check.v1"
+)
+
+var _ = gc.Suite(new(OptionsTestSuite))
+
+// OptionsTestSuite checks the DSN handling of the options-based constructor
+// and does not require a running cockroachdb instance.
+type OptionsTestSuite struct{}
+
+func (s *OptionsTestSuite) TestDSNWithParams(c *gc.C) {
+	params := map[string]string{"application_name": "crawler", "statement_timeout": "5000"}
+
+	dsn, err := dsnWithParams("postgresql://root@localhost:26257/linkgraph?sslmode=disable", params)
+	c.Assert(err, gc.IsNil)
+	c.Assert(dsn, gc.Equals, "postgresql://root@localhost:26257/linkgraph?application_name=crawler&sslmode=disable&statement_timeout=5000")
+
+	dsn, err = dsnWithParams("host=localhost port=26257", map[string]string{"application_name": "it's"})
+	c.Assert(err, gc.IsNil)
+	c.Assert(dsn, gc.Equals, `host=localhost port=26257 application_name='it\'s'`)
+
+	dsn, err = dsnWithParams("host=localhost", nil)
+	c.Assert(err, gc.IsNil)
+	c.Assert(dsn, gc.Equals, "host=localhost")
+}
+
+func (s *OptionsTestSuite) TestMissingDSN(c *gc.C) {
+	if dsn, ok := os.LookupEnv(DSNEnvVar); ok {
+		c.Assert(os.Unsetenv(DSNEnvVar), gc.IsNil)
+		defer func() { _ = os.Setenv(DSNEnvVar, dsn) }()
+	}
+
+	_, err := NewCockroachDbGraphWithOptions(context.TODO())
+	c.Assert(xerrors.Is(err, ErrMissingDSN), gc.Equals, true)
+}
+
+func (s *OptionsTestSuite) TestPingFailure(c *gc.C) {
+	// Nothing listens on port 1, so every ping attempt is refused.
+	_, err := NewCockroachDbGraphWithOptions(context.TODO(),
+		WithDSN("postgresql://root@127.0.0.1:1/linkgraph?sslmode=disable&connect_timeout=1"),
+		WithPingRetries(2, time.Millisecond),
+	)
+	c.Assert(err, gc.NotNil)
+	c.Assert(isConnectionError(err), gc.Equals, true)
+}
@@ -0,0 +1,135 @@
+package cdb
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// ErrCircuitOpen is returned by CockroachDBGraph mutations while the circuit
+// breaker is open because the cockroachdb instance appears to be down.
+var ErrCircuitOpen = xerrors.New("circuit breaker is open")
+
+const (
+	defaultMaxAttempts    = 5
+	defaultRetryBaseDelay = 20 * time.Millisecond
+	defaultRetryMaxDelay  = 2 * time.Second
+
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 10 * time.Second
+)
+
+// retryPolicy controls how operations that fail with a retryable error are
+// retried.
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// backoff returns a random delay before the specified retry attempt. The
+// upper bound of the delay doubles with each attempt up to maxDelay.
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	limit := p.maxDelay
+	if shift := attempt - 1; shift < 32 {
+		if d := p.baseDelay << uint(shift); d > 0 && d < limit {
+			limit = d
+		}
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(limit) + 1))
+}
+
+// circuitBreaker fails calls fast after threshold consecutive connection
+// failures. Once cooldown elapses, a single probe call is let through; the
+// breaker closes if the probe reaches the database and re-opens otherwise.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow returns ErrCircuitOpen if the call should fail fast.
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return nil
+	}
+	if b.probing || time.Since(b.openedAt) < b.cooldown {
+		return ErrCircuitOpen
+	}
+	b.probing = true
+	return nil
+}
+
+// record updates the breaker with the outcome of a call that was allowed.
+func (b *circuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if err == nil || !isConnectionError(err) {
+		b.failures = 0
+		return
+	}
+
+	if b.failures++; b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+}
+
+// release ends a probe whose outcome is unknown, e.g. because the caller's
+// context expired, without affecting the failure count.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+// withRetry runs op, retrying it with jittered exponential backoff while it
+// fails with a retryable error. Calls fail fast with ErrCircuitOpen while
+// the circuit breaker is open. As op may be invoked more than once, it must
+// be idempotent. Connection failures are ambiguous as the statement may
+// have been applied, so a retried removal may report graph.ErrNotFound.
+func (c *CockroachDBGraph) withRetry(ctx context.Context, op func() error) error {
+	for attempt := 1; ; attempt++ {
+		if err := c.breaker.allow(); err != nil {
+			return err
+		}
+
+		// Context errors say nothing about the health of the database
+		// and must not open the circuit.
+		err := op()
+		if isContextError(err) {
+			c.breaker.release()
+		} else {
+			c.breaker.record(err)
+		}
+		if err == nil || attempt >= c.retry.maxAttempts || !isRetryableError(err) {
+			return err
+		}
+
+		timer := time.NewTimer(c.retry.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package cdb
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/lib/pq"
+	"golang.org/x/xerrors"
+	gc "gopkg.in/check.v1"
+)
+
+var _ = gc.Suite(new(RetryTestSuite))
+
+// RetryTestSuite checks the retry and circuit breaker logic and does not
+// require a running cockroachdb instance.
+type RetryTestSuite struct {
+	g *CockroachDBGraph
+}
+
+func (s *RetryTestSuite) SetUpTest(c *gc.C) {
+	s.g = &CockroachDBGraph{
+		retry:   retryPolicy{maxAttempts: 3, baseDelay: time.Millisecond, maxDelay: 5 * time.Millisecond},
+		breaker: newCircuitBreaker(2, time.Hour),
+	}
+}
+
+func (s *RetryTestSuite) TestErrorClassification(c *gc.C) {
+	c.Assert(isRetryableError(&pq.Error{Code: "40001"}), gc.Equals, true)
+	c.Assert(isRetryableError(xerrors.Errorf("upsert: %w", &pq.Error{Code: "40001"})), gc.Equals, true)
+	c.Assert(isRetryableError(io.ErrUnexpectedEOF), gc.Equals, true)
+	c.Assert(isRetryableError(&pq.Error{Code: "23503"}), gc.Equals, false)
+	c.Assert(isRetryableError(xerrors.New("boom")), gc.Equals, false)
+
+	c.Assert(isConnectionError(&pq.Error{Code: "08006"}), gc.Equals, true)
+	c.Assert(isConnectionError(&pq.Error{Code: "40001"}), gc.Equals, false)
+
+	// Context errors may implement net.Error but are caused by the caller.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	c.Assert(isConnectionError(ctx.Err()), gc.Equals, false)
+	c.Assert(isConnectionError(xerrors.Errorf("query: %w", context.Canceled)), gc.Equals, false)
+	c.Assert(isRetryableError(context.DeadlineExceeded), gc.Equals, false)
+}
+
+func (s *RetryTestSuite) TestRetrySerializationFailure(c *gc.C) {
+	var calls int
+	err := s.g.withRetry(context.TODO(), func() error {
+		if calls++; calls < 3 {
+			return &pq.Error{Code: "40001"}
+		}
+		return nil
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(calls, gc.Equals, 3)
+
+	// Non-retryable errors are returned immediately.
+	calls = 0
+	err = s.g.withRetry(context.TODO(), func() error {
+		calls++
+		return &pq.Error{Code: "23503"}
+	})
+	c.Assert(err, gc.NotNil)
+	c.Assert(calls, gc.Equals, 1)
+}
+
+func (s *RetryTestSuite) TestCircuitBreaker(c *gc.C) {
+	var calls int
+	failing := func() error {
+		calls++
+		return io.ErrUnexpectedEOF
+	}
+
+	// The first call exhausts its attempts and opens the breaker.
+	err := s.g.withRetry(context.TODO(), failing)
+	c.Assert(xerrors.Is(err, ErrCircuitOpen), gc.Equals, true)
+	c.Assert(calls, gc.Equals, 2)
+
+	err = s.g.withRetry(context.TODO(), failing)
+	c.Assert(xerrors.Is(err, ErrCircuitOpen), gc.Equals, true)
+	c.Assert(calls, gc.Equals, 2)
+
+	// After the cooldown, a successful probe closes the breaker.
+	s.g.breaker.cooldown = 0
+	c.Assert(s.g.withRetry(context.TODO(), func() error { return nil }), gc.IsNil)
+	c.Assert(s.g.withRetry(context.TODO(), func() error { return nil }), gc.IsNil)
+}
+
+func (s *RetryTestSuite) TestContextErrorsDoNotOpenBreaker(c *gc.C) {
+	var calls int
+	for i := 0; i < 3; i++ {
+		err := s.g.withRetry(context.TODO(), func() error {
+			calls++
+			return xerrors.Errorf("query: %w", context.DeadlineExceeded)
+		})
+		c.Assert(xerrors.Is(err, context.DeadlineExceeded), gc.Equals, true)
+	}
+	c.Assert(calls, gc.Equals, 3)
+	c.Assert(s.g.breaker.failures, gc.Equals, 0)
+}
+
+func (s *RetryTestSuite) TestBackoff(c *gc.C) {
+	p := retryPolicy{baseDelay: 10 * time.Millisecond, maxDelay: 50 * time.Millisecond}
+	for attempt := 1; attempt < 100; attempt++ {
+		d := p.backoff(attempt)
+		c.Assert(d >= 0 && d <= p.maxDelay, gc.Equals, true, gc.Commentf("attempt %d: %s", attempt, d))
+	}
+	c.Assert(p.backoff(1) <= p.baseDelay, gc.Equals, true)
+}
@@ -0,0 +1,265 @@
+package instrumented
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+	"github.com/Waqas-Shah-42/Links-R-Us/metrics"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// Compile-time checks for ensuring InstrumentedGraph implements Graph and
+// the optional graph interfaces.
+var (
+	_ graph.Graph            = (*InstrumentedGraph)(nil)
+	_ graph.Restorer         = (*InstrumentedGraph)(nil)
+	_ graph.ChangeSubscriber = (*InstrumentedGraph)(nil)
+)
+
+// InstrumentedGraph wraps a graph.Graph and records call counts, error
+// counts, latencies and iterator item counts for each method. Calls to the
+// graph.Restorer and graph.ChangeSubscriber methods are forwarded to the
+// wrapped graph and fail with graph.ErrNotSupported if it does not implement
+// them.
+type InstrumentedGraph struct {
+	g       graph.Graph
+	metrics *metrics.Recorder
+}
+
+// NewInstrumentedGraph returns an InstrumentedGraph that wraps g. Metric
+// names are prefixed with "linkgraph".
+func NewInstrumentedGraph(g graph.Graph) *InstrumentedGraph {
+	return &InstrumentedGraph{
+		g: g,
+		metrics: metrics.NewRecorder("linkgraph",
+			metrics.SentinelError{Label: "not_found", Err: graph.ErrNotFound},
+			metrics.SentinelError{Label: "unknown_edge_links", Err: graph.ErrUnknownEdgeLinks},
+			metrics.SentinelError{Label: "conflict", Err: graph.ErrConflict},
+			metrics.SentinelError{Label: "not_supported", Err: graph.ErrNotSupported},
+		),
+	}
+}
+
+// Metrics returns the recorder that collects the metrics of the graph. It
+// can be mounted as an http.Handler.
+func (g *InstrumentedGraph) Metrics() *metrics.Recorder {
+	return g.metrics
+}
+
+// WritePrometheus writes the collected metrics to w using the Prometheus
+// text exposition format.
+func (g *InstrumentedGraph) WritePrometheus(w io.Writer) error {
+	return g.metrics.WritePrometheus(w)
+}
+
+// UpsertLink implements graph.Graph.
+func (g *InstrumentedGraph) UpsertLink(ctx context.Context, link *graph.Link) (err error) {
+	defer g.observe("UpsertLink", time.Now(), &err)
+	return g.g.UpsertLink(ctx, link)
+}
+
+// UpsertLinks implements graph.Graph.
+func (g *InstrumentedGraph) UpsertLinks(ctx context.Context, links []*graph.Link) (err error) {
+	defer g.observe("UpsertLinks", time.Now(), &err)
+	return g.g.UpsertLinks(ctx, links)
+}
+
+// FindLink implements graph.Graph.
+func (g *InstrumentedGraph) FindLink(ctx context.Context, id uuid.UUID) (_ *graph.Link, err error) {
+	defer g.observe("FindLink", time.Now(), &err)
+	return g.g.FindLink(ctx, id)
+}
+
+// FindLinkByURL implements graph.Graph.
+func (g *InstrumentedGraph) FindLinkByURL(ctx context.Context, url string) (_ *graph.Link, err error) {
+	defer g.observe("FindLinkByURL", time.Now(), &err)
+	return g.g.FindLinkByURL(ctx, url)
+}
+
+// FindLinksByURL implements graph.Graph.
+func (g *InstrumentedGraph) FindLinksByURL(ctx context.Context, urls []string) (_ []*graph.Link, err error) {
+	defer g.observe("FindLinksByURL", time.Now(), &err)
+	return g.g.FindLinksByURL(ctx, urls)
+}
+
+// RemoveLink implements graph.Graph.
+func (g *InstrumentedGraph) RemoveLink(ctx context.Context, id uuid.UUID) (err error) {
+	defer g.observe("RemoveLink", time.Now(), &err)
+	return g.g.RemoveLink(ctx, id)
+}
+
+// UpsertEdge implements graph.Graph.
+func (g *InstrumentedGraph) UpsertEdge(ctx context.Context, edge *graph.Edge) (err error) {
+	defer g.observe("UpsertEdge", time.Now(), &err)
+	return g.g.UpsertEdge(ctx, edge)
+}
+
+// UpsertEdges implements graph.Graph.
+func (g *InstrumentedGraph) UpsertEdges(ctx context.Context, edges []*graph.Edge) (err error) {
+	defer g.observe("UpsertEdges", time.Now(), &err)
+	return g.g.UpsertEdges(ctx, edges)
+}
+
+// RemoveEdge implements graph.Graph.
+func (g *InstrumentedGraph) RemoveEdge(ctx context.Context, id uuid.UUID) (err error) {
+	defer g.observe("RemoveEdge", time.Now(), &err)
+	return g.g.RemoveEdge(ctx, id)
+}
+
+// RemoveStaleEdges implements graph.Graph.
+func (g *InstrumentedGraph) RemoveStaleEdges(ctx context.Context, fromID uuid.UUID, updatedBefore time.Time) (err error) {
+	defer g.observe("RemoveStaleEdges", time.Now(), &err)
+	return g.g.RemoveStaleEdges(ctx, fromID, updatedBefore)
+}
+
+// Links implements graph.Graph.
+func (g *InstrumentedGraph) Links(ctx context.Context, fromID, toID uuid.UUID, retrievedBefore time.Time, opts ...graph.IteratorOption) (_ graph.LinkIterator, err error) {
+	defer g.observe("Links", time.Now(), &err)
+	it, err := g.g.Links(ctx, fromID, toID, retrievedBefore, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &linkIterator{LinkIterator: it, counter: g.newItemCounter("Links")}, nil
+}
+
+// Edges implements graph.Graph.
+func (g *InstrumentedGraph) Edges(ctx context.Context, fromID, toID uuid.UUID, updatedBefore time.Time, opts ...graph.IteratorOption) (_ graph.EdgeIterator, err error) {
+	defer g.observe("Edges", time.Now(), &err)
+	it, err := g.g.Edges(ctx, fromID, toID, updatedBefore, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &edgeIterator{EdgeIterator: it, counter: g.newItemCounter("Edges")}, nil
+}
+
+// InboundEdges implements graph.Graph.
+func (g *InstrumentedGraph) InboundEdges(ctx context.Context, dstID uuid.UUID, updatedBefore time.Time, opts ...graph.IteratorOption) (_ graph.EdgeIterator, err error) {
+	defer g.observe("InboundEdges", time.Now(), &err)
+	it, err := g.g.InboundEdges(ctx, dstID, updatedBefore, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &edgeIterator{EdgeIterator: it, counter: g.newItemCounter("InboundEdges")}, nil
+}
+
+// InDegree implements graph.Graph.
+func (g *InstrumentedGraph) InDegree(ctx context.Context, id uuid.UUID) (_ int, err error) {
+	defer g.observe("InDegree", time.Now(), &err)
+	return g.g.InDegree(ctx, id)
+}
+
+// OutDegree implements graph.Graph.
+func (g *InstrumentedGraph) OutDegree(ctx context.Context, id uuid.UUID) (_ int, err error) {
+	defer g.observe("OutDegree", time.Now(), &err)
+	return g.g.OutDegree(ctx, id)
+}
+
+// Stats implements graph.Graph.
+func (g *InstrumentedGraph) Stats(ctx context.Context) (_ *graph.Stats, err error) {
+	defer g.observe("Stats", time.Now(), &err)
+	return g.g.Stats(ctx)
+}
+
+// MostDueLinks implements graph.Graph.
+func (g *InstrumentedGraph) MostDueLinks(ctx context.Context, fromID, toID uuid.UUID, dueAt time.Time, limit int, opts ...graph.IteratorOption) (_ []*graph.Link, err error) {
+	defer g.observe("MostDueLinks", time.Now(), &err)
+	return g.g.MostDueLinks(ctx, fromID, toID, dueAt, limit, opts...)
+}
+
+// RestoreLinks implements graph.Restorer.
+func (g *InstrumentedGraph) RestoreLinks(ctx context.Context, links []*graph.Link) (err error) {
+	defer g.observe("RestoreLinks", time.Now(), &err)
+	r, ok := g.g.(graph.Restorer)
+	if !ok {
+		return xerrors.Errorf("restore links: %w", graph.ErrNotSupported)
+	}
+	return r.RestoreLinks(ctx, links)
+}
+
+// RestoreEdges implements graph.Restorer.
+func (g *InstrumentedGraph) RestoreEdges(ctx context.Context, edges []*graph.Edge) (err error) {
+	defer g.observe("RestoreEdges", time.Now(), &err)
+	r, ok := g.g.(graph.Restorer)
+	if !ok {
+		return xerrors.Errorf("restore edges: %w", graph.ErrNotSupported)
+	}
+	return r.RestoreEdges(ctx, edges)
+}
+
+// Subscribe implements graph.ChangeSubscriber.
+func (g *InstrumentedGraph) Subscribe(ctx context.Context) (_ graph.ChangeIterator, err error) {
+	defer g.observe("Subscribe", time.Now(), &err)
+	sub, ok := g.g.(graph.ChangeSubscriber)
+	if !ok {
+		return nil, xerrors.Errorf("subscribe: %w", graph.ErrNotSupported)
+	}
+	it, err := sub.Subscribe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &changeIterator{ChangeIterator: it, metrics: g.metrics}, nil
+}
+
+// observe records a call to method that started at start and failed with
+// *err, if not nil.
+func (g *InstrumentedGraph) observe(method string, start time.Time, err *error) {
+	g.metrics.ObserveSince(method, start, *err)
+}
+
+func (g *InstrumentedGraph) newItemCounter(method string) *metrics.ItemCounter {
+	return metrics.NewItemCounter(g.metrics, method)
+}
+
+// linkIterator counts the links returned by the wrapped iterator.
+type linkIterator struct {
+	graph.LinkIterator
+	counter *metrics.ItemCounter
+}
+
+// Next implements graph.LinkIterator.
+func (i *linkIterator) Next() bool {
+	return i.counter.Count(i.LinkIterator.Next())
+}
+
+// Close implements graph.LinkIterator.
+func (i *linkIterator) Close() error {
+	i.counter.Flush()
+	return i.LinkIterator.Close()
+}
+
+// edgeIterator counts the edges returned by the wrapped iterator.
+type edgeIterator struct {
+	graph.EdgeIterator
+	counter *metrics.ItemCounter
+}
+
+// Next implements graph.EdgeIterator.
+func (i *edgeIterator) Next() bool {
+	return i.counter.Count(i.EdgeIterator.Next())
+}
+
+// Close implements graph.EdgeIterator.
+func (i *edgeIterator) Close() error {
+	i.counter.Flush()
+	return i.EdgeIterator.Close()
+}
+
+// changeIterator counts the events returned by the wrapped iterator. As
+// subscriptions are long-lived, each event is reported as soon as it is
+// returned.
+type changeIterator struct {
+	graph.ChangeIterator
+	metrics *metrics.Recorder
+}
+
+// Next implements graph.ChangeIterator.
+func (i *changeIterator) Next() bool {
+	if !i.ChangeIterator.Next() {
+		return false
+	}
+	i.metrics.AddItems("Subscribe", 1)
+	return true
+}
@@ -0,0 +1,96 @@
+package instrumented
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph/graphtest"
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/store/memory"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+	gc "gopkg.in/check.v1"
+)
+
+var _ = gc.Suite(new(InstrumentedGraphTestSuite))
+
+func Test(t *testing.T) { gc.TestingT(t) }
+
+type InstrumentedGraphTestSuite struct {
+	graphtest.SuiteBase
+	g *InstrumentedGraph
+}
+
+func (s *InstrumentedGraphTestSuite) SetUpTest(c *gc.C) {
+	s.g = NewInstrumentedGraph(memory.NewInMemoryGraph())
+	s.SetGraph(s.g)
+}
+
+func (s *InstrumentedGraphTestSuite) TestMetrics(c *gc.C) {
+	src := &graph.Link{URL: "https://example.com/a"}
+	dst := &graph.Link{URL: "https://example.com/b"}
+	c.Assert(s.g.UpsertLinks(context.TODO(), []*graph.Link{src, dst}), gc.IsNil)
+	c.Assert(s.g.UpsertEdge(context.TODO(), &graph.Edge{Src: src.ID, Dst: dst.ID}), gc.IsNil)
+	c.Assert(s.g.UpsertEdge(context.TODO(), &graph.Edge{Src: src.ID, Dst: uuid.New()}), gc.NotNil)
+	_, err := s.g.FindLink(context.TODO(), uuid.New())
+	c.Assert(err, gc.NotNil)
+
+	it, err := s.g.Links(context.TODO(), uuid.Nil, uuid.MustParse("ffffffff-ffff-ffff-ffff-ffffffffffff"), time.Now())
+	c.Assert(err, gc.IsNil)
+	for it.Next() {
+	}
+	c.Assert(it.Close(), gc.IsNil)
+
+	var buf bytes.Buffer
+	c.Assert(s.g.WritePrometheus(&buf), gc.IsNil)
+	out := buf.String()
+	for _, exp := range []string{
+		`linkgraph_calls_total{method="UpsertEdge"} 2`,
+		`linkgraph_errors_total{method="UpsertEdge",error="unknown_edge_links"} 1`,
+		`linkgraph_errors_total{method="FindLink",error="not_found"} 1`,
+		`linkgraph_call_duration_seconds_count{method="UpsertLinks"} 1`,
+		`linkgraph_iterator_items_total{method="Links"} 2`,
+	} {
+		c.Assert(bytes.Contains(buf.Bytes(), []byte(exp+"\n")), gc.Equals, true, gc.Commentf("expected output to contain %q:\n%s", exp, out))
+	}
+}
+
+func (s *InstrumentedGraphTestSuite) TestOptionalInterfaceMetrics(c *gc.C) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
+	defer cancel()
+	sub, err := s.g.Subscribe(ctx)
+	c.Assert(err, gc.IsNil)
+
+	link := &graph.Link{ID: uuid.New(), URL: "https://example.com/a"}
+	c.Assert(s.g.RestoreLinks(context.TODO(), []*graph.Link{link}), gc.IsNil)
+	err = s.g.RestoreLinks(context.TODO(), []*graph.Link{{ID: uuid.New(), URL: link.URL}})
+	c.Assert(xerrors.Is(err, graph.ErrConflict), gc.Equals, true)
+	c.Assert(sub.Next(), gc.Equals, true)
+	c.Assert(sub.Close(), gc.IsNil)
+
+	var buf bytes.Buffer
+	c.Assert(s.g.WritePrometheus(&buf), gc.IsNil)
+	out := buf.String()
+	for _, exp := range []string{
+		`linkgraph_calls_total{method="RestoreLinks"} 2`,
+		`linkgraph_errors_total{method="RestoreLinks",error="conflict"} 1`,
+		`linkgraph_calls_total{method="Subscribe"} 1`,
+		`linkgraph_iterator_items_total{method="Subscribe"} 1`,
+	} {
+		c.Assert(bytes.Contains(buf.Bytes(), []byte(exp+"\n")), gc.Equals, true, gc.Commentf("expected output to contain %q:\n%s", exp, out))
+	}
+}
+
+func (s *InstrumentedGraphTestSuite) TestOptionalInterfacesNotSupported(c *gc.C) {
+	// Hide the optional interfaces implemented by the in-memory graph.
+	g := NewInstrumentedGraph(struct{ graph.Graph }{memory.NewInMemoryGraph()})
+
+	err := g.RestoreLinks(context.TODO(), []*graph.Link{{ID: uuid.New(), URL: "https://example.com"}})
+	c.Assert(xerrors.Is(err, graph.ErrNotSupported), gc.Equals, true)
+	err = g.RestoreEdges(context.TODO(), []*graph.Edge{{ID: uuid.New(), Src: uuid.New(), Dst: uuid.New()}})
+	c.Assert(xerrors.Is(err, graph.ErrNotSupported), gc.Equals, true)
+	_, err = g.Subscribe(context.TODO())
+	c.Assert(xerrors.Is(err, graph.ErrNotSupported), gc.Equals, true)
+}
@@ -0,0 +1,178 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// changeBufferSize is the number of events that can be queued for each
+// subscriber before it is considered to be lagging.
+const changeBufferSize = 1024
+
+// Compile-time check for ensuring InMemoryGraph implements ChangeSubscriber.
+var _ graph.ChangeSubscriber = (*InMemoryGraph)(nil)
+
+// changeFeed fans out change events to all subscribers. Publishing never
+// blocks; subscribers whose buffers are full are dropped.
+type changeFeed struct {
+	mu   sync.Mutex
+	subs map[*changeIterator]struct{}
+}
+
+// Subscribe returns an iterator that yields the changes applied to the graph
+// after the call. If the consumer falls more than changeBufferSize events
+// behind, the iterator fails with graph.ErrSubscriberLagged.
+func (s *InMemoryGraph) Subscribe(ctx context.Context) (graph.ChangeIterator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, xerrors.Errorf("subscribe: %w", err)
+	}
+
+	it := &changeIterator{
+		ctx:    ctx,
+		feed:   &s.changes,
+		events: make(chan graph.ChangeEvent, changeBufferSize),
+	}
+
+	s.changes.mu.Lock()
+	if s.changes.subs == nil {
+		s.changes.subs = make(map[*changeIterator]struct{})
+	}
+	s.changes.subs[it] = struct{}{}
+	s.changes.mu.Unlock()
+
+	return it, nil
+}
+
+// publishLinks emits a ChangeLinkUpserted event for each link.
+func (f *changeFeed) publishLinks(links ...*graph.Link) {
+	if !f.hasSubscribers() {
+		return
+	}
+
+	events := make([]graph.ChangeEvent, len(links))
+	for i, link := range links {
+		lCopy := new(graph.Link)
+		*lCopy = *link
+		events[i] = graph.ChangeEvent{Type: graph.ChangeLinkUpserted, Link: lCopy}
+	}
+	f.publish(events)
+}
+
+// publishEdges emits a ChangeEdgeUpserted event for each edge.
+func (f *changeFeed) publishEdges(edges ...*graph.Edge) {
+	if !f.hasSubscribers() {
+		return
+	}
+
+	events := make([]graph.ChangeEvent, len(edges))
+	for i, edge := range edges {
+		eCopy := new(graph.Edge)
+		*eCopy = *edge
+		events[i] = graph.ChangeEvent{Type: graph.ChangeEdgeUpserted, Edge: eCopy}
+	}
+	f.publish(events)
+}
+
+// publishStaleEdgesRemoved emits a ChangeStaleEdgesRemoved event.
+func (f *changeFeed) publishStaleEdgesRemoved(src uuid.UUID, updatedBefore time.Time) {
+	if !f.hasSubscribers() {
+		return
+	}
+
+	f.publish([]graph.ChangeEvent{{Type: graph.ChangeStaleEdgesRemoved, Src: src, UpdatedBefore: updatedBefore}})
+}
+
+func (f *changeFeed) hasSubscribers() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subs) != 0
+}
+
+func (f *changeFeed) publish(events []graph.ChangeEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for it := range f.subs {
+		for _, ev := range events {
+			select {
+			case it.events <- ev:
+				continue
+			default:
+			}
+
+			// The subscriber cannot keep up; drop it.
+			it.lagged = true
+			f.unsubscribe(it)
+			break
+		}
+	}
+}
+
+// unsubscribe removes it from the feed and closes its event channel. Callers
+// must hold f.mu.
+func (f *changeFeed) unsubscribe(it *changeIterator) {
+	if _, ok := f.subs[it]; !ok {
+		return
+	}
+	delete(f.subs, it)
+	close(it.events)
+}
+
+// changeIterator is a graph.ChangeIterator implementation for the in-memory
+// graph.
+type changeIterator struct {
+	ctx  context.Context
+	feed *changeFeed
+
+	events       chan graph.ChangeEvent
+	lagged       bool
+	latchedEvent *graph.ChangeEvent
+	lastErr      error
+}
+
+// Next implements graph.ChangeIterator.
+func (i *changeIterator) Next() bool {
+	if i.lastErr != nil {
+		return false
+	}
+
+	select {
+	case <-i.ctx.Done():
+		i.lastErr = i.ctx.Err()
+		return false
+	case ev, ok := <-i.events:
+		if !ok {
+			i.feed.mu.Lock()
+			if i.lagged {
+				i.lastErr = graph.ErrSubscriberLagged
+			}
+			i.feed.mu.Unlock()
+			return false
+		}
+		i.latchedEvent = &ev
+		return true
+	}
+}
+
+// Event implements graph.ChangeIterator.
+func (i *changeIterator) Event() *graph.ChangeEvent {
+	return i.latchedEvent
+}
+
+// Error implements graph.ChangeIterator.
+func (i *changeIterator) Error() error {
+	return i.lastErr
+}
+
+// Close implements graph.ChangeIterator.
+func (i *changeIterator) Close() error {
+	i.feed.mu.Lock()
+	i.feed.unsubscribe(i)
+	i.feed.mu.Unlock()
+	return nil
+}
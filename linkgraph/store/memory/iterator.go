@@ -1,18 +1,43 @@
 package memory
 
-import "github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+import (
+	"context"
+	"sort"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+	"github.com/google/uuid"
+)
 
 // linkIterator is a graph.LinkIterator implementation for the in-memory graph.
 type linkIterator struct {
-	s *InMemoryGraph
+	s   *InMemoryGraph
+	ctx context.Context
 
 	links    []*graph.Link
 	curIndex int
+	lastErr  error
+	cursor   uuid.UUID
+}
+
+// newLinkIterator returns an iterator over the links in list whose IDs are
+// greater than cursor, in ascending ID order.
+func newLinkIterator(ctx context.Context, s *InMemoryGraph, list []*graph.Link, cursor uuid.UUID) *linkIterator {
+	sort.Slice(list, func(l, r int) bool { return list[l].ID.String() < list[r].ID.String() })
+	if cursor != uuid.Nil {
+		after := cursor.String()
+		skip := sort.Search(len(list), func(i int) bool { return list[i].ID.String() > after })
+		list = list[skip:]
+	}
+
+	return &linkIterator{s: s, ctx: ctx, links: list, cursor: cursor}
 }
 
 // Next() implements the linkIterator
 func (i *linkIterator) Next() bool {
-	if i.curIndex >= len(i.links) {
+	if i.lastErr != nil || i.curIndex >= len(i.links) {
+		return false
+	}
+	if i.lastErr = i.ctx.Err(); i.lastErr != nil {
 		return false
 	}
 	i.curIndex++
@@ -27,6 +52,16 @@ func (i *linkIterator) Link() *graph.Link {
 	return link
 }
 
+// Cursor implements graph.LinkIterator.
+func (i *linkIterator) Cursor() string {
+	if i.curIndex > 0 {
+		return i.links[i.curIndex-1].ID.String()
+	} else if i.cursor != uuid.Nil {
+		return i.cursor.String()
+	}
+	return ""
+}
+
 // Close implements graph.LinkIterator.
 func (i *linkIterator) Close() error {
 	return nil
@@ -34,19 +69,39 @@ func (i *linkIterator) Close() error {
 
 // Error implements graph.LinkIterator.
 func (i *linkIterator) Error() error {
-	return nil
+	return i.lastErr
 }
 
+// edgeIterator is a graph.EdgeIterator implementation for the in-memory graph.
 type edgeIterator struct {
-	s *InMemoryGraph
+	s   *InMemoryGraph
+	ctx context.Context
 
 	edges    []*graph.Edge
 	curIndex int
+	lastErr  error
+	cursor   uuid.UUID
+}
+
+// newEdgeIterator returns an iterator over the edges in list whose IDs are
+// greater than cursor, in ascending ID order.
+func newEdgeIterator(ctx context.Context, s *InMemoryGraph, list []*graph.Edge, cursor uuid.UUID) *edgeIterator {
+	sort.Slice(list, func(l, r int) bool { return list[l].ID.String() < list[r].ID.String() })
+	if cursor != uuid.Nil {
+		after := cursor.String()
+		skip := sort.Search(len(list), func(i int) bool { return list[i].ID.String() > after })
+		list = list[skip:]
+	}
+
+	return &edgeIterator{s: s, ctx: ctx, edges: list, cursor: cursor}
 }
 
 // Next implements graph.LinkIterator.
 func (i *edgeIterator) Next() bool {
-	if i.curIndex >= len(i.edges) {
+	if i.lastErr != nil || i.curIndex >= len(i.edges) {
+		return false
+	}
+	if i.lastErr = i.ctx.Err(); i.lastErr != nil {
 		return false
 	}
 	i.curIndex++
@@ -55,7 +110,17 @@ func (i *edgeIterator) Next() bool {
 
 // Error implements graph.LinkIterator.
 func (i *edgeIterator) Error() error {
-	return nil
+	return i.lastErr
+}
+
+// Cursor implements graph.EdgeIterator.
+func (i *edgeIterator) Cursor() string {
+	if i.curIndex > 0 {
+		return i.edges[i.curIndex-1].ID.String()
+	} else if i.cursor != uuid.Nil {
+		return i.cursor.String()
+	}
+	return ""
 }
 
 // Close implements graph.LinkIterator.
@@ -1,6 +1,7 @@
 package memory
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -9,8 +10,12 @@ import (
 	"golang.org/x/xerrors"
 )
 
-// Compile-time check for ensuring InMemoryGraph implements Graph.
-var _ graph.Graph = (*InMemoryGraph)(nil)
+// Compile-time checks for ensuring InMemoryGraph implements Graph and
+// Restorer.
+var (
+	_ graph.Graph    = (*InMemoryGraph)(nil)
+	_ graph.Restorer = (*InMemoryGraph)(nil)
+)
 
 type edgeList []uuid.UUID
 
@@ -20,92 +25,231 @@ type InMemoryGraph struct {
 	links map[uuid.UUID]*graph.Link
 	edges map[uuid.UUID]*graph.Edge
 
-	linkURLIndex map[string]*graph.Link
-	linkEdgeMap  map[uuid.UUID]edgeList
+	linkURLIndex  map[string]*graph.Link
+	linkEdgeMap   map[uuid.UUID]edgeList
+	linkInEdgeMap map[uuid.UUID]edgeList
+
+	stats   statsCounters
+	changes changeFeed
+
+	// wal is only set for graphs created via NewPersistentInMemoryGraph.
+	wal            *writeAheadLog
+	stopSnapshotCh chan struct{}
+	snapshotDoneCh chan struct{}
 }
 
 // NewInMemoryGraph creates a new in-memory link graph.
 func NewInMemoryGraph() *InMemoryGraph {
 	return &InMemoryGraph{
-		links:        make(map[uuid.UUID]*graph.Link),
-		edges:        make(map[uuid.UUID]*graph.Edge),
-		linkURLIndex: make(map[string]*graph.Link),
-		linkEdgeMap:  make(map[uuid.UUID]edgeList),
+		links:         make(map[uuid.UUID]*graph.Link),
+		edges:         make(map[uuid.UUID]*graph.Edge),
+		linkURLIndex:  make(map[string]*graph.Link),
+		linkEdgeMap:   make(map[uuid.UUID]edgeList),
+		linkInEdgeMap: make(map[uuid.UUID]edgeList),
+		stats:         newStatsCounters(),
 	}
 }
 
 // UpsertLink creates a new link or updates an existing link.
-func (s *InMemoryGraph) UpsertLink(link *graph.Link) error {
+func (s *InMemoryGraph) UpsertLink(ctx context.Context, link *graph.Link) error {
+	if err := ctx.Err(); err != nil {
+		return xerrors.Errorf("upsert link: %w", err)
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// Check if a link with the same URL already exists. If so, convert
-	// this into an update and point the link ID to the existing link.
-	if existing := s.linkURLIndex[link.URL]; existing != nil {
-		link.ID = existing.ID
-		origTs := existing.RetrievedAt //To-Do maybe only the RetrievedDate for existing needs to be compared and updated.
-		*existing = *link
-		// replace link retrieved date if existing.Retrival data was more recent.
-		if origTs.After(existing.RetrievedAt) {
-			existing.RetrievedAt = origTs
+	stored := s.mergeLink(link, nil)
+	if err := s.wal.append(walRecord{Op: opUpsertLink, Link: stored}); err != nil {
+		return xerrors.Errorf("upsert link: %w", err)
+	}
+	s.restoreLink(stored)
+	*link = *stored
+	s.changes.publishLinks(link)
+	return nil
+}
+
+// UpsertLinks creates or updates a batch of links while holding the store
+// lock only once.
+func (s *InMemoryGraph) UpsertLinks(ctx context.Context, links []*graph.Link) error {
+	if err := ctx.Err(); err != nil {
+		return xerrors.Errorf("upsert links: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		records = make([]walRecord, len(links))
+		pending = make(map[string]*graph.Link, len(links))
+	)
+	for i, link := range links {
+		stored := s.mergeLink(link, pending)
+		pending[stored.URL] = stored
+		records[i] = walRecord{Op: opUpsertLink, Link: stored}
+	}
+	if err := s.wal.append(records...); err != nil {
+		return xerrors.Errorf("upsert links: %w", err)
+	}
+
+	for i, link := range links {
+		s.restoreLink(records[i].Link)
+		*link = *records[i].Link
+	}
+	s.changes.publishLinks(links...)
+	return nil
+}
+
+// mergeLink returns the state of link once upserted without modifying the
+// graph. If a link with the same URL exists, either in the graph or in
+// pending, its ID is reused and the attributes of link are merged into it;
+// otherwise a new ID is assigned. Callers must hold the write lock.
+func (s *InMemoryGraph) mergeLink(link *graph.Link, pending map[string]*graph.Link) *graph.Link {
+	stored := new(graph.Link)
+
+	existing := pending[link.URL]
+	if existing == nil {
+		existing = s.linkURLIndex[link.URL]
+	}
+	if existing != nil {
+		*stored = *existing
+
+		// Only replace the retrieved date if the new one is more recent.
+		if link.RetrievedAt.After(stored.RetrievedAt) {
+			stored.RetrievedAt = link.RetrievedAt
 		}
-		return nil
+		stored.Metadata.Merge(link.Metadata)
+		return stored
 	}
 
-	// Assign new ID and insert link
+	*stored = *link
+	if stored.Metadata.DiscoveredAt.IsZero() {
+		stored.Metadata.DiscoveredAt = time.Now()
+	}
+
+	// Assign new ID
 	for {
-		link.ID = uuid.New()
-		if s.links[link.ID] == nil {
+		stored.ID = uuid.New()
+		if s.links[stored.ID] == nil {
 			break
 		}
 	}
+	return stored
+}
 
-	lCopy := new(graph.Link)
-	*lCopy = *link
-	s.linkURLIndex[lCopy.URL] = lCopy
-	s.links[lCopy.ID] = lCopy
+// UpsertEdge creates a new edge or updates an existing edge.
+func (s *InMemoryGraph) UpsertEdge(ctx context.Context, edge *graph.Edge) error {
+	if err := ctx.Err(); err != nil {
+		return xerrors.Errorf("upsert edge: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.mergeEdge(edge, nil)
+	if err != nil {
+		return xerrors.Errorf("upsert edge: %w", err)
+	}
+	if err := s.wal.append(walRecord{Op: opUpsertEdge, Edge: stored}); err != nil {
+		return xerrors.Errorf("upsert edge: %w", err)
+	}
+	s.restoreEdge(stored)
+	*edge = *stored
+	s.changes.publishEdges(edge)
 	return nil
 }
 
-func (s *InMemoryGraph) UpsertEdge(edge *graph.Edge) error {
+// UpsertEdges creates or updates a batch of edges while holding the store
+// lock only once. Edges whose source or destination links are unknown are
+// skipped and reported via a *graph.BatchError.
+func (s *InMemoryGraph) UpsertEdges(ctx context.Context, edges []*graph.Edge) error {
+	if err := ctx.Err(); err != nil {
+		return xerrors.Errorf("upsert edges: %w", err)
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	var (
+		batchErr *graph.BatchError
+		records  []walRecord
+		upserted []*graph.Edge
+		pending  = make(map[linkPair]*graph.Edge, len(edges))
+	)
+	for i, edge := range edges {
+		stored, err := s.mergeEdge(edge, pending)
+		if err != nil {
+			if batchErr == nil {
+				batchErr = &graph.BatchError{Errors: make([]error, len(edges))}
+			}
+			batchErr.Errors[i] = err
+			continue
+		}
+		pending[linkPair{stored.Src, stored.Dst}] = stored
+		records = append(records, walRecord{Op: opUpsertEdge, Edge: stored})
+		upserted = append(upserted, edge)
+	}
+
+	if err := s.wal.append(records...); err != nil {
+		return xerrors.Errorf("upsert edges: %w", err)
+	}
+
+	for i, edge := range upserted {
+		s.restoreEdge(records[i].Edge)
+		*edge = *records[i].Edge
+	}
+	s.changes.publishEdges(upserted...)
+
+	if batchErr != nil {
+		return xerrors.Errorf("upsert edges: %w", batchErr)
+	}
+	return nil
+}
+
+// linkPair identifies an edge by its source and destination links.
+type linkPair struct{ src, dst uuid.UUID }
+
+// mergeEdge returns the state of edge once upserted without modifying the
+// graph. If an edge between the same links exists, either in the graph or
+// in pending, its ID is reused; otherwise a new ID is assigned. Callers must
+// hold the write lock.
+func (s *InMemoryGraph) mergeEdge(edge *graph.Edge, pending map[linkPair]*graph.Edge) (*graph.Edge, error) {
 	_, srcExists := s.links[edge.Src]
 	_, dstExists := s.links[edge.Dst]
 
 	if !srcExists || !dstExists {
-		return xerrors.Errorf("upsert edge: %w", graph.ErrUnknownEdgeLinks)
+		return nil, graph.ErrUnknownEdgeLinks
 	}
 
-	// Scan edge list from source
-	for _, edgeID := range s.linkEdgeMap[edge.Src] {
-		existingEdge := s.edges[edgeID]
-		if existingEdge.Src == edge.Src && existingEdge.Dst == edge.Dst {
-			existingEdge.UpdatedAt = time.Now()
-			*edge = *existingEdge
-			return nil
-		}
+	stored := new(graph.Edge)
+	*stored = *edge
+	stored.UpdatedAt = time.Now()
+
+	existing := pending[linkPair{edge.Src, edge.Dst}]
+	if existing == nil {
+		existing = s.findEdge(edge.Src, edge.Dst)
+	}
+	if existing != nil {
+		// Replace the edge attributes with the most recent ones
+		stored.ID = existing.ID
+		return stored, nil
 	}
 
 	for {
-		edge.ID = uuid.New()
-		if s.edges[edge.ID] == nil {
+		stored.ID = uuid.New()
+		if s.edges[stored.ID] == nil {
 			break
 		}
 	}
-
-	edge.UpdatedAt = time.Now()
-	eCopy := new(graph.Edge)
-	*eCopy = *edge
-	s.edges[eCopy.ID] = eCopy
-
-	// Append the edge ID to the list of edges originating fdrom the edge's source link
-	s.linkEdgeMap[edge.Src] = append(s.linkEdgeMap[edge.Src], eCopy.ID)
-	return nil
+	return stored, nil
 }
 
-func (s *InMemoryGraph) FindLink(id uuid.UUID) (*graph.Link, error) {
+// FindLink looks up a link by its ID.
+func (s *InMemoryGraph) FindLink(ctx context.Context, id uuid.UUID) (*graph.Link, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, xerrors.Errorf("find link: %w", err)
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -120,24 +264,137 @@ func (s *InMemoryGraph) FindLink(id uuid.UUID) (*graph.Link, error) {
 	return lCopy, nil
 }
 
-func (s *InMemoryGraph) Links(fromID, toID uuid.UUID, retrievedBefore time.Time) (graph.LinkIterator, error) {
+// FindLinkByURL looks up a link by its URL.
+func (s *InMemoryGraph) FindLinkByURL(ctx context.Context, url string) (*graph.Link, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, xerrors.Errorf("find link by URL: %w", err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	link := s.linkURLIndex[url]
+	if link == nil {
+		return nil, xerrors.Errorf("find link by URL: %w", graph.ErrNotFound)
+	}
+
+	lCopy := new(graph.Link)
+	*lCopy = *link
+	return lCopy, nil
+}
+
+// FindLinksByURL looks up a batch of links by their URLs. URLs that do not
+// correspond to a known link are omitted from the returned list and
+// duplicate URLs are only returned once.
+func (s *InMemoryGraph) FindLinksByURL(ctx context.Context, urls []string) ([]*graph.Link, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, xerrors.Errorf("find links by URL: %w", err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var (
+		list []*graph.Link
+		seen = make(map[string]bool, len(urls))
+	)
+	for _, url := range urls {
+		link := s.linkURLIndex[url]
+		if link == nil || seen[url] {
+			continue
+		}
+		seen[url] = true
+
+		lCopy := new(graph.Link)
+		*lCopy = *link
+		list = append(list, lCopy)
+	}
+	return list, nil
+}
+
+// Links returns an iterator for the set of links whose IDs belong to the
+// [fromID, toID) range and were retrieved before the provided timestamp.
+func (s *InMemoryGraph) Links(ctx context.Context, fromID, toID uuid.UUID, retrievedBefore time.Time, opts ...graph.IteratorOption) (graph.LinkIterator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, xerrors.Errorf("links: %w", err)
+	}
+
+	itOpts := graph.ApplyIteratorOptions(opts...)
+	cursor, err := graph.ParseCursor(itOpts.Cursor)
+	if err != nil {
+		return nil, xerrors.Errorf("links: %w", err)
+	}
+
 	from, to := fromID.String(), toID.String()
 
 	s.mu.RLock()
 	var list []*graph.Link
 	for linkID, link := range s.links {
-		if id := linkID.String(); id >= from && id < to && link.RetrievedAt.Before(retrievedBefore) {
-			list = append(list, link)
+		if id := linkID.String(); id < from || id >= to || !link.RetrievedAt.Before(retrievedBefore) {
+			continue
+		}
+		if itOpts.HostHashes != nil && !itOpts.HostHashes.Contains(graph.HostHash(link.URL)) {
+			continue
 		}
+		list = append(list, link)
 	}
-	s.mu.RLocker()
+	it := newLinkIterator(ctx, s, list, cursor)
+	s.mu.RUnlock()
 
-	return &linkIterator{s: s, links: list}, nil
+	return it, nil
+}
 
+// MostDueLinks returns up to limit links in the [fromID, toID) range that
+// are due at dueAt, ordered by descending priority and ascending next fetch
+// time.
+func (s *InMemoryGraph) MostDueLinks(ctx context.Context, fromID, toID uuid.UUID, dueAt time.Time, limit int, opts ...graph.IteratorOption) ([]*graph.Link, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, xerrors.Errorf("most due links: %w", err)
+	} else if limit < 0 {
+		return nil, xerrors.Errorf("most due links: %w", graph.ErrInvalidLimit)
+	} else if limit == 0 {
+		return nil, nil
+	}
+
+	itOpts := graph.ApplyIteratorOptions(opts...)
+	from, to := fromID.String(), toID.String()
+
+	s.mu.RLock()
+	var list []*graph.Link
+	for linkID, link := range s.links {
+		if id := linkID.String(); id < from || id >= to || !link.IsDue(dueAt) {
+			continue
+		}
+		if itOpts.HostHashes != nil && !itOpts.HostHashes.Contains(graph.HostHash(link.URL)) {
+			continue
+		}
+		lCopy := new(graph.Link)
+		*lCopy = *link
+		list = append(list, lCopy)
+	}
+	s.mu.RUnlock()
+
+	graph.SortByDue(list)
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
 }
 
-func (s *InMemoryGraph) Edges(fromID, toID uuid.UUID, updatedBefore time.Time) (graph.EdgeIterator, error) {
+// Edges returns an iterator for the set of edges whose source vertex IDs
+// belong to the [fromID, toID) range and were last updated before the provided
+// value.
+func (s *InMemoryGraph) Edges(ctx context.Context, fromID, toID uuid.UUID, updatedBefore time.Time, opts ...graph.IteratorOption) (graph.EdgeIterator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, xerrors.Errorf("edges: %w", err)
+	}
+
 	from, to := fromID.String(), toID.String()
+	itOpts := graph.ApplyIteratorOptions(opts...)
+	cursor, err := graph.ParseCursor(itOpts.Cursor)
+	if err != nil {
+		return nil, xerrors.Errorf("edges: %w", err)
+	}
 
 	s.mu.RLock()
 	var list []*graph.Edge
@@ -147,26 +404,123 @@ func (s *InMemoryGraph) Edges(fromID, toID uuid.UUID, updatedBefore time.Time) (
 		}
 
 		for _, edgeID := range s.linkEdgeMap[linkID] {
-			if edge := s.edges[edgeID]; edge.UpdatedAt.Before(updatedBefore) {
+			if edge := s.edges[edgeID]; edgeMatches(edge, updatedBefore, itOpts) {
 				list = append(list, edge)
 			}
 		}
 	}
+	it := newEdgeIterator(ctx, s, list, cursor)
 	s.mu.RUnlock()
 
-	return &edgeIterator{s: s, edges: list}, nil
+	return it, nil
+}
+
+// InboundEdges returns an iterator for the set of edges that point to the
+// specified link ID and were last updated before the provided value.
+func (s *InMemoryGraph) InboundEdges(ctx context.Context, dstID uuid.UUID, updatedBefore time.Time, opts ...graph.IteratorOption) (graph.EdgeIterator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, xerrors.Errorf("inbound edges: %w", err)
+	}
+
+	itOpts := graph.ApplyIteratorOptions(opts...)
+	cursor, err := graph.ParseCursor(itOpts.Cursor)
+	if err != nil {
+		return nil, xerrors.Errorf("inbound edges: %w", err)
+	}
+
+	s.mu.RLock()
+	var list []*graph.Edge
+	for _, edgeID := range s.linkInEdgeMap[dstID] {
+		if edge := s.edges[edgeID]; edgeMatches(edge, updatedBefore, itOpts) {
+			list = append(list, edge)
+		}
+	}
+	it := newEdgeIterator(ctx, s, list, cursor)
+	s.mu.RUnlock()
+
+	return it, nil
+}
+
+// edgeMatches returns true if edge was updated before the specified
+// timestamp and is not excluded by the iterator options.
+func edgeMatches(edge *graph.Edge, updatedBefore time.Time, opts graph.IteratorOptions) bool {
+	if opts.ExcludeNoFollow && edge.NoFollow {
+		return false
+	}
+	return edge.UpdatedAt.Before(updatedBefore)
+}
+
+// InDegree returns the number of edges that point to the specified link ID.
+func (s *InMemoryGraph) InDegree(ctx context.Context, id uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, xerrors.Errorf("in degree: %w", err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.links[id] == nil {
+		return 0, xerrors.Errorf("in degree: %w", graph.ErrNotFound)
+	}
+	return len(s.linkInEdgeMap[id]), nil
+}
+
+// OutDegree returns the number of edges that originate from the specified
+// link ID.
+func (s *InMemoryGraph) OutDegree(ctx context.Context, id uuid.UUID) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, xerrors.Errorf("out degree: %w", err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.links[id] == nil {
+		return 0, xerrors.Errorf("out degree: %w", graph.ErrNotFound)
+	}
+	return len(s.linkEdgeMap[id]), nil
 }
 
 // RemoveStaleEdges removes any edge that originates from the specified link ID
 // and was updated before the specified timestamp.
-func (s *InMemoryGraph) RemoveStaleEdges(fromID uuid.UUID, updatedBefore time.Time) error {
+func (s *InMemoryGraph) RemoveStaleEdges(ctx context.Context, fromID uuid.UUID, updatedBefore time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return xerrors.Errorf("remove stale edges: %w", err)
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if !s.hasStaleEdges(fromID, updatedBefore) {
+		return nil
+	}
+	if err := s.wal.append(walRecord{Op: opRemoveStaleEdges, ID: fromID, UpdatedBefore: updatedBefore}); err != nil {
+		return xerrors.Errorf("remove stale edges: %w", err)
+	}
+	s.removeStaleEdges(fromID, updatedBefore)
+	s.changes.publishStaleEdgesRemoved(fromID, updatedBefore)
+	return nil
+}
+
+// hasStaleEdges returns true if any edge that originates from fromID was
+// updated before the specified timestamp. Callers must hold the read lock.
+func (s *InMemoryGraph) hasStaleEdges(fromID uuid.UUID, updatedBefore time.Time) bool {
+	for _, edgeID := range s.linkEdgeMap[fromID] {
+		if s.edges[edgeID].UpdatedAt.Before(updatedBefore) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeStaleEdges implements RemoveStaleEdges and reports whether any edges
+// were removed. Callers must hold the write lock.
+func (s *InMemoryGraph) removeStaleEdges(fromID uuid.UUID, updatedBefore time.Time) bool {
 	var newEdgeList edgeList
 	for _, edgeID := range s.linkEdgeMap[fromID] {
 		edge := s.edges[edgeID]
 		if edge.UpdatedAt.Before(updatedBefore) {
+			s.linkInEdgeMap[edge.Dst] = s.linkInEdgeMap[edge.Dst].without(edgeID)
 			delete(s.edges, edgeID)
 			continue
 		}
@@ -174,7 +528,187 @@ func (s *InMemoryGraph) RemoveStaleEdges(fromID uuid.UUID, updatedBefore time.Ti
 		newEdgeList = append(newEdgeList, edgeID)
 	}
 
+	if len(newEdgeList) == len(s.linkEdgeMap[fromID]) {
+		return false
+	}
+
 	// Replace edge list or origin link with the filtered edge list
-	s.linkEdgeMap[fromID] = newEdgeList
+	s.setOutEdges(fromID, newEdgeList)
+	return true
+}
+
+// RemoveLink removes the link with the specified ID together with any edges
+// that originate from or point to it.
+func (s *InMemoryGraph) RemoveLink(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return xerrors.Errorf("remove link: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	link := s.links[id]
+	if link == nil {
+		return xerrors.Errorf("remove link: %w", graph.ErrNotFound)
+	}
+
+	if err := s.wal.append(walRecord{Op: opRemoveLink, ID: id}); err != nil {
+		return xerrors.Errorf("remove link: %w", err)
+	}
+	s.removeLink(link)
+	return nil
+}
+
+// removeLink deletes link together with its edges. Callers must hold the
+// write lock.
+func (s *InMemoryGraph) removeLink(link *graph.Link) {
+	id := link.ID
+
+	// Drop outbound and inbound edges. The edge lists are cloned as
+	// removeEdge modifies them in place.
+	for _, edgeID := range append(edgeList(nil), s.linkEdgeMap[id]...) {
+		s.removeEdge(s.edges[edgeID])
+	}
+	for _, edgeID := range append(edgeList(nil), s.linkInEdgeMap[id]...) {
+		s.removeEdge(s.edges[edgeID])
+	}
+	s.untrackLink(link)
+	delete(s.linkEdgeMap, id)
+	delete(s.linkInEdgeMap, id)
+
+	delete(s.linkURLIndex, link.URL)
+	delete(s.links, id)
+}
+
+// RemoveEdge removes the edge with the specified ID.
+func (s *InMemoryGraph) RemoveEdge(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.Err(); err != nil {
+		return xerrors.Errorf("remove edge: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	edge := s.edges[id]
+	if edge == nil {
+		return xerrors.Errorf("remove edge: %w", graph.ErrNotFound)
+	}
+
+	if err := s.wal.append(walRecord{Op: opRemoveEdge, ID: id}); err != nil {
+		return xerrors.Errorf("remove edge: %w", err)
+	}
+	s.removeEdge(edge)
+	return nil
+}
+
+// removeEdge deletes edge and drops it from the outbound edge list of its
+// source and the inbound edge list of its destination. Callers must hold the
+// write lock.
+func (s *InMemoryGraph) removeEdge(edge *graph.Edge) {
+	s.setOutEdges(edge.Src, s.linkEdgeMap[edge.Src].without(edge.ID))
+	s.linkInEdgeMap[edge.Dst] = s.linkInEdgeMap[edge.Dst].without(edge.ID)
+	delete(s.edges, edge.ID)
+}
+
+// without returns the edge list with id filtered out. The filtering is
+// performed in place.
+func (l edgeList) without(id uuid.UUID) edgeList {
+	filtered := l[:0]
+	for _, edgeID := range l {
+		if edgeID != id {
+			filtered = append(filtered, edgeID)
+		}
+	}
+	return filtered
+}
+
+// RestoreLinks inserts links or replaces the links with the same ID,
+// preserving their IDs and timestamps.
+func (s *InMemoryGraph) RestoreLinks(ctx context.Context, links []*graph.Link) error {
+	if err := ctx.Err(); err != nil {
+		return xerrors.Errorf("restore links: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Validate the whole batch before applying it so that a conflict
+	// leaves the graph unchanged.
+	batchURLs := make(map[string]uuid.UUID, len(links))
+	for _, link := range links {
+		if existing := s.linkURLIndex[link.URL]; existing != nil && existing.ID != link.ID {
+			return xerrors.Errorf("restore links: url %q: %w", link.URL, graph.ErrConflict)
+		}
+		if id, seen := batchURLs[link.URL]; seen && id != link.ID {
+			return xerrors.Errorf("restore links: url %q: %w", link.URL, graph.ErrConflict)
+		}
+		batchURLs[link.URL] = link.ID
+	}
+
+	records := make([]walRecord, len(links))
+	for i, link := range links {
+		if link.Metadata.DiscoveredAt.IsZero() {
+			link.Metadata.DiscoveredAt = time.Now()
+		}
+		records[i] = walRecord{Op: opUpsertLink, Link: link}
+	}
+	if err := s.wal.append(records...); err != nil {
+		return xerrors.Errorf("restore links: %w", err)
+	}
+	for _, link := range links {
+		s.restoreLink(link)
+	}
+	s.changes.publishLinks(links...)
+	return nil
+}
+
+// RestoreEdges inserts edges or replaces the edges with the same ID,
+// preserving their IDs and timestamps.
+func (s *InMemoryGraph) RestoreEdges(ctx context.Context, edges []*graph.Edge) error {
+	if err := ctx.Err(); err != nil {
+		return xerrors.Errorf("restore edges: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	batchPairs := make(map[linkPair]uuid.UUID, len(edges))
+	for _, edge := range edges {
+		if s.links[edge.Src] == nil || s.links[edge.Dst] == nil {
+			return xerrors.Errorf("restore edges: edge %s: %w", edge.ID, graph.ErrUnknownEdgeLinks)
+		}
+
+		pair := linkPair{edge.Src, edge.Dst}
+		if existing := s.findEdge(edge.Src, edge.Dst); existing != nil && existing.ID != edge.ID {
+			return xerrors.Errorf("restore edges: edge %s: %w", edge.ID, graph.ErrConflict)
+		}
+		if id, seen := batchPairs[pair]; seen && id != edge.ID {
+			return xerrors.Errorf("restore edges: edge %s: %w", edge.ID, graph.ErrConflict)
+		}
+		batchPairs[pair] = edge.ID
+	}
+
+	records := make([]walRecord, len(edges))
+	for i, edge := range edges {
+		records[i] = walRecord{Op: opUpsertEdge, Edge: edge}
+	}
+	if err := s.wal.append(records...); err != nil {
+		return xerrors.Errorf("restore edges: %w", err)
+	}
+	for _, edge := range edges {
+		s.restoreEdge(edge)
+	}
+	s.changes.publishEdges(edges...)
+	return nil
+}
+
+// findEdge returns the edge from src to dst or nil if no such edge exists.
+// Callers must hold the read lock.
+func (s *InMemoryGraph) findEdge(src, dst uuid.UUID) *graph.Edge {
+	for _, edgeID := range s.linkEdgeMap[src] {
+		if edge := s.edges[edgeID]; edge.Dst == dst {
+			return edge
+		}
+	}
 	return nil
 }
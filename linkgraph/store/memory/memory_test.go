@@ -1,9 +1,13 @@
 package memory
 
 import (
+	"context"
+	"fmt"
 	"testing"
 
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
 	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph/graphtest"
+	"golang.org/x/xerrors"
 	gc "gopkg.in/check.v1"
 )
 
@@ -13,10 +17,39 @@ func Test(t *testing.T) { gc.TestingT(t) }
 
 type InMemoryGraphTestSuite struct {
 	graphtest.SuiteBase
+	g *InMemoryGraph
 }
 
 func (s *InMemoryGraphTestSuite) SetUpTest(c *gc.C) {
-	s.SetGraph(NewInMemoryGraph())
+	s.g = NewInMemoryGraph()
+	s.SetGraph(s.g)
+}
+
+func (s *InMemoryGraphTestSuite) TestLaggingSubscriber(c *gc.C) {
+	lagging, err := s.g.Subscribe(context.TODO())
+	c.Assert(err, gc.IsNil)
+	active, err := s.g.Subscribe(context.TODO())
+	c.Assert(err, gc.IsNil)
+	defer func() { c.Assert(active.Close(), gc.IsNil) }()
+
+	for i := 0; i <= changeBufferSize; i++ {
+		c.Assert(s.g.UpsertLink(context.TODO(), &graph.Link{URL: fmt.Sprint("https://example.com/", i)}), gc.IsNil)
+		if i < changeBufferSize {
+			c.Assert(active.Next(), gc.Equals, true)
+		}
+	}
+
+	// The buffered events are still delivered before the error is reported.
+	for i := 0; i < changeBufferSize; i++ {
+		c.Assert(lagging.Next(), gc.Equals, true)
+	}
+	c.Assert(lagging.Next(), gc.Equals, false)
+	c.Assert(xerrors.Is(lagging.Error(), graph.ErrSubscriberLagged), gc.Equals, true)
+	c.Assert(lagging.Close(), gc.IsNil)
+
+	// Subscribers that keep up are not affected.
+	c.Assert(active.Next(), gc.Equals, true)
+	c.Assert(active.Event().Link.URL, gc.Equals, fmt.Sprint("https://example.com/", changeBufferSize))
 }
 
 // In-memory graph implementation completed
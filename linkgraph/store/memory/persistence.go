@@ -0,0 +1,365 @@
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+const (
+	snapshotFile = "snapshot.json"
+	walFile      = "wal.log"
+)
+
+// walOp identifies the graph operation recorded by a log entry.
+type walOp string
+
+const (
+	opUpsertLink       walOp = "upsert_link"
+	opUpsertEdge       walOp = "upsert_edge"
+	opRemoveLink       walOp = "remove_link"
+	opRemoveEdge       walOp = "remove_edge"
+	opRemoveStaleEdges walOp = "remove_stale_edges"
+)
+
+// walRecord is a single entry in the write-ahead log. Upserts record the
+// stored state of the link or edge (including its assigned ID and
+// timestamps) so that replaying the log reproduces the graph exactly.
+type walRecord struct {
+	Seq           uint64      `json:"seq"`
+	Op            walOp       `json:"op"`
+	Link          *graph.Link `json:"link,omitempty"`
+	Edge          *graph.Edge `json:"edge,omitempty"`
+	ID            uuid.UUID   `json:"id"`
+	UpdatedBefore time.Time   `json:"updated_before"`
+}
+
+// snapshot is the on-disk representation of a compacted graph. Seq is the
+// sequence number of the last log entry reflected in the snapshot.
+type snapshot struct {
+	Seq   uint64        `json:"seq"`
+	Links []*graph.Link `json:"links"`
+	Edges []*graph.Edge `json:"edges"`
+}
+
+// PersistenceConfig configures the durability of an InMemoryGraph.
+type PersistenceConfig struct {
+	// Dir is the directory where the snapshot and the write-ahead log
+	// are stored. It is created if it does not exist.
+	Dir string
+
+	// SnapshotInterval controls how often the graph is compacted into a
+	// snapshot and the write-ahead log is truncated. If zero, snapshots
+	// are only taken when Snapshot or Close are invoked.
+	SnapshotInterval time.Duration
+}
+
+// NewPersistentInMemoryGraph creates an in-memory link graph that records
+// every mutation in a write-ahead log and periodically compacts it into a
+// snapshot. Any state persisted in cfg.Dir by a previous instance is
+// restored before the graph is returned.
+func NewPersistentInMemoryGraph(cfg PersistenceConfig) (*InMemoryGraph, error) {
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return nil, xerrors.Errorf("new persistent in-memory graph: %w", err)
+	}
+
+	s := NewInMemoryGraph()
+	snapSeq, err := s.loadSnapshot(filepath.Join(cfg.Dir, snapshotFile))
+	if err != nil {
+		return nil, xerrors.Errorf("new persistent in-memory graph: load snapshot: %w", err)
+	}
+
+	f, err := os.OpenFile(filepath.Join(cfg.Dir, walFile), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, xerrors.Errorf("new persistent in-memory graph: %w", err)
+	}
+
+	lastSeq, err := s.replayLog(f, snapSeq)
+	if err != nil {
+		_ = f.Close()
+		return nil, xerrors.Errorf("new persistent in-memory graph: replay log: %w", err)
+	}
+
+	s.wal = &writeAheadLog{dir: cfg.Dir, f: f, seq: lastSeq}
+	if cfg.SnapshotInterval > 0 {
+		s.stopSnapshotCh = make(chan struct{})
+		s.snapshotDoneCh = make(chan struct{})
+		go s.snapshotLoop(cfg.SnapshotInterval)
+	}
+	return s, nil
+}
+
+// Snapshot compacts the current graph state into a snapshot file and
+// truncates the write-ahead log. It is a no-op for graphs created via
+// NewInMemoryGraph.
+func (s *InMemoryGraph) Snapshot() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.snapshot(); err != nil {
+		return xerrors.Errorf("snapshot: %w", err)
+	}
+	return nil
+}
+
+// Close stops the periodic snapshots, writes a final snapshot and releases
+// the write-ahead log. It is a no-op for graphs created via
+// NewInMemoryGraph.
+func (s *InMemoryGraph) Close() error {
+	if s.stopSnapshotCh != nil {
+		close(s.stopSnapshotCh)
+		<-s.snapshotDoneCh
+		s.stopSnapshotCh = nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.wal == nil {
+		return nil
+	}
+
+	err := s.snapshot()
+	if closeErr := s.wal.f.Close(); err == nil {
+		err = closeErr
+	}
+	s.wal = nil
+
+	if err != nil {
+		return xerrors.Errorf("close: %w", err)
+	}
+	return nil
+}
+
+func (s *InMemoryGraph) snapshotLoop(interval time.Duration) {
+	defer close(s.snapshotDoneCh)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopSnapshotCh:
+			return
+		case <-ticker.C:
+			// A failed snapshot leaves the log intact; the next
+			// attempt (or Close) will retry the compaction.
+			_ = s.Snapshot()
+		}
+	}
+}
+
+// snapshot writes the graph state to a temporary file, atomically moves it
+// into place and then truncates the log. Callers must hold the write lock.
+func (s *InMemoryGraph) snapshot() error {
+	if s.wal == nil {
+		return nil
+	}
+
+	snap := snapshot{Seq: s.wal.seq}
+	for _, link := range s.links {
+		snap.Links = append(snap.Links, link)
+	}
+	for _, edge := range s.edges {
+		snap.Edges = append(snap.Edges, edge)
+	}
+
+	var (
+		path    = filepath.Join(s.wal.dir, snapshotFile)
+		tmpPath = path + ".tmp"
+	)
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if err = json.NewEncoder(f).Encode(snap); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+
+	// Entries up to snap.Seq are now covered by the snapshot. Even if the
+	// truncation fails they are skipped when the log is replayed.
+	return s.wal.f.Truncate(0)
+}
+
+// loadSnapshot restores the graph state from the snapshot at path and
+// returns the sequence number of the last log entry it reflects. A missing
+// snapshot is not an error.
+func (s *InMemoryGraph) loadSnapshot(path string) (uint64, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var snap snapshot
+	if err = json.NewDecoder(f).Decode(&snap); err != nil {
+		return 0, err
+	}
+
+	for _, link := range snap.Links {
+		s.restoreLink(link)
+	}
+	for _, edge := range snap.Edges {
+		s.restoreEdge(edge)
+	}
+	return snap.Seq, nil
+}
+
+// replayLog applies the log entries in f whose sequence numbers are greater
+// than afterSeq and returns the sequence number of the last entry in the log.
+// An incomplete trailing entry, left behind by a crash mid-write, is
+// discarded.
+func (s *InMemoryGraph) replayLog(f *os.File, afterSeq uint64) (uint64, error) {
+	var (
+		r       = bufio.NewReader(f)
+		lastSeq = afterSeq
+		offset  int64
+	)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(bytes.TrimSpace(line)) != 0 {
+				return lastSeq, f.Truncate(offset)
+			}
+			return lastSeq, nil
+		} else if err != nil {
+			return 0, err
+		}
+		offset += int64(len(line))
+
+		var rec walRecord
+		if err = json.Unmarshal(line, &rec); err != nil {
+			return 0, xerrors.Errorf("decode entry at offset %d: %w", offset-int64(len(line)), err)
+		}
+		if rec.Seq > lastSeq {
+			lastSeq = rec.Seq
+		}
+		if rec.Seq > afterSeq {
+			s.applyRecord(rec)
+		}
+	}
+}
+
+// applyRecord replays a single log entry.
+func (s *InMemoryGraph) applyRecord(rec walRecord) {
+	switch rec.Op {
+	case opUpsertLink:
+		s.restoreLink(rec.Link)
+	case opUpsertEdge:
+		s.restoreEdge(rec.Edge)
+	case opRemoveLink:
+		if link := s.links[rec.ID]; link != nil {
+			s.removeLink(link)
+		}
+	case opRemoveEdge:
+		if edge := s.edges[rec.ID]; edge != nil {
+			s.removeEdge(edge)
+		}
+	case opRemoveStaleEdges:
+		s.removeStaleEdges(rec.ID, rec.UpdatedBefore)
+	}
+}
+
+// restoreLink inserts or replaces a link using its persisted ID.
+func (s *InMemoryGraph) restoreLink(link *graph.Link) {
+	if existing := s.links[link.ID]; existing != nil {
+		s.countLink(existing, -1)
+		if existing.URL != link.URL {
+			delete(s.linkURLIndex, existing.URL)
+			s.linkURLIndex[link.URL] = existing
+		}
+		*existing = *link
+		s.countLink(existing, 1)
+		return
+	}
+
+	lCopy := new(graph.Link)
+	*lCopy = *link
+	s.linkURLIndex[lCopy.URL] = lCopy
+	s.links[lCopy.ID] = lCopy
+	s.trackLink(lCopy)
+}
+
+// restoreEdge inserts or replaces an edge using its persisted ID.
+func (s *InMemoryGraph) restoreEdge(edge *graph.Edge) {
+	if existing := s.edges[edge.ID]; existing != nil {
+		if existing.Src == edge.Src && existing.Dst == edge.Dst {
+			*existing = *edge
+			return
+		}
+
+		// The edge now connects a different pair of links.
+		s.removeEdge(existing)
+	}
+
+	eCopy := new(graph.Edge)
+	*eCopy = *edge
+	s.edges[eCopy.ID] = eCopy
+	s.setOutEdges(edge.Src, append(s.linkEdgeMap[edge.Src], eCopy.ID))
+	s.linkInEdgeMap[edge.Dst] = append(s.linkInEdgeMap[edge.Dst], eCopy.ID)
+}
+
+// writeAheadLog appends JSON-encoded walRecords to a log file.
+type writeAheadLog struct {
+	dir string
+	f   *os.File
+	seq uint64
+}
+
+// append assigns sequence numbers to recs and durably writes them to the
+// log. It is a no-op if the log is nil, i.e. for non-persistent graphs. If
+// the write fails, the log is truncated back to its previous size so that a
+// partially written batch is not replayed.
+func (l *writeAheadLog) append(recs ...walRecord) error {
+	if l == nil || len(recs) == 0 {
+		return nil
+	}
+
+	var (
+		buf bytes.Buffer
+		enc = json.NewEncoder(&buf)
+		seq = l.seq
+	)
+	for _, rec := range recs {
+		seq++
+		rec.Seq = seq
+		if err := enc.Encode(rec); err != nil {
+			return xerrors.Errorf("write-ahead log: %w", err)
+		}
+	}
+
+	info, err := l.f.Stat()
+	if err != nil {
+		return xerrors.Errorf("write-ahead log: %w", err)
+	}
+	if _, err = l.f.Write(buf.Bytes()); err == nil {
+		err = l.f.Sync()
+	}
+	if err != nil {
+		_ = l.f.Truncate(info.Size())
+		return xerrors.Errorf("write-ahead log: %w", err)
+	}
+
+	l.seq = seq
+	return nil
+}
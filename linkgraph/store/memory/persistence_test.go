@@ -0,0 +1,200 @@
+package memory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph/graphtest"
+	gc "gopkg.in/check.v1"
+)
+
+var _ = gc.Suite(new(PersistentInMemoryGraphTestSuite))
+
+type PersistentInMemoryGraphTestSuite struct {
+	graphtest.SuiteBase
+	g   *InMemoryGraph
+	dir string
+}
+
+func (s *PersistentInMemoryGraphTestSuite) SetUpTest(c *gc.C) {
+	s.dir = c.MkDir()
+	s.g = s.open(c)
+	s.SetGraph(s.g)
+}
+
+func (s *PersistentInMemoryGraphTestSuite) TearDownTest(c *gc.C) {
+	c.Assert(s.g.Close(), gc.IsNil)
+}
+
+func (s *PersistentInMemoryGraphTestSuite) open(c *gc.C) *InMemoryGraph {
+	g, err := NewPersistentInMemoryGraph(PersistenceConfig{Dir: s.dir})
+	c.Assert(err, gc.IsNil)
+	return g
+}
+
+func (s *PersistentInMemoryGraphTestSuite) TestRecoverFromLog(c *gc.C) {
+	src, dst := s.populate(c)
+
+	// Simulate a crash by re-opening the directory without closing the
+	// original graph; only the log is available.
+	g := s.open(c)
+	defer func() { c.Assert(g.Close(), gc.IsNil) }()
+	s.assertRecovered(c, g, src, dst)
+
+	// The stats counters must be rebuilt while replaying the log.
+	stats, err := g.Stats(context.TODO())
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats.Links, gc.Equals, 2)
+	c.Assert(stats.Edges, gc.Equals, 1)
+	c.Assert(stats.UncrawledLinks, gc.Equals, 1)
+	c.Assert(stats.OutDegreeHistogram, gc.DeepEquals, map[int]int{0: 1, 1: 1})
+	c.Assert(stats.HostLinks, gc.DeepEquals, map[string]int{"example.com": 2})
+}
+
+func (s *PersistentInMemoryGraphTestSuite) TestRecoverFromSnapshotAndLog(c *gc.C) {
+	src, dst := s.populate(c)
+	c.Assert(s.g.Snapshot(), gc.IsNil)
+
+	// Mutations after the snapshot are only present in the log.
+	extra := &graph.Link{URL: "https://example.com/c"}
+	c.Assert(s.g.UpsertLink(context.TODO(), extra), gc.IsNil)
+	c.Assert(s.g.UpsertEdge(context.TODO(), &graph.Edge{Src: dst.ID, Dst: extra.ID}), gc.IsNil)
+
+	g := s.open(c)
+	defer func() { c.Assert(g.Close(), gc.IsNil) }()
+	s.assertRecovered(c, g, src, dst)
+
+	_, err := g.FindLink(context.TODO(), extra.ID)
+	c.Assert(err, gc.IsNil)
+	degree, err := g.OutDegree(context.TODO(), dst.ID)
+	c.Assert(err, gc.IsNil)
+	c.Assert(degree, gc.Equals, 1)
+}
+
+func (s *PersistentInMemoryGraphTestSuite) TestRecoverAfterClose(c *gc.C) {
+	src, dst := s.populate(c)
+	c.Assert(s.g.Close(), gc.IsNil)
+
+	logInfo, err := os.Stat(filepath.Join(s.dir, walFile))
+	c.Assert(err, gc.IsNil)
+	c.Assert(logInfo.Size(), gc.Equals, int64(0), gc.Commentf("expected Close to compact the log"))
+
+	s.g = s.open(c)
+	s.assertRecovered(c, s.g, src, dst)
+}
+
+func (s *PersistentInMemoryGraphTestSuite) TestDiscardIncompleteLogEntry(c *gc.C) {
+	src, dst := s.populate(c)
+	c.Assert(s.g.Close(), gc.IsNil)
+
+	f, err := os.OpenFile(filepath.Join(s.dir, walFile), os.O_WRONLY|os.O_APPEND, 0644)
+	c.Assert(err, gc.IsNil)
+	_, err = f.WriteString(`{"seq":42,"op":"upsert_li`)
+	c.Assert(err, gc.IsNil)
+	c.Assert(f.Close(), gc.IsNil)
+
+	s.g = s.open(c)
+	s.assertRecovered(c, s.g, src, dst)
+
+	// New entries must be appended after the last complete one.
+	extra := &graph.Link{URL: "https://example.com/c"}
+	c.Assert(s.g.UpsertLink(context.TODO(), extra), gc.IsNil)
+	_, err = s.recoverReadOnly(c).FindLink(context.TODO(), extra.ID)
+	c.Assert(err, gc.IsNil)
+}
+
+func (s *PersistentInMemoryGraphTestSuite) TestFailedAppendLeavesGraphUnchanged(c *gc.C) {
+	src, dst := s.populate(c)
+
+	// Closing the log file causes any subsequent append to fail.
+	c.Assert(s.g.wal.f.Close(), gc.IsNil)
+	defer func() { s.g.wal = nil }()
+
+	err := s.g.UpsertLink(context.TODO(), &graph.Link{URL: "https://example.com/c"})
+	c.Assert(err, gc.NotNil)
+	_, err = s.g.FindLinkByURL(context.TODO(), "https://example.com/c")
+	c.Assert(err, gc.ErrorMatches, ".*not found")
+
+	err = s.g.UpsertLink(context.TODO(), &graph.Link{URL: src.URL, Metadata: graph.LinkMetadata{StatusCode: 404}})
+	c.Assert(err, gc.NotNil)
+	err = s.g.UpsertEdge(context.TODO(), &graph.Edge{Src: dst.ID, Dst: src.ID})
+	c.Assert(err, gc.NotNil)
+	err = s.g.RemoveStaleEdges(context.TODO(), src.ID, time.Now())
+	c.Assert(err, gc.NotNil)
+	err = s.g.RemoveLink(context.TODO(), dst.ID)
+	c.Assert(err, gc.NotNil)
+
+	s.assertRecovered(c, s.g, src, dst)
+	stats, err := s.g.Stats(context.TODO())
+	c.Assert(err, gc.IsNil)
+	c.Assert(stats.Links, gc.Equals, 2)
+	c.Assert(stats.Edges, gc.Equals, 1)
+}
+
+// recoverReadOnly rebuilds the graph persisted in s.dir without opening the
+// log for writing, leaving the state owned by s.g untouched.
+func (s *PersistentInMemoryGraphTestSuite) recoverReadOnly(c *gc.C) *InMemoryGraph {
+	g := NewInMemoryGraph()
+	snapSeq, err := g.loadSnapshot(filepath.Join(s.dir, snapshotFile))
+	c.Assert(err, gc.IsNil)
+
+	f, err := os.Open(filepath.Join(s.dir, walFile))
+	c.Assert(err, gc.IsNil)
+	defer func() { c.Assert(f.Close(), gc.IsNil) }()
+
+	_, err = g.replayLog(f, snapSeq)
+	c.Assert(err, gc.IsNil)
+	return g
+}
+
+// populate inserts two links connected by an edge, plus a third link and
+// edge that are subsequently removed.
+func (s *PersistentInMemoryGraphTestSuite) populate(c *gc.C) (*graph.Link, *graph.Link) {
+	src := &graph.Link{URL: "https://example.com/a", RetrievedAt: time.Now().Add(-time.Hour)}
+	dst := &graph.Link{URL: "https://example.com/b"}
+	removed := &graph.Link{URL: "https://example.com/removed"}
+	c.Assert(s.g.UpsertLinks(context.TODO(), []*graph.Link{src, dst, removed}), gc.IsNil)
+
+	edges := []*graph.Edge{
+		{Src: src.ID, Dst: dst.ID, AnchorText: "b"},
+		{Src: src.ID, Dst: removed.ID},
+	}
+	c.Assert(s.g.UpsertEdges(context.TODO(), edges), gc.IsNil)
+	c.Assert(s.g.RemoveLink(context.TODO(), removed.ID), gc.IsNil)
+
+	// Update the metadata of an existing link.
+	c.Assert(s.g.UpsertLink(context.TODO(), &graph.Link{
+		URL:      src.URL,
+		Metadata: graph.LinkMetadata{StatusCode: 200},
+	}), gc.IsNil)
+
+	return src, dst
+}
+
+func (s *PersistentInMemoryGraphTestSuite) assertRecovered(c *gc.C, g *InMemoryGraph, src, dst *graph.Link) {
+	got, err := g.FindLinkByURL(context.TODO(), src.URL)
+	c.Assert(err, gc.IsNil)
+	c.Assert(got.ID, gc.Equals, src.ID)
+	c.Assert(got.RetrievedAt.Equal(src.RetrievedAt), gc.Equals, true)
+	c.Assert(got.Metadata.StatusCode, gc.Equals, 200)
+
+	_, err = g.FindLinkByURL(context.TODO(), "https://example.com/removed")
+	c.Assert(err, gc.ErrorMatches, ".*not found")
+
+	it, err := g.InboundEdges(context.TODO(), dst.ID, time.Now())
+	c.Assert(err, gc.IsNil)
+	c.Assert(it.Next(), gc.Equals, true)
+	c.Assert(it.Edge().Src, gc.Equals, src.ID)
+	c.Assert(it.Edge().AnchorText, gc.Equals, "b")
+	c.Assert(it.Close(), gc.IsNil)
+
+	out, err := g.OutDegree(context.TODO(), src.ID)
+	c.Assert(err, gc.IsNil)
+	c.Assert(out, gc.Equals, 1)
+	in, err := g.InDegree(context.TODO(), dst.ID)
+	c.Assert(err, gc.IsNil)
+	c.Assert(in, gc.Equals, 1)
+}
@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/linkgraph/graph"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// statsCounters are kept up to date as links and edges are added and removed
+// so that Stats does not need to scan the graph.
+type statsCounters struct {
+	uncrawledLinks int
+	outDegrees     map[int]int
+	hostLinks      map[string]int
+}
+
+func newStatsCounters() statsCounters {
+	return statsCounters{
+		outDegrees: make(map[int]int),
+		hostLinks:  make(map[string]int),
+	}
+}
+
+// Stats returns a summary of the graph contents.
+func (s *InMemoryGraph) Stats(ctx context.Context) (*graph.Stats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, xerrors.Errorf("stats: %w", err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := &graph.Stats{
+		Links:              len(s.links),
+		Edges:              len(s.edges),
+		UncrawledLinks:     s.stats.uncrawledLinks,
+		OutDegreeHistogram: make(map[int]int, len(s.stats.outDegrees)),
+		HostLinks:          make(map[string]int, len(s.stats.hostLinks)),
+	}
+	for degree, count := range s.stats.outDegrees {
+		stats.OutDegreeHistogram[degree] = count
+	}
+	for host, count := range s.stats.hostLinks {
+		stats.HostLinks[host] = count
+	}
+	return stats, nil
+}
+
+// trackLink accounts for a newly inserted link which has no outbound edges
+// yet. Callers must hold the write lock.
+func (s *InMemoryGraph) trackLink(link *graph.Link) {
+	s.countLink(link, 1)
+	adjustDegreeCount(s.stats.outDegrees, 0, 1)
+}
+
+// untrackLink drops the contribution of a link that is about to be deleted.
+// Callers must hold the write lock.
+func (s *InMemoryGraph) untrackLink(link *graph.Link) {
+	s.countLink(link, -1)
+	adjustDegreeCount(s.stats.outDegrees, len(s.linkEdgeMap[link.ID]), -1)
+}
+
+// countLink adds (delta > 0) or removes (delta < 0) the contribution of link
+// to the per-link counters. Callers must hold the write lock.
+func (s *InMemoryGraph) countLink(link *graph.Link, delta int) {
+	if link.RetrievedAt.IsZero() {
+		s.stats.uncrawledLinks += delta
+	}
+	adjustHostCount(s.stats.hostLinks, graph.LinkHost(link.URL), delta)
+}
+
+// setOutEdges replaces the outbound edge list of the link with the specified
+// ID and updates the out-degree histogram. Callers must hold the write lock.
+func (s *InMemoryGraph) setOutEdges(id uuid.UUID, list edgeList) {
+	adjustDegreeCount(s.stats.outDegrees, len(s.linkEdgeMap[id]), -1)
+	adjustDegreeCount(s.stats.outDegrees, len(list), 1)
+	s.linkEdgeMap[id] = list
+}
+
+// adjustHostCount adds delta to the link counter for host, dropping
+// counters that reach zero.
+func adjustHostCount(counts map[string]int, host string, delta int) {
+	if counts[host] += delta; counts[host] <= 0 {
+		delete(counts, host)
+	}
+}
+
+// adjustDegreeCount adds delta to the link counter for degree, dropping
+// counters that reach zero.
+func adjustDegreeCount(counts map[int]int, degree int, delta int) {
+	if counts[degree] += delta; counts[degree] <= 0 {
+		delete(counts, degree)
+	}
+}
@@ -0,0 +1,219 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// LatencyBuckets are the upper bounds, in seconds, of the call latency
+// histogram buckets.
+var LatencyBuckets = []float64{0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
+// otherErrorLabel is used for errors that do not match any sentinel error.
+const otherErrorLabel = "other"
+
+// SentinelError associates a sentinel error with the label used to report
+// matching errors.
+type SentinelError struct {
+	Label string
+	Err   error
+}
+
+// Recorder collects per-method call counts, error counts, latencies and
+// iterator item counts and renders them in the Prometheus text exposition
+// format.
+type Recorder struct {
+	namespace string
+	sentinels []SentinelError
+
+	mu      sync.Mutex
+	methods map[string]*methodStats
+}
+
+type methodStats struct {
+	calls   uint64
+	errors  map[string]uint64
+	buckets []uint64
+	sum     float64
+	items   uint64
+}
+
+// NewRecorder returns a Recorder whose metric names are prefixed by
+// namespace. Errors are counted under the label of the first sentinel error
+// they match, as reported by xerrors.Is.
+func NewRecorder(namespace string, sentinels ...SentinelError) *Recorder {
+	return &Recorder{
+		namespace: namespace,
+		sentinels: sentinels,
+		methods:   make(map[string]*methodStats),
+	}
+}
+
+// Observe records a call to method that took d to complete and returned err.
+func (r *Recorder) Observe(method string, d time.Duration, err error) {
+	seconds := d.Seconds()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.statsFor(method)
+	stats.calls++
+	stats.sum += seconds
+	for i, le := range LatencyBuckets {
+		if seconds <= le {
+			stats.buckets[i]++
+		}
+	}
+	if err != nil {
+		stats.errors[r.errorLabel(err)]++
+	}
+}
+
+// ObserveSince is a convenience wrapper for calling Observe with the time
+// elapsed since start.
+func (r *Recorder) ObserveSince(method string, start time.Time, err error) {
+	r.Observe(method, time.Since(start), err)
+}
+
+// AddItems records n items yielded by an iterator returned by method.
+func (r *Recorder) AddItems(method string, n uint64) {
+	if n == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	r.statsFor(method).items += n
+	r.mu.Unlock()
+}
+
+// statsFor returns the stats for method, creating them if required. Callers
+// must hold r.mu.
+func (r *Recorder) statsFor(method string) *methodStats {
+	stats := r.methods[method]
+	if stats == nil {
+		stats = &methodStats{
+			errors:  make(map[string]uint64),
+			buckets: make([]uint64, len(LatencyBuckets)),
+		}
+		r.methods[method] = stats
+	}
+	return stats
+}
+
+func (r *Recorder) errorLabel(err error) string {
+	for _, sentinel := range r.sentinels {
+		if xerrors.Is(err, sentinel.Err) {
+			return sentinel.Label
+		}
+	}
+	return otherErrorLabel
+}
+
+// WritePrometheus writes the collected metrics to w using the Prometheus
+// text exposition format.
+func (r *Recorder) WritePrometheus(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	methods := make([]string, 0, len(r.methods))
+	for method := range r.methods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	var sb strings.Builder
+	name := r.namespace + "_calls_total"
+	writeHeader(&sb, name, "counter", "Number of calls per method.")
+	for _, method := range methods {
+		fmt.Fprintf(&sb, "%s{method=%q} %d\n", name, method, r.methods[method].calls)
+	}
+
+	name = r.namespace + "_errors_total"
+	writeHeader(&sb, name, "counter", "Number of failed calls per method and error.")
+	for _, method := range methods {
+		errors := r.methods[method].errors
+		labels := make([]string, 0, len(errors))
+		for label := range errors {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		for _, label := range labels {
+			fmt.Fprintf(&sb, "%s{method=%q,error=%q} %d\n", name, method, label, errors[label])
+		}
+	}
+
+	name = r.namespace + "_call_duration_seconds"
+	writeHeader(&sb, name, "histogram", "Call latency per method.")
+	for _, method := range methods {
+		stats := r.methods[method]
+		for i, le := range LatencyBuckets {
+			fmt.Fprintf(&sb, "%s_bucket{method=%q,le=%q} %d\n", name, method, formatFloat(le), stats.buckets[i])
+		}
+		fmt.Fprintf(&sb, "%s_bucket{method=%q,le=\"+Inf\"} %d\n", name, method, stats.calls)
+		fmt.Fprintf(&sb, "%s_sum{method=%q} %s\n", name, method, formatFloat(stats.sum))
+		fmt.Fprintf(&sb, "%s_count{method=%q} %d\n", name, method, stats.calls)
+	}
+
+	name = r.namespace + "_iterator_items_total"
+	writeHeader(&sb, name, "counter", "Number of items yielded by iterators per method.")
+	for _, method := range methods {
+		if items := r.methods[method].items; items != 0 {
+			fmt.Fprintf(&sb, "%s{method=%q} %d\n", name, method, items)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// ServeHTTP implements http.Handler by writing the collected metrics.
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_ = r.WritePrometheus(w)
+}
+
+func writeHeader(sb *strings.Builder, name, metricType, help string) {
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// ItemCounter counts the items yielded by an iterator and reports them to
+// a Recorder when the iterator is exhausted or closed.
+type ItemCounter struct {
+	recorder *Recorder
+	method   string
+	pending  uint64
+}
+
+// NewItemCounter returns an ItemCounter that reports items for method.
+func NewItemCounter(r *Recorder, method string) *ItemCounter {
+	return &ItemCounter{recorder: r, method: method}
+}
+
+// Count accepts the result of an iterator's Next call and returns it
+// unchanged.
+func (c *ItemCounter) Count(hasNext bool) bool {
+	if hasNext {
+		c.pending++
+	} else {
+		c.Flush()
+	}
+	return hasNext
+}
+
+// Flush reports the items counted since the last call.
+func (c *ItemCounter) Flush() {
+	c.recorder.AddItems(c.method, c.pending)
+	c.pending = 0
+}
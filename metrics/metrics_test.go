@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"golang.org/x/xerrors"
+	gc "gopkg.in/check.v1"
+)
+
+var _ = gc.Suite(new(RecorderTestSuite))
+
+func Test(t *testing.T) { gc.TestingT(t) }
+
+type RecorderTestSuite struct{}
+
+func (s *RecorderTestSuite) TestWritePrometheus(c *gc.C) {
+	errNotFound := xerrors.New("not found")
+	r := NewRecorder("test", SentinelError{Label: "not_found", Err: errNotFound})
+
+	r.Observe("Find", 250*time.Millisecond, nil)
+	r.Observe("Find", 2*time.Second, xerrors.Errorf("find: %w", errNotFound))
+	r.Observe("Find", 500*time.Millisecond, xerrors.New("boom"))
+	counter := NewItemCounter(r, "Iterate")
+	counter.Count(true)
+	counter.Count(true)
+	counter.Count(false)
+	counter.Flush()
+
+	var buf bytes.Buffer
+	c.Assert(r.WritePrometheus(&buf), gc.IsNil)
+	c.Assert(buf.String(), gc.Equals, `# HELP test_calls_total Number of calls per method.
+# TYPE test_calls_total counter
+test_calls_total{method="Find"} 3
+test_calls_total{method="Iterate"} 0
+# HELP test_errors_total Number of failed calls per method and error.
+# TYPE test_errors_total counter
+test_errors_total{method="Find",error="not_found"} 1
+test_errors_total{method="Find",error="other"} 1
+# HELP test_call_duration_seconds Call latency per method.
+# TYPE test_call_duration_seconds histogram
+test_call_duration_seconds_bucket{method="Find",le="0.0005"} 0
+test_call_duration_seconds_bucket{method="Find",le="0.001"} 0
+test_call_duration_seconds_bucket{method="Find",le="0.005"} 0
+test_call_duration_seconds_bucket{method="Find",le="0.01"} 0
+test_call_duration_seconds_bucket{method="Find",le="0.05"} 0
+test_call_duration_seconds_bucket{method="Find",le="0.1"} 0
+test_call_duration_seconds_bucket{method="Find",le="0.5"} 2
+test_call_duration_seconds_bucket{method="Find",le="1"} 2
+test_call_duration_seconds_bucket{method="Find",le="5"} 3
+test_call_duration_seconds_bucket{method="Find",le="+Inf"} 3
+test_call_duration_seconds_sum{method="Find"} 2.75
+test_call_duration_seconds_count{method="Find"} 3
+test_call_duration_seconds_bucket{method="Iterate",le="0.0005"} 0
+test_call_duration_seconds_bucket{method="Iterate",le="0.001"} 0
+test_call_duration_seconds_bucket{method="Iterate",le="0.005"} 0
+test_call_duration_seconds_bucket{method="Iterate",le="0.01"} 0
+test_call_duration_seconds_bucket{method="Iterate",le="0.05"} 0
+test_call_duration_seconds_bucket{method="Iterate",le="0.1"} 0
+test_call_duration_seconds_bucket{method="Iterate",le="0.5"} 0
+test_call_duration_seconds_bucket{method="Iterate",le="1"} 0
+test_call_duration_seconds_bucket{method="Iterate",le="5"} 0
+test_call_duration_seconds_bucket{method="Iterate",le="+Inf"} 0
+test_call_duration_seconds_sum{method="Iterate"} 0
+test_call_duration_seconds_count{method="Iterate"} 0
+# HELP test_iterator_items_total Number of items yielded by iterators per method.
+# TYPE test_iterator_items_total counter
+test_iterator_items_total{method="Iterate"} 2
+`)
+}
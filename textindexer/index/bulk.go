@@ -0,0 +1,277 @@
+package index
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// BulkOpType describes the type of a bulk operation.
+type BulkOpType uint8
+
+const (
+	// BulkOpIndex inserts or updates a document.
+	BulkOpIndex BulkOpType = iota
+
+	// BulkOpUpdateScore updates the PageRank score of a document.
+	BulkOpUpdateScore
+)
+
+// BulkOp is a single operation that is applied as part of a batch.
+type BulkOp struct {
+	Type BulkOpType
+
+	// The document to index for BulkOpIndex operations.
+	Document *Document
+
+	// The link ID and score for BulkOpUpdateScore operations.
+	LinkID uuid.UUID
+	Score  float64
+}
+
+// BulkFailure describes a bulk operation that could not be applied.
+type BulkFailure struct {
+	// The position of the failed operation in the batch.
+	Index int
+
+	LinkID uuid.UUID
+	Err    error
+}
+
+// BulkError is returned when some of the operations in a batch could not
+// be applied. The remaining operations have been applied successfully.
+type BulkError struct {
+	Failures []BulkFailure
+}
+
+// Error implements error.
+func (e *BulkError) Error() string {
+	if len(e.Failures) == 1 {
+		return fmt.Sprintf("bulk: operation for link %s failed: %v", e.Failures[0].LinkID, e.Failures[0].Err)
+	}
+	return fmt.Sprintf("bulk: %d operations failed; first error: %v", len(e.Failures), e.Failures[0].Err)
+}
+
+// BulkIndexer is implemented by indexers that can apply a batch of
+// operations more efficiently than one call per operation.
+type BulkIndexer interface {
+	// BulkIndex applies ops in order. If some of the operations fail, a
+	// *BulkError describing the failures is returned.
+	BulkIndex(ops []BulkOp) error
+}
+
+// ApplyBulk applies ops to idx, using BulkIndex if idx implements
+// BulkIndexer and falling back to one call per operation otherwise.
+func ApplyBulk(idx Indexer, ops []BulkOp) error {
+	if bulkIdx, ok := idx.(BulkIndexer); ok {
+		return bulkIdx.BulkIndex(ops)
+	}
+
+	var bulkErr BulkError
+	for opIdx, op := range ops {
+		var err error
+		switch op.Type {
+		case BulkOpIndex:
+			if op.Document == nil {
+				err = xerrors.Errorf("index: %w", ErrMissingLinkID)
+				break
+			}
+			err = idx.Index(op.Document)
+		case BulkOpUpdateScore:
+			err = idx.UpdateScore(op.LinkID, op.Score)
+		default:
+			err = xerrors.Errorf("unknown bulk operation type %d", op.Type)
+		}
+		if err != nil {
+			bulkErr.Failures = append(bulkErr.Failures, BulkFailure{Index: opIdx, LinkID: op.linkID(), Err: err})
+		}
+	}
+
+	if len(bulkErr.Failures) != 0 {
+		return &bulkErr
+	}
+	return nil
+}
+
+// linkID returns the link ID that op refers to.
+func (op BulkOp) linkID() uuid.UUID {
+	if op.Type == BulkOpIndex && op.Document != nil {
+		return op.Document.LinkID
+	}
+	return op.LinkID
+}
+
+const (
+	defaultBatchSize     = 500
+	defaultFlushInterval = time.Second
+)
+
+// BatcherConfig configures a Batcher.
+type BatcherConfig struct {
+	// The number of buffered operations that triggers a flush. Defaults
+	// to 500.
+	BatchSize int
+
+	// The maximum time an operation is buffered for. Defaults to 1s.
+	FlushInterval time.Duration
+
+	// ErrorHandler is invoked with errors from flushes triggered by
+	// FlushInterval. If not specified, such errors are returned by the
+	// next call to Flush or Close.
+	ErrorHandler func(error)
+}
+
+// Batcher buffers document and score updates and applies them to an
+// Indexer in batches, once either BatchSize operations are buffered or
+// FlushInterval elapses.
+type Batcher struct {
+	idx Indexer
+	cfg BatcherConfig
+
+	mu         sync.Mutex
+	ops        []BulkOp
+	pendingErr error
+	closed     bool
+
+	// flushMu serializes flushes so batches are applied in order.
+	flushMu sync.Mutex
+
+	closeOnce sync.Once
+	closedCh  chan struct{}
+	doneCh    chan struct{}
+}
+
+// NewBatcher returns a Batcher that applies operations to idx.
+func NewBatcher(idx Indexer, cfg BatcherConfig) *Batcher {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+
+	b := &Batcher{
+		idx:      idx,
+		cfg:      cfg,
+		closedCh: make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+	go b.flushPeriodically()
+	return b
+}
+
+// Index buffers doc for indexing. It fails with ErrBatcherClosed once Close
+// has been called.
+func (b *Batcher) Index(doc *Document) error {
+	if doc.LinkID == uuid.Nil {
+		return xerrors.Errorf("index: %w", ErrMissingLinkID)
+	}
+
+	dcopy := new(Document)
+	*dcopy = *doc
+	return b.add(BulkOp{Type: BulkOpIndex, Document: dcopy})
+}
+
+// UpdateScore buffers a PageRank score update. It fails with
+// ErrBatcherClosed once Close has been called.
+func (b *Batcher) UpdateScore(linkID uuid.UUID, score float64) error {
+	return b.add(BulkOp{Type: BulkOpUpdateScore, LinkID: linkID, Score: score})
+}
+
+func (b *Batcher) add(op BulkOp) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrBatcherClosed
+	}
+	b.ops = append(b.ops, op)
+	full := len(b.ops) >= b.cfg.BatchSize
+	b.mu.Unlock()
+
+	if full {
+		return b.Flush()
+	}
+	return nil
+}
+
+// Flush applies all buffered operations. Failures of individual
+// operations are reported via a *BulkError. If an earlier periodic flush
+// failed as well, the returned error wraps the error of this flush and
+// mentions the earlier one.
+func (b *Batcher) Flush() error {
+	err := b.flush()
+
+	b.mu.Lock()
+	pendingErr := b.pendingErr
+	b.pendingErr = nil
+	b.mu.Unlock()
+
+	switch {
+	case err == nil:
+		return pendingErr
+	case pendingErr != nil:
+		return xerrors.Errorf("flush (earlier periodic flush failed: %v): %w", pendingErr, err)
+	default:
+		return err
+	}
+}
+
+func (b *Batcher) flush() error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	ops := b.ops
+	b.ops = nil
+	b.mu.Unlock()
+
+	if len(ops) == 0 {
+		return nil
+	}
+	return ApplyBulk(b.idx, ops)
+}
+
+// Close flushes any buffered operations and stops the periodic flushes.
+// Operations can no longer be buffered after Close has been called.
+func (b *Batcher) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	b.closeOnce.Do(func() { close(b.closedCh) })
+	<-b.doneCh
+	return b.Flush()
+}
+
+func (b *Batcher) flushPeriodically() {
+	defer close(b.doneCh)
+
+	ticker := time.NewTicker(b.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.closedCh:
+			return
+		case <-ticker.C:
+			if err := b.flush(); err != nil {
+				b.reportError(err)
+			}
+		}
+	}
+}
+
+func (b *Batcher) reportError(err error) {
+	if b.cfg.ErrorHandler != nil {
+		b.cfg.ErrorHandler(err)
+		return
+	}
+
+	b.mu.Lock()
+	if b.pendingErr == nil {
+		b.pendingErr = err
+	}
+	b.mu.Unlock()
+}
@@ -0,0 +1,79 @@
+package index
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+	gc "gopkg.in/check.v1"
+)
+
+var _ = gc.Suite(new(BatcherTestSuite))
+
+type BatcherTestSuite struct{}
+
+func (s *BatcherTestSuite) TestAddAfterClose(c *gc.C) {
+	idx := new(recordingIndexer)
+	b := NewBatcher(idx, BatcherConfig{})
+	c.Assert(b.UpdateScore(uuid.New(), 0.5), gc.IsNil)
+	c.Assert(b.Close(), gc.IsNil)
+	c.Assert(idx.scores, gc.HasLen, 1)
+
+	err := b.Index(&Document{LinkID: uuid.New()})
+	c.Assert(xerrors.Is(err, ErrBatcherClosed), gc.Equals, true)
+	err = b.UpdateScore(uuid.New(), 0.5)
+	c.Assert(xerrors.Is(err, ErrBatcherClosed), gc.Equals, true)
+	c.Assert(b.Flush(), gc.IsNil)
+	c.Assert(idx.scores, gc.HasLen, 1)
+}
+
+func (s *BatcherTestSuite) TestFlushReportsPeriodicFlushErrors(c *gc.C) {
+	bgID, fgID := uuid.New(), uuid.New()
+	idx := &recordingIndexer{failIDs: map[uuid.UUID]bool{bgID: true, fgID: true}}
+	b := NewBatcher(idx, BatcherConfig{FlushInterval: 10 * time.Millisecond})
+	defer func() { _ = b.Close() }()
+
+	// Wait for the periodic flush to fail.
+	c.Assert(b.UpdateScore(bgID, 0.5), gc.IsNil)
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		b.mu.Lock()
+		failed := b.pendingErr != nil
+		b.mu.Unlock()
+		if failed {
+			break
+		}
+		c.Assert(time.Now().Before(deadline), gc.Equals, true, gc.Commentf("periodic flush did not fail"))
+		time.Sleep(time.Millisecond)
+	}
+
+	// Both the periodic and the explicit flush failures are reported.
+	c.Assert(b.UpdateScore(fgID, 0.5), gc.IsNil)
+	err := b.Flush()
+	c.Assert(err, gc.ErrorMatches, ".*"+bgID.String()+".*"+fgID.String()+".*")
+	var bulkErr *BulkError
+	c.Assert(xerrors.As(err, &bulkErr), gc.Equals, true)
+	c.Assert(bulkErr.Failures, gc.HasLen, 1)
+	c.Assert(bulkErr.Failures[0].LinkID, gc.Equals, fgID)
+	c.Assert(b.Flush(), gc.IsNil)
+}
+
+// recordingIndexer records the score updates applied to it and fails the
+// ones for the link IDs in failIDs.
+type recordingIndexer struct {
+	Indexer
+
+	mu      sync.Mutex
+	scores  []uuid.UUID
+	failIDs map[uuid.UUID]bool
+}
+
+func (i *recordingIndexer) UpdateScore(linkID uuid.UUID, _ float64) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.failIDs[linkID] {
+		return xerrors.Errorf("update score: %w", ErrNotFound)
+	}
+	i.scores = append(i.scores, linkID)
+	return nil
+}
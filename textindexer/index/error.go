@@ -10,4 +10,16 @@ var (
 	// ErrMissingLinkID is returned when attempting to index a document
 	// that does not specify a valid link ID.
 	ErrMissingLinkID = xerrors.New("document does not provide a valid linkID")
+
+	// ErrEmptyURLPrefix is returned when attempting to delete documents
+	// by an empty URL prefix, which would match every document.
+	ErrEmptyURLPrefix = xerrors.New("empty URL prefix")
+
+	// ErrInvalidQuery is returned when a QueryTypeAdvanced expression
+	// cannot be parsed.
+	ErrInvalidQuery = xerrors.New("invalid query")
+
+	// ErrBatcherClosed is returned when attempting to buffer operations
+	// in a Batcher that has been closed.
+	ErrBatcherClosed = xerrors.New("batcher is closed")
 )
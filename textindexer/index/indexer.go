@@ -11,6 +11,13 @@ type Indexer interface {
 	FindByID(linkID uuid.UUID) (*Document, error)
 	Search(query Query) (Iterator, error)
 	UpdateScore(linkID uuid.UUID, score float64) error
+
+	// Delete removes the document with the specified link ID.
+	Delete(linkID uuid.UUID) error
+
+	// DeleteByURLPrefix removes all documents whose URL starts with prefix
+	// and returns the number of removed documents.
+	DeleteByURLPrefix(prefix string) (uint64, error)
 }
 
 type Document struct {
@@ -29,6 +36,90 @@ type Query struct {
 	Type       QueryType
 	Expression string
 	Offset     uint64
+
+	// Highlight, if not nil, requests highlighted fragments of the
+	// matching documents. They are available via Iterator.Highlights.
+	Highlight *HighlightOptions
+
+	// Filter restricts the results without affecting their relevance
+	// scores.
+	Filter Filter
+}
+
+// Filter restricts search results to documents with matching attributes.
+// Zero values do not restrict the results.
+type Filter struct {
+	// Hosts, if not empty, only matches documents whose URL host is one
+	// of Hosts or one of their subdomains.
+	Hosts []string
+
+	// IndexedAfter and IndexedBefore only match documents indexed strictly
+	// after and before the specified times.
+	IndexedAfter  time.Time
+	IndexedBefore time.Time
+
+	// MinPageRank only matches documents with at least the specified
+	// PageRank score.
+	MinPageRank float64
+}
+
+// IsZero returns true if f does not restrict the results.
+func (f Filter) IsZero() bool {
+	return len(f.Hosts) == 0 && f.IndexedAfter.IsZero() && f.IndexedBefore.IsZero() && f.MinPageRank == 0
+}
+
+// Validate returns an error wrapping ErrInvalidQuery if any of the hosts
+// in f contains wildcard characters.
+func (f Filter) Validate() error {
+	for _, host := range f.Hosts {
+		if err := checkSite(host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The tags that surround matched terms in highlighted fragments.
+const (
+	HighlightPreTag  = "<mark>"
+	HighlightPostTag = "</mark>"
+)
+
+// The default highlighting settings.
+const (
+	DefaultFragmentSize = 100
+	DefaultNumFragments = 3
+)
+
+// HighlightOptions configures the highlighted fragments returned for each
+// search result. Zero values select the defaults.
+type HighlightOptions struct {
+	// The approximate size of each fragment in characters.
+	FragmentSize int
+
+	// The maximum number of fragments per field.
+	NumFragments int
+}
+
+// Sizes returns the fragment size and count, applying defaults for unset
+// values.
+func (o *HighlightOptions) Sizes() (fragmentSize, numFragments int) {
+	fragmentSize, numFragments = o.FragmentSize, o.NumFragments
+	if fragmentSize <= 0 {
+		fragmentSize = DefaultFragmentSize
+	}
+	if numFragments <= 0 {
+		numFragments = DefaultNumFragments
+	}
+	return fragmentSize, numFragments
+}
+
+// Highlights contains HTML-escaped fragments of a search result around the
+// matched terms, which are wrapped in HighlightPreTag and HighlightPostTag.
+// Fields without matches have no fragments.
+type Highlights struct {
+	Title   []string
+	Content []string
 }
 
 type QueryType uint8
@@ -36,6 +127,10 @@ type QueryType uint8
 const (
 	QueryTypeMatch QueryType = iota
 	QueryTypePhrase
+
+	// QueryTypeAdvanced expressions are parsed with ParseQuery and support
+	// field scopes, exclusions, phrases, OR and grouping.
+	QueryTypeAdvanced
 )
 
 type Iterator interface {
@@ -52,6 +147,10 @@ type Iterator interface {
 	// Returns current document
 	Document() *Document
 
+	// Returns highlighted fragments of the current document or nil if
+	// highlighting was not requested
+	Highlights() *Highlights
+
 	// Returns approximate number of search results
 	TotalCount() uint64
 }
@@ -2,6 +2,7 @@ package indextest
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Waqas-Shah-42/Links-R-Us/textindexer/index"
@@ -289,6 +290,371 @@ func (s *SuiteBase) TestUpdateScoreForUnknownDocument(c *gc.C) {
 	c.Assert(doc.PageRank, gc.Equals, 0.5)
 }
 
+// TestDelete verifies that deleted documents can no longer be looked up or
+// searched for.
+func (s *SuiteBase) TestDelete(c *gc.C) {
+	var ids []uuid.UUID
+	for i := 0; i < 3; i++ {
+		doc := &index.Document{
+			LinkID:  uuid.New(),
+			URL:     fmt.Sprintf("http://example.com/%d", i),
+			Title:   "Illustrious examples",
+			Content: "Lorem ipsum dolor",
+		}
+		err := s.idx.Index(doc)
+		c.Assert(err, gc.IsNil)
+		ids = append(ids, doc.LinkID)
+	}
+
+	err := s.idx.Delete(ids[1])
+	c.Assert(err, gc.IsNil)
+
+	_, err = s.idx.FindByID(ids[1])
+	c.Assert(xerrors.Is(err, index.ErrNotFound), gc.Equals, true)
+
+	it, err := s.idx.Search(index.Query{
+		Type:       index.QueryTypeMatch,
+		Expression: "lorem",
+	})
+	c.Assert(err, gc.IsNil)
+	got := iterateDocs(c, it)
+	c.Assert(got, gc.HasLen, 2)
+	for _, id := range got {
+		c.Assert(id, gc.Not(gc.Equals), ids[1])
+	}
+
+	// Delete again and delete unknown
+	err = s.idx.Delete(ids[1])
+	c.Assert(xerrors.Is(err, index.ErrNotFound), gc.Equals, true)
+	err = s.idx.Delete(uuid.New())
+	c.Assert(xerrors.Is(err, index.ErrNotFound), gc.Equals, true)
+}
+
+// TestDeleteByURLPrefix verifies that all documents of a site can be
+// removed at once.
+func (s *SuiteBase) TestDeleteByURLPrefix(c *gc.C) {
+	urls := []string{
+		"http://example.com",
+		"http://example.com/about",
+		"http://example.com/blog/post",
+		"http://example.org/about",
+		"https://example.com",
+	}
+	ids := make(map[string]uuid.UUID)
+	for _, url := range urls {
+		doc := &index.Document{
+			LinkID:  uuid.New(),
+			URL:     url,
+			Title:   "Illustrious examples",
+			Content: "Lorem ipsum dolor",
+		}
+		err := s.idx.Index(doc)
+		c.Assert(err, gc.IsNil)
+		ids[url] = doc.LinkID
+	}
+
+	removed, err := s.idx.DeleteByURLPrefix("http://example.com")
+	c.Assert(err, gc.IsNil)
+	c.Assert(removed, gc.Equals, uint64(3))
+
+	for _, url := range urls {
+		_, err = s.idx.FindByID(ids[url])
+		if strings.HasPrefix(url, "http://example.com") {
+			c.Assert(xerrors.Is(err, index.ErrNotFound), gc.Equals, true, gc.Commentf(url))
+		} else {
+			c.Assert(err, gc.IsNil, gc.Commentf(url))
+		}
+	}
+
+	// Nothing left to remove
+	removed, err = s.idx.DeleteByURLPrefix("http://example.com")
+	c.Assert(err, gc.IsNil)
+	c.Assert(removed, gc.Equals, uint64(0))
+
+	// Refuse to remove everything
+	_, err = s.idx.DeleteByURLPrefix("")
+	c.Assert(xerrors.Is(err, index.ErrEmptyURLPrefix), gc.Equals, true)
+}
+
+// TestBulkIndex verifies that batches of document and score updates are
+// applied in order and that failed operations are reported.
+func (s *SuiteBase) TestBulkIndex(c *gc.C) {
+	bulkIdx, ok := s.idx.(index.BulkIndexer)
+	if !ok {
+		c.Skip("indexer does not implement index.BulkIndexer")
+	}
+
+	docs := []*index.Document{
+		{LinkID: uuid.New(), URL: "http://example.com/0", Title: "Illustrious examples", Content: "Lorem ipsum dolor"},
+		{LinkID: uuid.New(), URL: "http://example.com/1", Title: "Illustrious examples", Content: "Lorem ipsum dolor"},
+	}
+	scoreOnlyID := uuid.New()
+	err := bulkIdx.BulkIndex([]index.BulkOp{
+		{Type: index.BulkOpIndex, Document: docs[0]},
+		{Type: index.BulkOpUpdateScore, LinkID: docs[0].LinkID, Score: 0.5},
+		{Type: index.BulkOpIndex, Document: &index.Document{URL: "http://example.com/no-id"}},
+		{Type: index.BulkOpIndex, Document: docs[1]},
+		{Type: index.BulkOpUpdateScore, LinkID: scoreOnlyID, Score: 0.25},
+		// Re-indexing must not override the score set above.
+		{Type: index.BulkOpIndex, Document: &index.Document{LinkID: docs[0].LinkID, URL: docs[0].URL, Title: "A more exciting title"}},
+	})
+	var bulkErr *index.BulkError
+	c.Assert(xerrors.As(err, &bulkErr), gc.Equals, true, gc.Commentf("expected a *BulkError; got %v", err))
+	c.Assert(bulkErr.Failures, gc.HasLen, 1)
+	c.Assert(bulkErr.Failures[0].Index, gc.Equals, 2)
+	c.Assert(xerrors.Is(bulkErr.Failures[0].Err, index.ErrMissingLinkID), gc.Equals, true)
+
+	got, err := s.idx.FindByID(docs[0].LinkID)
+	c.Assert(err, gc.IsNil)
+	c.Assert(got.Title, gc.Equals, "A more exciting title")
+	c.Assert(got.PageRank, gc.Equals, 0.5)
+
+	got, err = s.idx.FindByID(docs[1].LinkID)
+	c.Assert(err, gc.IsNil)
+	c.Assert(got.URL, gc.Equals, docs[1].URL)
+
+	got, err = s.idx.FindByID(scoreOnlyID)
+	c.Assert(err, gc.IsNil)
+	c.Assert(got.PageRank, gc.Equals, 0.25)
+}
+
+// TestBatcher verifies that a Batcher flushes buffered operations once the
+// batch size or flush interval is reached.
+func (s *SuiteBase) TestBatcher(c *gc.C) {
+	b := index.NewBatcher(s.idx, index.BatcherConfig{BatchSize: 3, FlushInterval: time.Hour})
+	defer func() { c.Assert(b.Close(), gc.IsNil) }()
+
+	var ids []uuid.UUID
+	for i := 0; i < 3; i++ {
+		doc := &index.Document{
+			LinkID:  uuid.New(),
+			URL:     fmt.Sprintf("http://example.com/%d", i),
+			Title:   "Illustrious examples",
+			Content: "Lorem ipsum dolor",
+		}
+		ids = append(ids, doc.LinkID)
+		c.Assert(b.Index(doc), gc.IsNil)
+
+		// Nothing is flushed until the batch is full.
+		if i < 2 {
+			_, err := s.idx.FindByID(doc.LinkID)
+			c.Assert(xerrors.Is(err, index.ErrNotFound), gc.Equals, true)
+		}
+	}
+	for _, id := range ids {
+		_, err := s.idx.FindByID(id)
+		c.Assert(err, gc.IsNil)
+	}
+
+	// Explicit flushes apply partial batches.
+	c.Assert(b.UpdateScore(ids[0], 0.75), gc.IsNil)
+	c.Assert(b.Flush(), gc.IsNil)
+	got, err := s.idx.FindByID(ids[0])
+	c.Assert(err, gc.IsNil)
+	c.Assert(got.PageRank, gc.Equals, 0.75)
+
+	err = b.Index(&index.Document{URL: "http://example.com/no-id"})
+	c.Assert(xerrors.Is(err, index.ErrMissingLinkID), gc.Equals, true)
+}
+
+// TestBatcherFlushInterval verifies that buffered operations are flushed
+// periodically.
+func (s *SuiteBase) TestBatcherFlushInterval(c *gc.C) {
+	b := index.NewBatcher(s.idx, index.BatcherConfig{BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+	defer func() { c.Assert(b.Close(), gc.IsNil) }()
+
+	linkID := uuid.New()
+	c.Assert(b.UpdateScore(linkID, 0.5), gc.IsNil)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := s.idx.FindByID(linkID); err == nil {
+			break
+		}
+		c.Assert(time.Now().Before(deadline), gc.Equals, true, gc.Commentf("buffered operation was not flushed"))
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestSearchHighlights verifies that search results carry highlighted
+// fragments around the matched terms when requested.
+func (s *SuiteBase) TestSearchHighlights(c *gc.C) {
+	doc := &index.Document{
+		LinkID:  uuid.New(),
+		URL:     "http://example.com",
+		Title:   "Ovidius",
+		Content: strings.Repeat("lorem ipsum dolor sit amet ", 20) + "poeta in terra pontica " + strings.Repeat("lorem ipsum dolor sit amet ", 20),
+	}
+	c.Assert(s.idx.Index(doc), gc.IsNil)
+
+	it, err := s.idx.Search(index.Query{
+		Type:       index.QueryTypeMatch,
+		Expression: "poeta",
+		Highlight:  &index.HighlightOptions{FragmentSize: 40, NumFragments: 2},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(it.Next(), gc.Equals, true)
+	highlights := it.Highlights()
+	c.Assert(highlights, gc.NotNil)
+	c.Assert(highlights.Title, gc.HasLen, 0)
+	c.Assert(highlights.Content, gc.HasLen, 1)
+	c.Assert(strings.Contains(highlights.Content[0], index.HighlightPreTag+"poeta"+index.HighlightPostTag), gc.Equals, true, gc.Commentf(highlights.Content[0]))
+	c.Assert(len(highlights.Content[0]) < len(doc.Content), gc.Equals, true)
+	c.Assert(it.Next(), gc.Equals, false)
+	c.Assert(it.Close(), gc.IsNil)
+
+	// Matches in both fields
+	it, err = s.idx.Search(index.Query{
+		Type:       index.QueryTypeMatch,
+		Expression: "ovidius poeta",
+		Highlight:  &index.HighlightOptions{},
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(it.Next(), gc.Equals, true)
+	c.Assert(it.Highlights().Title, gc.DeepEquals, []string{index.HighlightPreTag + "Ovidius" + index.HighlightPostTag})
+	c.Assert(it.Highlights().Content, gc.HasLen, 1)
+	c.Assert(it.Close(), gc.IsNil)
+
+	// Highlighting not requested
+	it, err = s.idx.Search(index.Query{
+		Type:       index.QueryTypeMatch,
+		Expression: "poeta",
+	})
+	c.Assert(err, gc.IsNil)
+	c.Assert(it.Next(), gc.Equals, true)
+	c.Assert(it.Highlights(), gc.IsNil)
+	c.Assert(it.Close(), gc.IsNil)
+}
+
+// TestAdvancedSearch verifies the query language supported by
+// QueryTypeAdvanced queries.
+func (s *SuiteBase) TestAdvancedSearch(c *gc.C) {
+	docs := []*index.Document{
+		{URL: "http://example.com/go", Title: "Golang tips", Content: "Concurrency patterns in go"},
+		{URL: "http://blog.example.com/rust", Title: "Rust tips", Content: "Ownership and borrowing"},
+		{URL: "http://other.org/go", Title: "Go modules", Content: "Versioning with go modules and golang tooling"},
+		{URL: "http://notexample.com/compare", Title: "Rust and golang", Content: "Comparing languages"},
+	}
+	for i, doc := range docs {
+		doc.LinkID = uuid.New()
+		c.Assert(s.idx.Index(doc), gc.IsNil)
+
+		// Use scores that are large enough to determine the result order.
+		c.Assert(s.idx.UpdateScore(doc.LinkID, float64(100*(len(docs)-i))), gc.IsNil)
+	}
+
+	specs := []struct {
+		expr string
+		exp  []int
+	}{
+		{expr: "title:tips", exp: []int{0, 1}},
+		{expr: "golang -rust", exp: []int{0, 2}},
+		{expr: "site:example.com", exp: []int{0, 1}},
+		{expr: "-site:example.com", exp: []int{2, 3}},
+		{expr: `"go modules" OR title:rust`, exp: []int{1, 2, 3}},
+		{expr: "(title:rust OR title:golang) -site:example.com", exp: []int{3}},
+		{expr: `content:"go modules"`, exp: []int{2}},
+		{expr: "golang AND NOT (tips OR comparing)", exp: []int{2}},
+	}
+	for _, spec := range specs {
+		it, err := s.idx.Search(index.Query{
+			Type:       index.QueryTypeAdvanced,
+			Expression: spec.expr,
+		})
+		c.Assert(err, gc.IsNil, gc.Commentf(spec.expr))
+
+		var expIDs []uuid.UUID
+		for _, docIndex := range spec.exp {
+			expIDs = append(expIDs, docs[docIndex].LinkID)
+		}
+		c.Assert(iterateDocs(c, it), gc.DeepEquals, expIDs, gc.Commentf(spec.expr))
+	}
+
+	_, err := s.idx.Search(index.Query{
+		Type:       index.QueryTypeAdvanced,
+		Expression: "(golang",
+	})
+	c.Assert(xerrors.Is(err, index.ErrInvalidQuery), gc.Equals, true)
+}
+
+// TestSearchFilters verifies that search results can be restricted by host,
+// indexing time and PageRank score.
+func (s *SuiteBase) TestSearchFilters(c *gc.C) {
+	docs := []*index.Document{
+		{URL: "http://example.com/a"},
+		{URL: "http://blog.example.com/b"},
+		{URL: "http://other.org/c"},
+	}
+	scores := []float64{0.9, 0.5, 0.1}
+	indexedAt := make([]time.Time, len(docs))
+	for i, doc := range docs {
+		doc.LinkID = uuid.New()
+		doc.Title = "Illustrious examples"
+		doc.Content = "Lorem ipsum dolor"
+		doc.IndexedAt = time.Now().UTC()
+		c.Assert(s.idx.Index(doc), gc.IsNil)
+		c.Assert(s.idx.UpdateScore(doc.LinkID, scores[i]), gc.IsNil)
+
+		// Look up the indexing time as recorded by the indexer.
+		got, err := s.idx.FindByID(doc.LinkID)
+		c.Assert(err, gc.IsNil)
+		indexedAt[i] = got.IndexedAt
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	specs := []struct {
+		descr  string
+		qtype  index.QueryType
+		expr   string
+		filter index.Filter
+		exp    []int
+	}{
+		{descr: "no filter", filter: index.Filter{}, exp: []int{0, 1, 2}},
+		{descr: "host and subdomains", filter: index.Filter{Hosts: []string{"Example.COM"}}, exp: []int{0, 1}},
+		{descr: "any of multiple hosts", filter: index.Filter{Hosts: []string{"other.org", "blog.example.com"}}, exp: []int{1, 2}},
+		{descr: "indexed after", filter: index.Filter{IndexedAfter: indexedAt[0]}, exp: []int{1, 2}},
+		{descr: "indexed before", filter: index.Filter{IndexedBefore: indexedAt[2]}, exp: []int{0, 1}},
+		{descr: "indexed between", filter: index.Filter{IndexedAfter: indexedAt[0], IndexedBefore: indexedAt[2]}, exp: []int{1}},
+		{descr: "min PageRank", filter: index.Filter{MinPageRank: 0.5}, exp: []int{0, 1}},
+		{descr: "host and min PageRank", filter: index.Filter{Hosts: []string{"example.com"}, MinPageRank: 0.6}, exp: []int{0}},
+		{
+			descr:  "advanced query",
+			qtype:  index.QueryTypeAdvanced,
+			expr:   "lorem -site:blog.example.com",
+			filter: index.Filter{MinPageRank: 0.05},
+			exp:    []int{0, 2},
+		},
+	}
+	for _, spec := range specs {
+		expr := spec.expr
+		if expr == "" {
+			expr = "lorem"
+		}
+		it, err := s.idx.Search(index.Query{
+			Type:       spec.qtype,
+			Expression: expr,
+			Filter:     spec.filter,
+		})
+		c.Assert(err, gc.IsNil, gc.Commentf(spec.descr))
+
+		var expIDs []uuid.UUID
+		for _, docIndex := range spec.exp {
+			expIDs = append(expIDs, docs[docIndex].LinkID)
+		}
+		c.Assert(iterateDocs(c, it), gc.DeepEquals, expIDs, gc.Commentf(spec.descr))
+	}
+
+	// Hosts are matched literally, so wildcards are rejected as they are
+	// for site: terms.
+	for _, host := range []string{"*.com", "exampl?.com"} {
+		_, err := s.idx.Search(index.Query{
+			Expression: "lorem",
+			Filter:     index.Filter{Hosts: []string{"other.org", host}},
+		})
+		c.Assert(xerrors.Is(err, index.ErrInvalidQuery), gc.Equals, true, gc.Commentf(host))
+	}
+}
+
 func iterateDocs(c *gc.C, it index.Iterator) []uuid.UUID {
 	var seen []uuid.UUID
 	for it.Next() {
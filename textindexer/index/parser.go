@@ -0,0 +1,357 @@
+package index
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+
+	"golang.org/x/xerrors"
+)
+
+// The fields that terms and phrases can be scoped to, e.g. title:golang.
+const (
+	FieldTitle   = "title"
+	FieldContent = "content"
+	FieldSite    = "site"
+)
+
+// Node is a node of the backend-neutral query AST produced by ParseQuery.
+type Node interface {
+	isNode()
+}
+
+// TermNode matches documents containing Term. An empty Field matches both
+// the title and the content; FieldSite matches documents whose URL host is
+// Term or one of its subdomains.
+type TermNode struct {
+	Field string
+	Term  string
+}
+
+// PhraseNode matches documents containing the exact Phrase. An empty Field
+// matches both the title and the content.
+type PhraseNode struct {
+	Field  string
+	Phrase string
+}
+
+// AndNode matches documents that match all of its children.
+type AndNode struct {
+	Children []Node
+}
+
+// OrNode matches documents that match any of its children.
+type OrNode struct {
+	Children []Node
+}
+
+// NotNode matches documents that do not match Child.
+type NotNode struct {
+	Child Node
+}
+
+func (*TermNode) isNode()   {}
+func (*PhraseNode) isNode() {}
+func (*AndNode) isNode()    {}
+func (*OrNode) isNode()     {}
+func (*NotNode) isNode()    {}
+
+// URLHost returns the lower-cased host of rawURL or an empty string if it
+// cannot be parsed. It is indexed for matching FieldSite queries.
+func URLHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// ParseQuery parses expr into a query AST. The query language supports:
+//   - terms and "exact phrases"
+//   - field-scoped terms and phrases: title:golang, content:"go modules",
+//     site:example.com
+//   - exclusion via -term or NOT term
+//   - disjunction via OR; adjacent terms (or terms joined by AND) must all
+//     match
+//   - grouping with parentheses
+func ParseQuery(expr string) (Node, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, xerrors.Errorf("parse query: %w", err)
+	}
+
+	p := &parser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, xerrors.Errorf("parse query: %w", err)
+	}
+	if p.peek().typ != tokenEOF {
+		return nil, xerrors.Errorf("parse query: unexpected %s: %w", p.peek(), ErrInvalidQuery)
+	}
+	return node, nil
+}
+
+type tokenType uint8
+
+const (
+	tokenEOF tokenType = iota
+	tokenTerm
+	tokenPhrase
+	tokenOr
+	tokenAnd
+	tokenNot
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	typ   tokenType
+	field string
+	value string
+}
+
+func (t token) String() string {
+	switch t.typ {
+	case tokenEOF:
+		return "end of query"
+	case tokenLParen:
+		return `"("`
+	case tokenRParen:
+		return `")"`
+	case tokenOr:
+		return "OR"
+	case tokenAnd:
+		return "AND"
+	case tokenNot:
+		return "negation"
+	default:
+		return `"` + t.value + `"`
+	}
+}
+
+// tokenize splits expr into tokens.
+func tokenize(expr string) ([]token, error) {
+	var (
+		tokens []token
+		runes  = []rune(expr)
+	)
+	for pos := 0; pos < len(runes); {
+		r := runes[pos]
+		switch {
+		case unicode.IsSpace(r):
+			pos++
+		case r == '(':
+			tokens = append(tokens, token{typ: tokenLParen})
+			pos++
+		case r == ')':
+			tokens = append(tokens, token{typ: tokenRParen})
+			pos++
+		case r == '-' && pos+1 < len(runes) && !unicode.IsSpace(runes[pos+1]):
+			tokens = append(tokens, token{typ: tokenNot})
+			pos++
+		case r == '"':
+			phrase, next, err := readPhrase(runes, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{typ: tokenPhrase, value: phrase})
+			pos = next
+		default:
+			start := pos
+			for pos < len(runes) && !unicode.IsSpace(runes[pos]) && !strings.ContainsRune(`()"`, runes[pos]) {
+				pos++
+			}
+			word := string(runes[start:pos])
+
+			switch word {
+			case "OR":
+				tokens = append(tokens, token{typ: tokenOr})
+				continue
+			case "AND":
+				tokens = append(tokens, token{typ: tokenAnd})
+				continue
+			case "NOT":
+				tokens = append(tokens, token{typ: tokenNot})
+				continue
+			}
+
+			field, value := splitField(word)
+			if field == "" {
+				tokens = append(tokens, token{typ: tokenTerm, value: word})
+				continue
+			}
+
+			// A field may be followed by a phrase, e.g. title:"foo bar".
+			if value == "" {
+				if pos >= len(runes) || runes[pos] != '"' {
+					return nil, xerrors.Errorf("missing value for field %q: %w", field, ErrInvalidQuery)
+				}
+				phrase, next, err := readPhrase(runes, pos)
+				if err != nil {
+					return nil, err
+				}
+				if field == FieldSite {
+					return nil, xerrors.Errorf("field %q does not support phrases: %w", field, ErrInvalidQuery)
+				}
+				tokens = append(tokens, token{typ: tokenPhrase, field: field, value: phrase})
+				pos = next
+				continue
+			}
+
+			if field == FieldSite {
+				if err := checkSite(value); err != nil {
+					return nil, err
+				}
+				value = strings.ToLower(value)
+			}
+			tokens = append(tokens, token{typ: tokenTerm, field: field, value: value})
+		}
+	}
+	return append(tokens, token{typ: tokenEOF}), nil
+}
+
+// checkSite returns an error if host contains wildcard characters, which
+// site filters do not support.
+func checkSite(host string) error {
+	if strings.ContainsAny(host, "*?") {
+		return xerrors.Errorf("invalid site %q: %w", host, ErrInvalidQuery)
+	}
+	return nil
+}
+
+// splitField splits word into a field and a value if word is scoped to a
+// known field. Words with other prefixes, such as URLs, are not split.
+func splitField(word string) (field, value string) {
+	sep := strings.IndexByte(word, ':')
+	if sep == -1 {
+		return "", word
+	}
+
+	switch field = strings.ToLower(word[:sep]); field {
+	case FieldTitle, FieldContent, FieldSite:
+		return field, word[sep+1:]
+	default:
+		return "", word
+	}
+}
+
+// readPhrase reads the quoted phrase that starts at runes[pos] and returns
+// it along with the position following the closing quote.
+func readPhrase(runes []rune, pos int) (string, int, error) {
+	end := pos + 1
+	for end < len(runes) && runes[end] != '"' {
+		end++
+	}
+	if end >= len(runes) {
+		return "", 0, xerrors.Errorf("unterminated phrase: %w", ErrInvalidQuery)
+	}
+
+	phrase := strings.TrimSpace(string(runes[pos+1 : end]))
+	if phrase == "" {
+		return "", 0, xerrors.Errorf("empty phrase: %w", ErrInvalidQuery)
+	}
+	return phrase, end + 1, nil
+}
+
+// parser is a recursive descent parser for the query language. Operator
+// precedence from lowest to highest is OR, AND and negation.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.typ != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (Node, error) {
+	node, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	children := []Node{node}
+	for p.peek().typ == tokenOr {
+		p.next()
+		if node, err = p.parseAnd(); err != nil {
+			return nil, err
+		}
+		children = append(children, node)
+	}
+
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return &OrNode{Children: children}, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	var children []Node
+	for {
+		switch p.peek().typ {
+		case tokenEOF, tokenOr, tokenRParen:
+			if len(children) == 0 {
+				return nil, xerrors.Errorf("unexpected %s: %w", p.peek(), ErrInvalidQuery)
+			}
+			if len(children) == 1 {
+				return children[0], nil
+			}
+			return &AndNode{Children: children}, nil
+		case tokenAnd:
+			if len(children) == 0 {
+				return nil, xerrors.Errorf("unexpected %s: %w", p.peek(), ErrInvalidQuery)
+			}
+			p.next()
+			if t := p.peek().typ; t == tokenEOF || t == tokenOr || t == tokenAnd || t == tokenRParen {
+				return nil, xerrors.Errorf("unexpected %s: %w", p.peek(), ErrInvalidQuery)
+			}
+		}
+
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, node)
+	}
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	if p.peek().typ == tokenNot {
+		p.next()
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &NotNode{Child: child}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	t := p.next()
+	switch t.typ {
+	case tokenTerm:
+		return &TermNode{Field: t.field, Term: t.value}, nil
+	case tokenPhrase:
+		return &PhraseNode{Field: t.field, Phrase: t.value}, nil
+	case tokenLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().typ != tokenRParen {
+			return nil, xerrors.Errorf("missing closing parenthesis: %w", ErrInvalidQuery)
+		}
+		return node, nil
+	default:
+		return nil, xerrors.Errorf("unexpected %s: %w", t, ErrInvalidQuery)
+	}
+}
@@ -0,0 +1,112 @@
+package index
+
+import (
+	"testing"
+
+	"golang.org/x/xerrors"
+	gc "gopkg.in/check.v1"
+)
+
+var _ = gc.Suite(new(ParserTestSuite))
+
+func Test(t *testing.T) { gc.TestingT(t) }
+
+type ParserTestSuite struct{}
+
+func (s *ParserTestSuite) TestParseQuery(c *gc.C) {
+	specs := []struct {
+		expr string
+		exp  Node
+	}{
+		{
+			expr: "golang",
+			exp:  &TermNode{Term: "golang"},
+		},
+		{
+			expr: `golang "exact phrase"`,
+			exp: &AndNode{Children: []Node{
+				&TermNode{Term: "golang"},
+				&PhraseNode{Phrase: "exact phrase"},
+			}},
+		},
+		{
+			expr: `title:golang Content:"go modules" site:Example.COM`,
+			exp: &AndNode{Children: []Node{
+				&TermNode{Field: FieldTitle, Term: "golang"},
+				&PhraseNode{Field: FieldContent, Phrase: "go modules"},
+				&TermNode{Field: FieldSite, Term: "example.com"},
+			}},
+		},
+		{
+			expr: "golang -rust NOT site:example.com",
+			exp: &AndNode{Children: []Node{
+				&TermNode{Term: "golang"},
+				&NotNode{Child: &TermNode{Term: "rust"}},
+				&NotNode{Child: &TermNode{Field: FieldSite, Term: "example.com"}},
+			}},
+		},
+		{
+			// AND binds tighter than OR.
+			expr: "a b OR c AND d",
+			exp: &OrNode{Children: []Node{
+				&AndNode{Children: []Node{&TermNode{Term: "a"}, &TermNode{Term: "b"}}},
+				&AndNode{Children: []Node{&TermNode{Term: "c"}, &TermNode{Term: "d"}}},
+			}},
+		},
+		{
+			expr: "golang -(title:rust OR title:\"c++\")",
+			exp: &AndNode{Children: []Node{
+				&TermNode{Term: "golang"},
+				&NotNode{Child: &OrNode{Children: []Node{
+					&TermNode{Field: FieldTitle, Term: "rust"},
+					&PhraseNode{Field: FieldTitle, Phrase: "c++"},
+				}}},
+			}},
+		},
+		{
+			// Unknown field prefixes, dashes within words and lower-case
+			// operators are treated as terms.
+			expr: "http://example.com well-known or",
+			exp: &AndNode{Children: []Node{
+				&TermNode{Term: "http://example.com"},
+				&TermNode{Term: "well-known"},
+				&TermNode{Term: "or"},
+			}},
+		},
+	}
+
+	for specIndex, spec := range specs {
+		got, err := ParseQuery(spec.expr)
+		c.Assert(err, gc.IsNil, gc.Commentf("spec %d: %q", specIndex, spec.expr))
+		c.Assert(got, gc.DeepEquals, spec.exp, gc.Commentf("spec %d: %q", specIndex, spec.expr))
+	}
+}
+
+func (s *ParserTestSuite) TestParseInvalidQuery(c *gc.C) {
+	exprs := []string{
+		"",
+		"   ",
+		`"unterminated`,
+		`""`,
+		"(golang",
+		"golang)",
+		"golang OR",
+		"OR golang",
+		"golang AND",
+		"golang NOT",
+		"golang -)",
+		"title:",
+		`site:"example com"`,
+		"site:*.example.com",
+	}
+
+	for _, expr := range exprs {
+		_, err := ParseQuery(expr)
+		c.Assert(xerrors.Is(err, ErrInvalidQuery), gc.Equals, true, gc.Commentf("%q: %v", expr, err))
+	}
+}
+
+func (s *ParserTestSuite) TestURLHost(c *gc.C) {
+	c.Assert(URLHost("https://WWW.Example.com:8080/foo"), gc.Equals, "www.example.com")
+	c.Assert(URLHost("://invalid"), gc.Equals, "")
+}
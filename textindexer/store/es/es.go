@@ -5,6 +5,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -49,7 +51,8 @@ type esTotal struct {
 }
 
 type esHitWrapper struct {
-	DocSource esDoc `json:"_source"`
+	DocSource esDoc               `json:"_source"`
+	Highlight map[string][]string `json:"highlight,omitempty"`
 }
 
 type esDoc struct {
@@ -65,6 +68,33 @@ type esUpdateRes struct {
 	Result string `json:"result"`
 }
 
+type esBulkRes struct {
+	Errors bool         `json:"errors"`
+	Items  []esBulkItem `json:"items"`
+}
+
+type esBulkItem struct {
+	Update esBulkItemRes `json:"update"`
+}
+
+type esBulkItemRes struct {
+	Status int      `json:"status"`
+	Error  *esError `json:"error,omitempty"`
+}
+
+type esBulkAction struct {
+	Update esBulkActionMeta `json:"update"`
+}
+
+type esBulkActionMeta struct {
+	Index string `json:"_index"`
+	ID    string `json:"_id"`
+}
+
+type esDeleteByQueryRes struct {
+	Deleted uint64 `json:"deleted"`
+}
+
 type esErrorRes struct {
 	Error esError `json:"error"`
 }
@@ -78,14 +108,19 @@ func (e esError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Type, e.Reason)
 }
 
-// Compile-time check to ensure ElasticSearchIndexer implements Indexer.
-var _ index.Indexer = (*ElasticSearchIndexer)(nil)
+// Compile-time check to ensure ElasticSearchIndexer implements Indexer and
+// BulkIndexer.
+var (
+	_ index.Indexer     = (*ElasticSearchIndexer)(nil)
+	_ index.BulkIndexer = (*ElasticSearchIndexer)(nil)
+)
 
 // ElasticSearchIndexer is an Indexer implementation that uses an elastic search
 // instance to catalogue and search documents.
 type ElasticSearchIndexer struct {
-	es         *elasticsearch.Client
-	refreshOpt func(*esapi.UpdateRequest)
+	es          *elasticsearch.Client
+	refreshOpt  func(*esapi.UpdateRequest)
+	syncUpdates bool
 }
 
 // NewElasticSearchIndexer creates a text indexer that uses an in-memory
@@ -109,8 +144,9 @@ func NewElasticSearchIndexer(esNodes []string, syncUpdates bool) (*ElasticSearch
 	}
 
 	return &ElasticSearchIndexer{
-		es:         es,
-		refreshOpt: refreshOpt,
+		es:          es,
+		refreshOpt:  refreshOpt,
+		syncUpdates: syncUpdates,
 	}, nil
 }
 
@@ -177,24 +213,28 @@ func (i *ElasticSearchIndexer) FindByID(linkID uuid.UUID) (*index.Document, erro
 // Search the index for a particular query and return back a result
 // iterator.
 func (i *ElasticSearchIndexer) Search(q index.Query) (index.Iterator, error) {
-	var qtype string
+	if err := q.Filter.Validate(); err != nil {
+		return nil, xerrors.Errorf("search: %w", err)
+	}
+
+	var textQuery map[string]interface{}
 	switch q.Type {
 	case index.QueryTypePhrase:
-		qtype = "phrase"
+		textQuery = matchQuery("phrase", q.Expression, esFields[""])
+	case index.QueryTypeAdvanced:
+		node, err := index.ParseQuery(q.Expression)
+		if err != nil {
+			return nil, xerrors.Errorf("search: %w", err)
+		}
+		textQuery = esQuery(node)
 	default:
-		qtype = "best_fields"
+		textQuery = matchQuery("best_fields", q.Expression, esFields[""])
 	}
 
 	query := map[string]interface{}{
 		"query": map[string]interface{}{
 			"function_score": map[string]interface{}{
-				"query": map[string]interface{}{
-					"multi_match": map[string]interface{}{
-						"type":   qtype,
-						"query":  q.Expression,
-						"fields": []string{"Title", "Content"},
-					},
-				},
+				"query": filteredQuery(textQuery, q.Filter),
 				"script_score": map[string]interface{}{
 					"script": map[string]interface{}{
 						"source": "_score + doc['PageRank'].value",
@@ -205,6 +245,22 @@ func (i *ElasticSearchIndexer) Search(q index.Query) (index.Iterator, error) {
 		"from": q.Offset,
 		"size": batchSize,
 	}
+	if q.Highlight != nil {
+		fragmentSize, numFragments := q.Highlight.Sizes()
+		field := map[string]interface{}{
+			"fragment_size":       fragmentSize,
+			"number_of_fragments": numFragments,
+		}
+		query["highlight"] = map[string]interface{}{
+			"pre_tags":  []string{index.HighlightPreTag},
+			"post_tags": []string{index.HighlightPostTag},
+			"encoder":   "html",
+			"fields": map[string]interface{}{
+				"Title":   field,
+				"Content": field,
+			},
+		}
+	}
 
 	searchRes, err := runSearch(i.es, query)
 	if err != nil {
@@ -243,6 +299,160 @@ func (i *ElasticSearchIndexer) UpdateScore(linkID uuid.UUID, score float64) erro
 	return nil
 }
 
+// BulkIndex applies ops through a single request to the _bulk API.
+func (i *ElasticSearchIndexer) BulkIndex(ops []index.BulkOp) error {
+	var (
+		buf     bytes.Buffer
+		enc     = json.NewEncoder(&buf)
+		bulkErr index.BulkError
+		sentOps []int
+		sentIDs []uuid.UUID
+	)
+	for opIdx, op := range ops {
+		var (
+			linkID uuid.UUID
+			doc    interface{}
+		)
+		switch op.Type {
+		case index.BulkOpIndex:
+			if op.Document == nil || op.Document.LinkID == uuid.Nil {
+				bulkErr.Failures = append(bulkErr.Failures, index.BulkFailure{
+					Index: opIdx,
+					Err:   xerrors.Errorf("index: %w", index.ErrMissingLinkID),
+				})
+				continue
+			}
+			linkID, doc = op.Document.LinkID, makeEsDoc(op.Document)
+		case index.BulkOpUpdateScore:
+			linkID = op.LinkID
+			doc = map[string]interface{}{
+				"LinkID":   op.LinkID.String(),
+				"PageRank": op.Score,
+			}
+		default:
+			bulkErr.Failures = append(bulkErr.Failures, index.BulkFailure{
+				Index:  opIdx,
+				LinkID: op.LinkID,
+				Err:    xerrors.Errorf("unknown bulk operation type %d", op.Type),
+			})
+			continue
+		}
+
+		action := esBulkAction{Update: esBulkActionMeta{Index: indexName, ID: linkID.String()}}
+		update := map[string]interface{}{
+			"doc":           doc,
+			"doc_as_upsert": true,
+		}
+		if err := enc.Encode(action); err != nil {
+			return xerrors.Errorf("bulk index: %w", err)
+		}
+		if err := enc.Encode(update); err != nil {
+			return xerrors.Errorf("bulk index: %w", err)
+		}
+		sentOps = append(sentOps, opIdx)
+		sentIDs = append(sentIDs, linkID)
+	}
+
+	if len(sentOps) != 0 {
+		refresh := "false"
+		if i.syncUpdates {
+			refresh = "true"
+		}
+
+		res, err := i.es.Bulk(&buf, i.es.Bulk.WithRefresh(refresh))
+		if err != nil {
+			return xerrors.Errorf("bulk index: %w", err)
+		}
+
+		var bulkRes esBulkRes
+		if err = unmarshalResponse(res, &bulkRes); err != nil {
+			return xerrors.Errorf("bulk index: %w", err)
+		} else if len(bulkRes.Items) != len(sentOps) {
+			return xerrors.Errorf("bulk index: expected %d results; got %d", len(sentOps), len(bulkRes.Items))
+		}
+
+		for itemIdx, item := range bulkRes.Items {
+			if item.Update.Error == nil {
+				continue
+			}
+			bulkErr.Failures = append(bulkErr.Failures, index.BulkFailure{
+				Index:  sentOps[itemIdx],
+				LinkID: sentIDs[itemIdx],
+				Err:    *item.Update.Error,
+			})
+		}
+	}
+
+	if len(bulkErr.Failures) != 0 {
+		sort.Slice(bulkErr.Failures, func(l, r int) bool {
+			return bulkErr.Failures[l].Index < bulkErr.Failures[r].Index
+		})
+		return &bulkErr
+	}
+	return nil
+}
+
+// Delete removes the document with the specified link ID.
+func (i *ElasticSearchIndexer) Delete(linkID uuid.UUID) error {
+	refresh := "false"
+	if i.syncUpdates {
+		refresh = "true"
+	}
+
+	res, err := i.es.Delete(indexName, linkID.String(), i.es.Delete.WithRefresh(refresh))
+	if err != nil {
+		return xerrors.Errorf("delete: %w", err)
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		_ = res.Body.Close()
+		return xerrors.Errorf("delete: %w", index.ErrNotFound)
+	}
+
+	var deleteRes esUpdateRes
+	if err = unmarshalResponse(res, &deleteRes); err != nil {
+		return xerrors.Errorf("delete: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteByURLPrefix removes all documents whose URL starts with prefix.
+func (i *ElasticSearchIndexer) DeleteByURLPrefix(prefix string) (uint64, error) {
+	if prefix == "" {
+		return 0, xerrors.Errorf("delete by URL prefix: %w", index.ErrEmptyURLPrefix)
+	}
+
+	var buf bytes.Buffer
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"prefix": map[string]interface{}{
+				"URL": prefix,
+			},
+		},
+	}
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return 0, xerrors.Errorf("delete by URL prefix: %w", err)
+	}
+
+	res, err := i.es.DeleteByQuery(
+		[]string{indexName},
+		&buf,
+		i.es.DeleteByQuery.WithConflicts("proceed"),
+		i.es.DeleteByQuery.WithRefresh(i.syncUpdates),
+	)
+	if err != nil {
+		return 0, xerrors.Errorf("delete by URL prefix: %w", err)
+	}
+
+	var deleteRes esDeleteByQueryRes
+	if err = unmarshalResponse(res, &deleteRes); err != nil {
+		return 0, xerrors.Errorf("delete by URL prefix: %w", err)
+	}
+
+	return deleteRes.Deleted, nil
+}
+
 func ensureIndex(es *elasticsearch.Client) error {
 	mappingsReader := strings.NewReader(esMappings)
 	res, err := es.Indices.Create(indexName, es.Indices.Create.WithBody(mappingsReader))
@@ -14,8 +14,9 @@ type esIterator struct {
 	rsIdx  int
 	rs     *esSearchRes
 
-	latchedDoc *index.Document
-	lastErr    error
+	latchedDoc        *index.Document
+	latchedHighlights *index.Highlights
+	lastErr           error
 }
 
 // Close the iterator and release any allocated resources.
@@ -43,7 +44,14 @@ func (it *esIterator) Next() bool {
 		it.rsIdx = 0
 	}
 
-	it.latchedDoc = mapEsDoc(&it.rs.Hits.HitList[it.rsIdx].DocSource)
+	hit := &it.rs.Hits.HitList[it.rsIdx]
+	it.latchedDoc = mapEsDoc(&hit.DocSource)
+	if _, highlighted := it.searchReq["highlight"]; highlighted {
+		it.latchedHighlights = &index.Highlights{
+			Title:   hit.Highlight["Title"],
+			Content: hit.Highlight["Content"],
+		}
+	}
 	it.cumIdx++
 	it.rsIdx++
 	return true
@@ -59,6 +67,12 @@ func (it *esIterator) Document() *index.Document {
 	return it.latchedDoc
 }
 
+// Highlights returns highlighted fragments of the current document or nil
+// if highlighting was not requested.
+func (it *esIterator) Highlights() *index.Highlights {
+	return it.latchedHighlights
+}
+
 // TotalCount returns the approximate number of search results.
 func (it *esIterator) TotalCount() uint64 {
 	return it.rs.Hits.Total.Count
@@ -0,0 +1,163 @@
+package es
+
+import (
+	"strings"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/textindexer/index"
+)
+
+// esFields maps the fields of the query language to elasticsearch document
+// fields. Unscoped terms and phrases match both the title and the content.
+var esFields = map[string][]string{
+	"":                 {"Title", "Content"},
+	index.FieldTitle:   {"Title"},
+	index.FieldContent: {"Content"},
+}
+
+// esQuery translates a query AST into an elasticsearch query.
+func esQuery(node index.Node) map[string]interface{} {
+	switch n := node.(type) {
+	case *index.TermNode:
+		if n.Field == index.FieldSite {
+			return siteQuery(n.Term)
+		}
+		return matchQuery("best_fields", n.Term, esFields[n.Field])
+	case *index.PhraseNode:
+		return matchQuery("phrase", n.Phrase, esFields[n.Field])
+	case *index.AndNode:
+		var must, mustNot []interface{}
+		for _, child := range n.Children {
+			if not, ok := child.(*index.NotNode); ok {
+				mustNot = append(mustNot, esQuery(not.Child))
+				continue
+			}
+			must = append(must, esQuery(child))
+		}
+		boolQuery := map[string]interface{}{}
+		if len(must) != 0 {
+			boolQuery["must"] = must
+		}
+		if len(mustNot) != 0 {
+			boolQuery["must_not"] = mustNot
+		}
+		return map[string]interface{}{"bool": boolQuery}
+	case *index.OrNode:
+		should := make([]interface{}, len(n.Children))
+		for i, child := range n.Children {
+			should[i] = esQuery(child)
+		}
+		return map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should":               should,
+				"minimum_should_match": 1,
+			},
+		}
+	case *index.NotNode:
+		return map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must_not": []interface{}{esQuery(n.Child)},
+			},
+		}
+	default:
+		return map[string]interface{}{"match_none": map[string]interface{}{}}
+	}
+}
+
+// siteQuery returns a query that matches documents whose URL host is host
+// or one of its subdomains. The query runs against the URL keyword field so
+// that it also matches documents indexed before host filtering was added.
+func siteQuery(host string) map[string]interface{} {
+	return map[string]interface{}{
+		"regexp": map[string]interface{}{
+			"URL": map[string]interface{}{
+				"value":            siteURLPattern(host),
+				"case_insensitive": true,
+			},
+		},
+	}
+}
+
+// siteURLPattern returns a Lucene regular expression that matches absolute
+// URLs whose host is host or one of its subdomains, with optional userinfo
+// and port.
+func siteURLPattern(host string) string {
+	hostPattern := escapeRegexp(host)
+	if strings.ContainsRune(host, ':') {
+		// IPv6 addresses are enclosed in brackets.
+		hostPattern = `\[` + hostPattern + `\]`
+	}
+	return `[a-zA-Z][a-zA-Z0-9+.\-]*://([^/?\#]*\@)?([^/?\#\@:\[\]]*\.)?` + hostPattern + `(:[0-9]*)?([/?\#].*)?`
+}
+
+// escapeRegexp escapes the characters of s that are reserved by the Lucene
+// regular expression syntax.
+func escapeRegexp(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`.?+*|{}[]()"\#@&<>~`, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// filteredQuery applies f to textQuery. The filters are evaluated in filter
+// context so they do not contribute to the relevance score of the results.
+func filteredQuery(textQuery map[string]interface{}, f index.Filter) map[string]interface{} {
+	if f.IsZero() {
+		return textQuery
+	}
+
+	var filters []interface{}
+	if len(f.Hosts) != 0 {
+		hosts := make([]interface{}, len(f.Hosts))
+		for i, host := range f.Hosts {
+			hosts[i] = siteQuery(strings.ToLower(host))
+		}
+		filters = append(filters, map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should":               hosts,
+				"minimum_should_match": 1,
+			},
+		})
+	}
+
+	if !f.IndexedAfter.IsZero() || !f.IndexedBefore.IsZero() {
+		indexedAt := make(map[string]interface{})
+		if !f.IndexedAfter.IsZero() {
+			indexedAt["gt"] = f.IndexedAfter.UTC()
+		}
+		if !f.IndexedBefore.IsZero() {
+			indexedAt["lt"] = f.IndexedBefore.UTC()
+		}
+		filters = append(filters, map[string]interface{}{
+			"range": map[string]interface{}{"IndexedAt": indexedAt},
+		})
+	}
+
+	if f.MinPageRank != 0 {
+		filters = append(filters, map[string]interface{}{
+			"range": map[string]interface{}{
+				"PageRank": map[string]interface{}{"gte": f.MinPageRank},
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"must":   textQuery,
+			"filter": filters,
+		},
+	}
+}
+
+func matchQuery(qtype, expr string, fields []string) map[string]interface{} {
+	return map[string]interface{}{
+		"multi_match": map[string]interface{}{
+			"type":   qtype,
+			"query":  expr,
+			"fields": fields,
+		},
+	}
+}
@@ -0,0 +1,119 @@
+package instrumented
+
+import (
+	"io"
+	"time"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/metrics"
+	"github.com/Waqas-Shah-42/Links-R-Us/textindexer/index"
+	"github.com/google/uuid"
+)
+
+// Compile-time check for ensuring InstrumentedIndexer implements Indexer and
+// BulkIndexer.
+var (
+	_ index.Indexer     = (*InstrumentedIndexer)(nil)
+	_ index.BulkIndexer = (*InstrumentedIndexer)(nil)
+)
+
+// InstrumentedIndexer wraps an index.Indexer and records call counts, error
+// counts, latencies and search result counts for each method.
+type InstrumentedIndexer struct {
+	idx     index.Indexer
+	metrics *metrics.Recorder
+}
+
+// NewInstrumentedIndexer returns an InstrumentedIndexer that wraps idx.
+// Metric names are prefixed with "textindexer".
+func NewInstrumentedIndexer(idx index.Indexer) *InstrumentedIndexer {
+	return &InstrumentedIndexer{
+		idx: idx,
+		metrics: metrics.NewRecorder("textindexer",
+			metrics.SentinelError{Label: "not_found", Err: index.ErrNotFound},
+			metrics.SentinelError{Label: "missing_link_id", Err: index.ErrMissingLinkID},
+			metrics.SentinelError{Label: "empty_url_prefix", Err: index.ErrEmptyURLPrefix},
+		),
+	}
+}
+
+// Metrics returns the recorder that collects the metrics of the indexer. It
+// can be mounted as an http.Handler.
+func (i *InstrumentedIndexer) Metrics() *metrics.Recorder {
+	return i.metrics
+}
+
+// WritePrometheus writes the collected metrics to w using the Prometheus
+// text exposition format.
+func (i *InstrumentedIndexer) WritePrometheus(w io.Writer) error {
+	return i.metrics.WritePrometheus(w)
+}
+
+// Index implements index.Indexer.
+func (i *InstrumentedIndexer) Index(doc *index.Document) (err error) {
+	defer i.observe("Index", time.Now(), &err)
+	return i.idx.Index(doc)
+}
+
+// FindByID implements index.Indexer.
+func (i *InstrumentedIndexer) FindByID(linkID uuid.UUID) (_ *index.Document, err error) {
+	defer i.observe("FindByID", time.Now(), &err)
+	return i.idx.FindByID(linkID)
+}
+
+// Search implements index.Indexer.
+func (i *InstrumentedIndexer) Search(query index.Query) (_ index.Iterator, err error) {
+	defer i.observe("Search", time.Now(), &err)
+	it, err := i.idx.Search(query)
+	if err != nil {
+		return nil, err
+	}
+	return &docIterator{Iterator: it, counter: metrics.NewItemCounter(i.metrics, "Search")}, nil
+}
+
+// UpdateScore implements index.Indexer.
+func (i *InstrumentedIndexer) UpdateScore(linkID uuid.UUID, score float64) (err error) {
+	defer i.observe("UpdateScore", time.Now(), &err)
+	return i.idx.UpdateScore(linkID, score)
+}
+
+// BulkIndex implements index.BulkIndexer. If the wrapped indexer does not
+// support bulk operations, they are applied one at a time.
+func (i *InstrumentedIndexer) BulkIndex(ops []index.BulkOp) (err error) {
+	defer i.observe("BulkIndex", time.Now(), &err)
+	return index.ApplyBulk(i.idx, ops)
+}
+
+// Delete implements index.Indexer.
+func (i *InstrumentedIndexer) Delete(linkID uuid.UUID) (err error) {
+	defer i.observe("Delete", time.Now(), &err)
+	return i.idx.Delete(linkID)
+}
+
+// DeleteByURLPrefix implements index.Indexer.
+func (i *InstrumentedIndexer) DeleteByURLPrefix(prefix string) (_ uint64, err error) {
+	defer i.observe("DeleteByURLPrefix", time.Now(), &err)
+	return i.idx.DeleteByURLPrefix(prefix)
+}
+
+// observe records a call to method that started at start and failed with
+// *err, if not nil.
+func (i *InstrumentedIndexer) observe(method string, start time.Time, err *error) {
+	i.metrics.ObserveSince(method, start, *err)
+}
+
+// docIterator counts the documents returned by the wrapped iterator.
+type docIterator struct {
+	index.Iterator
+	counter *metrics.ItemCounter
+}
+
+// Next implements index.Iterator.
+func (it *docIterator) Next() bool {
+	return it.counter.Count(it.Iterator.Next())
+}
+
+// Close implements index.Iterator.
+func (it *docIterator) Close() error {
+	it.counter.Flush()
+	return it.Iterator.Close()
+}
@@ -0,0 +1,58 @@
+package instrumented
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/textindexer/index"
+	"github.com/Waqas-Shah-42/Links-R-Us/textindexer/index/indextest"
+	"github.com/Waqas-Shah-42/Links-R-Us/textindexer/store/memory"
+	"github.com/google/uuid"
+	gc "gopkg.in/check.v1"
+)
+
+var _ = gc.Suite(new(InstrumentedIndexerTestSuite))
+
+func Test(t *testing.T) { gc.TestingT(t) }
+
+type InstrumentedIndexerTestSuite struct {
+	indextest.SuiteBase
+	bleve *memory.InMemoryBleveIndexer
+	idx   *InstrumentedIndexer
+}
+
+func (s *InstrumentedIndexerTestSuite) SetUpTest(c *gc.C) {
+	bleve, err := memory.NewInMemoryBleveIndexer()
+	c.Assert(err, gc.IsNil)
+	s.bleve = bleve
+	s.idx = NewInstrumentedIndexer(bleve)
+	s.SetIndexer(s.idx)
+}
+
+func (s *InstrumentedIndexerTestSuite) TearDownTest(c *gc.C) {
+	c.Assert(s.bleve.Close(), gc.IsNil)
+}
+
+func (s *InstrumentedIndexerTestSuite) TestMetrics(c *gc.C) {
+	c.Assert(s.idx.Index(&index.Document{LinkID: uuid.New(), Content: "lorem ipsum"}), gc.IsNil)
+	c.Assert(s.idx.Index(&index.Document{Content: "lorem ipsum"}), gc.NotNil)
+	_, err := s.idx.FindByID(uuid.New())
+	c.Assert(err, gc.NotNil)
+
+	it, err := s.idx.Search(index.Query{Type: index.QueryTypeMatch, Expression: "lorem"})
+	c.Assert(err, gc.IsNil)
+	for it.Next() {
+	}
+	c.Assert(it.Close(), gc.IsNil)
+
+	var buf bytes.Buffer
+	c.Assert(s.idx.WritePrometheus(&buf), gc.IsNil)
+	for _, exp := range []string{
+		`textindexer_calls_total{method="Index"} 2`,
+		`textindexer_errors_total{method="Index",error="missing_link_id"} 1`,
+		`textindexer_errors_total{method="FindByID",error="not_found"} 1`,
+		`textindexer_iterator_items_total{method="Search"} 1`,
+	} {
+		c.Assert(bytes.Contains(buf.Bytes(), []byte(exp+"\n")), gc.Equals, true, gc.Commentf("expected output to contain %q:\n%s", exp, buf.String()))
+	}
+}
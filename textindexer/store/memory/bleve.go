@@ -1,11 +1,16 @@
 package memory
 
 import (
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/Waqas-Shah-42/Links-R-Us/textindexer/index"
 	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/search/highlight/format/html"
+	simpleFragmenter "github.com/blevesearch/bleve/search/highlight/fragmenter/simple"
+	"github.com/blevesearch/bleve/search/highlight/highlighter/simple"
 	"github.com/blevesearch/bleve/search/query"
 	"github.com/google/uuid"
 	"golang.org/x/xerrors"
@@ -13,7 +18,10 @@ import (
 
 const batchSize = 10
 
-var _ index.Indexer = (*InMemoryBleveIndexer)(nil)
+var (
+	_ index.Indexer     = (*InMemoryBleveIndexer)(nil)
+	_ index.BulkIndexer = (*InMemoryBleveIndexer)(nil)
+)
 
 type InMemoryBleveIndexer struct {
 	mu   sync.RWMutex
@@ -23,13 +31,24 @@ type InMemoryBleveIndexer struct {
 }
 
 type bleveDoc struct {
-	Title    string
-	Content  string
-	PageRank float64
+	Title     string
+	Content   string
+	Host      string
+	IndexedAt time.Time
+	PageRank  float64
 }
 
 func NewInMemoryBleveIndexer() (*InMemoryBleveIndexer, error) {
+	// Hosts are matched verbatim by site: queries and are excluded from
+	// unscoped searches.
+	hostMapping := bleve.NewTextFieldMapping()
+	hostMapping.Analyzer = keyword.Name
+	hostMapping.IncludeInAll = false
+	docMapping := bleve.NewDocumentMapping()
+	docMapping.AddFieldMappingsAt("Host", hostMapping)
+
 	mapping := bleve.NewIndexMapping()
+	mapping.DefaultMapping = docMapping
 	idx, err := bleve.NewMemOnly(mapping)
 	if err != nil {
 		return nil, err
@@ -43,9 +62,11 @@ func NewInMemoryBleveIndexer() (*InMemoryBleveIndexer, error) {
 
 func makeBleveDoc(d *index.Document) bleveDoc {
 	return bleveDoc{
-		Title:    d.Title,
-		Content:  d.Content,
-		PageRank: d.PageRank,
+		Title:     d.Title,
+		Content:   d.Content,
+		Host:      index.URLHost(d.URL),
+		IndexedAt: d.IndexedAt,
+		PageRank:  d.PageRank,
 	}
 }
 
@@ -103,24 +124,167 @@ func (i *InMemoryBleveIndexer) UpdateScore(linkID uuid.UUID, score float64) erro
 	return nil
 }
 
+// BulkIndex applies ops using a single bleve batch.
+func (i *InMemoryBleveIndexer) BulkIndex(ops []index.BulkOp) error {
+	var (
+		bulkErr index.BulkError
+		batch   = i.idx.NewBatch()
+		staged  = make(map[string]*index.Document)
+		now     = time.Now()
+	)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	lookup := func(key string) *index.Document {
+		if doc, exists := staged[key]; exists {
+			return doc
+		}
+		return i.docs[key]
+	}
+
+	for opIdx, op := range ops {
+		var doc *index.Document
+		switch op.Type {
+		case index.BulkOpIndex:
+			if op.Document == nil || op.Document.LinkID == uuid.Nil {
+				bulkErr.Failures = append(bulkErr.Failures, index.BulkFailure{
+					Index: opIdx,
+					Err:   xerrors.Errorf("index: %w", index.ErrMissingLinkID),
+				})
+				continue
+			}
+			op.Document.IndexedAt = now
+			doc = copyDoc(op.Document)
+			if orig := lookup(doc.LinkID.String()); orig != nil {
+				doc.PageRank = orig.PageRank
+			}
+		case index.BulkOpUpdateScore:
+			doc = &index.Document{LinkID: op.LinkID}
+			if orig := lookup(op.LinkID.String()); orig != nil {
+				doc = copyDoc(orig)
+			}
+			doc.PageRank = op.Score
+		default:
+			bulkErr.Failures = append(bulkErr.Failures, index.BulkFailure{
+				Index:  opIdx,
+				LinkID: op.LinkID,
+				Err:    xerrors.Errorf("unknown bulk operation type %d", op.Type),
+			})
+			continue
+		}
+
+		key := doc.LinkID.String()
+		if err := batch.Index(key, makeBleveDoc(doc)); err != nil {
+			bulkErr.Failures = append(bulkErr.Failures, index.BulkFailure{Index: opIdx, LinkID: doc.LinkID, Err: err})
+			continue
+		}
+		staged[key] = doc
+	}
+
+	if batch.Size() != 0 {
+		if err := i.idx.Batch(batch); err != nil {
+			return xerrors.Errorf("bulk index: %w", err)
+		}
+	}
+	for key, doc := range staged {
+		i.docs[key] = doc
+	}
+
+	if len(bulkErr.Failures) != 0 {
+		return &bulkErr
+	}
+	return nil
+}
+
+// Delete removes the document with the specified link ID.
+func (i *InMemoryBleveIndexer) Delete(linkID uuid.UUID) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	key := linkID.String()
+	if _, found := i.docs[key]; !found {
+		return xerrors.Errorf("delete: %w", index.ErrNotFound)
+	}
+
+	if err := i.idx.Delete(key); err != nil {
+		return xerrors.Errorf("delete: %w", err)
+	}
+	delete(i.docs, key)
+	return nil
+}
+
+// DeleteByURLPrefix removes all documents whose URL starts with prefix.
+func (i *InMemoryBleveIndexer) DeleteByURLPrefix(prefix string) (uint64, error) {
+	if prefix == "" {
+		return 0, xerrors.Errorf("delete by URL prefix: %w", index.ErrEmptyURLPrefix)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var keys []string
+	batch := i.idx.NewBatch()
+	for key, doc := range i.docs {
+		if strings.HasPrefix(doc.URL, prefix) {
+			batch.Delete(key)
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	if err := i.idx.Batch(batch); err != nil {
+		return 0, xerrors.Errorf("delete by URL prefix: %w", err)
+	}
+	for _, key := range keys {
+		delete(i.docs, key)
+	}
+	return uint64(len(keys)), nil
+}
+
 func (i *InMemoryBleveIndexer) Search(q index.Query) (index.Iterator, error) {
+	if err := q.Filter.Validate(); err != nil {
+		return nil, xerrors.Errorf("search: %w", err)
+	}
+
 	var bq query.Query
 	switch q.Type {
 	case index.QueryTypePhrase:
 		bq = bleve.NewMatchPhraseQuery(q.Expression)
+	case index.QueryTypeAdvanced:
+		node, err := index.ParseQuery(q.Expression)
+		if err != nil {
+			return nil, xerrors.Errorf("search: %w", err)
+		}
+		bq = bleveQuery(node)
 	default:
 		bq = bleve.NewMatchQuery(q.Expression)
 	}
+	bq = filteredQuery(bq, q.Filter)
 
 	searchReq := bleve.NewSearchRequest(bq)
 	searchReq.SortBy([]string{"-PageRank", "_score"})
 	searchReq.Size = batchSize
 	searchReq.From = int(q.Offset)
+	searchReq.IncludeLocations = q.Highlight != nil
 	rs, err := i.idx.Search(searchReq)
 	if err != nil {
 		return nil, xerrors.Errorf("search: %w", err)
 	}
-	return &bleveIterator{idx: i, searchReq: searchReq, rs: rs, cumIdx: q.Offset}, nil
+
+	it := &bleveIterator{idx: i, searchReq: searchReq, rs: rs, cumIdx: q.Offset}
+	if q.Highlight != nil {
+		fragmentSize, numFragments := q.Highlight.Sizes()
+		it.highlighter = simple.NewHighlighter(
+			simpleFragmenter.NewFragmenter(fragmentSize),
+			html.NewFragmentFormatter(index.HighlightPreTag, index.HighlightPostTag),
+			"",
+		)
+		it.numFragments = numFragments
+	}
+	return it, nil
 }
 
 // Close the indexer and release any allocated resources.
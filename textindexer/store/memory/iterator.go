@@ -3,6 +3,9 @@ package memory
 import (
 	"github.com/Waqas-Shah-42/Links-R-Us/textindexer/index"
 	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/document"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/highlight/highlighter/simple"
 )
 
 type bleveIterator struct {
@@ -13,8 +16,12 @@ type bleveIterator struct {
 	rsIdx  int
 	rs     *bleve.SearchResult
 
-	latchedDoc *index.Document
-	lastErr    error
+	highlighter  *simple.Highlighter
+	numFragments int
+
+	latchedDoc        *index.Document
+	latchedHighlights *index.Highlights
+	lastErr           error
 }
 
 // Next loads next document matching query.
@@ -31,10 +38,13 @@ func (it *bleveIterator) Next() bool {
 		it.rsIdx = 0
 	}
 
-	nextID := it.rs.Hits[it.rsIdx].ID
-	if it.latchedDoc, it.lastErr = it.idx.findByID(nextID); it.lastErr != nil {
+	hit := it.rs.Hits[it.rsIdx]
+	if it.latchedDoc, it.lastErr = it.idx.findByID(hit.ID); it.lastErr != nil {
 		return false
 	}
+	if it.highlighter != nil {
+		it.latchedHighlights = it.highlight(hit, it.latchedDoc)
+	}
 	it.cumIdx++
 	it.rsIdx++
 	return true
@@ -50,6 +60,23 @@ func (it *bleveIterator) Close() error {
 	return nil
 }
 
+// highlight returns the best fragments of doc around the terms matched by
+// hit.
+func (it *bleveIterator) highlight(hit *search.DocumentMatch, doc *index.Document) *index.Highlights {
+	bleveDoc := document.NewDocument(hit.ID).
+		AddField(document.NewTextField("Title", nil, []byte(doc.Title))).
+		AddField(document.NewTextField("Content", nil, []byte(doc.Content)))
+
+	var highlights index.Highlights
+	if len(hit.Locations["Title"]) != 0 {
+		highlights.Title = it.highlighter.BestFragmentsInField(hit, bleveDoc, "Title", it.numFragments)
+	}
+	if len(hit.Locations["Content"]) != 0 {
+		highlights.Content = it.highlighter.BestFragmentsInField(hit, bleveDoc, "Content", it.numFragments)
+	}
+	return &highlights
+}
+
 // Error returns the last error encountered by the iterator.
 func (it *bleveIterator) Error() error {
 	return it.lastErr
@@ -60,6 +87,12 @@ func (it *bleveIterator) Document() *index.Document {
 	return it.latchedDoc
 }
 
+// Highlights returns highlighted fragments of the current document or nil
+// if highlighting was not requested.
+func (it *bleveIterator) Highlights() *index.Highlights {
+	return it.latchedHighlights
+}
+
 // TotalCount returns the approximate number of search results.
 func (it *bleveIterator) TotalCount() uint64 {
 	if it.rs == nil {
@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"strings"
+
+	"github.com/Waqas-Shah-42/Links-R-Us/textindexer/index"
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/search/query"
+)
+
+// bleveFields maps the fields of the query language to bleve document
+// fields. Unscoped terms and phrases match any text field via "_all".
+var bleveFields = map[string]string{
+	index.FieldTitle:   "Title",
+	index.FieldContent: "Content",
+}
+
+// bleveQuery translates a query AST into a bleve query.
+func bleveQuery(node index.Node) query.Query {
+	switch n := node.(type) {
+	case *index.TermNode:
+		if n.Field == index.FieldSite {
+			return siteQuery(n.Term)
+		}
+		q := bleve.NewMatchQuery(n.Term)
+		q.SetField(bleveFields[n.Field])
+		return q
+	case *index.PhraseNode:
+		q := bleve.NewMatchPhraseQuery(n.Phrase)
+		q.SetField(bleveFields[n.Field])
+		return q
+	case *index.AndNode:
+		var must, mustNot []query.Query
+		for _, child := range n.Children {
+			if not, ok := child.(*index.NotNode); ok {
+				mustNot = append(mustNot, bleveQuery(not.Child))
+				continue
+			}
+			must = append(must, bleveQuery(child))
+		}
+		return booleanQuery(must, mustNot)
+	case *index.OrNode:
+		children := make([]query.Query, len(n.Children))
+		for i, child := range n.Children {
+			children[i] = bleveQuery(child)
+		}
+		return bleve.NewDisjunctionQuery(children...)
+	case *index.NotNode:
+		return booleanQuery(nil, []query.Query{bleveQuery(n.Child)})
+	default:
+		return bleve.NewMatchNoneQuery()
+	}
+}
+
+// siteQuery returns a query that matches documents whose host is host or
+// one of its subdomains.
+func siteQuery(host string) *query.DisjunctionQuery {
+	exact := bleve.NewTermQuery(host)
+	exact.SetField("Host")
+	subdomains := bleve.NewWildcardQuery("*." + host)
+	subdomains.SetField("Host")
+	return bleve.NewDisjunctionQuery(exact, subdomains)
+}
+
+// filteredQuery restricts the results of q to documents matching f. As
+// bleve has no filter context, documents that fail any of the filters are
+// excluded via a must-not clause, which does not contribute to the
+// relevance score of the results.
+func filteredQuery(q query.Query, f index.Filter) query.Query {
+	var failed []query.Query
+	if len(f.Hosts) != 0 {
+		hosts := bleve.NewDisjunctionQuery()
+		for _, host := range f.Hosts {
+			hosts.AddQuery(siteQuery(strings.ToLower(host)))
+		}
+		failed = append(failed, booleanQuery(nil, []query.Query{hosts}))
+	}
+
+	if !f.IndexedAfter.IsZero() || !f.IndexedBefore.IsZero() {
+		exclusive := false
+		indexedAt := bleve.NewDateRangeInclusiveQuery(f.IndexedAfter, f.IndexedBefore, &exclusive, &exclusive)
+		indexedAt.SetField("IndexedAt")
+		failed = append(failed, booleanQuery(nil, []query.Query{indexedAt}))
+	}
+
+	if f.MinPageRank != 0 {
+		inclusive := true
+		pageRank := bleve.NewNumericRangeInclusiveQuery(&f.MinPageRank, nil, &inclusive, nil)
+		pageRank.SetField("PageRank")
+		failed = append(failed, booleanQuery(nil, []query.Query{pageRank}))
+	}
+
+	if len(failed) == 0 {
+		return q
+	}
+	return booleanQuery([]query.Query{q}, failed)
+}
+
+// booleanQuery returns a query that matches all must queries and none of
+// the mustNot queries. Bleve only returns results for boolean queries with
+// positive clauses, so a match-all clause is added if must is empty.
+func booleanQuery(must, mustNot []query.Query) query.Query {
+	if len(must) == 0 {
+		must = []query.Query{bleve.NewMatchAllQuery()}
+	}
+	q := bleve.NewBooleanQuery()
+	q.AddMust(must...)
+	q.AddMustNot(mustNot...)
+	return q
+}